@@ -33,21 +33,32 @@ type App struct {
 	SessionStore     storage.SessionStore
 	PKCEStore        storage.PKCEStore
 	JWTManager       *utils.JWTManager
+	KeyService       *services.KeyService
 	ClientService    *services.ClientService
+	ClientPolicy     *services.ClientPolicy
+	ClientAuth       *services.ClientAuthenticator
+	PARService       *services.PARService
+	RequestObjects   *services.RequestObjectService
+	AuthRequests     *services.AuthorizationRequestService
+	ConsentService   *services.ConsentService
 	AuthService      *services.AuthService
 	TokenService     *services.TokenService
 	PKCEService      *services.PKCEService
 	SessionService   *services.SessionService
 	ScopeService     *services.ScopeService
+	ResourceService  *services.ResourceService
 	UserService      *services.UserService
 	DashboardService *services.DashboardService
 	AuditService     *services.AuditService
 
+	TrustedIssuerService *services.TrustedIssuerService
+
 	IntrospectionHandler *handlers.IntrospectionHandler
 	RevocationHandler    *handlers.RevocationHandler
 	JWKSHandler          *handlers.JWKSHandler
 	DiscoveryHandler     *handlers.DiscoveryHandler
 	UserInfoHandler      *handlers.UserInfoHandler
+	RegistrationHandler  *handlers.RegistrationHandler
 	AdminHandler         *handlers.AdminHandler
 	HealthHandler        *handlers.HealthHandler
 
@@ -122,42 +133,81 @@ func run() error {
 	auditStore := mongodb.NewAuditRepository(db)
 	sessionStore := redis.NewSessionRepository(redisClient)
 	pkceStore := redis.NewPKCERepository(redisClient)
+	denylistStore := redis.NewDenylistRepository(redisClient)
+	replayStore := redis.NewReplayRepository(redisClient)
+	parStore := redis.NewPARRepository(redisClient)
+	authRequestStore := redis.NewAuthorizationRequestRepository(redisClient)
+	signingKeyStore := mongodb.NewSigningKeyRepository(db)
+	consentStore := mongodb.NewConsentRepository(db)
+	scopeStore := mongodb.NewScopeRepository(db)
+	resourceStore := mongodb.NewResourceServerRepository(db)
+	trustedIssuerStore := mongodb.NewTrustedIssuerRepository(db)
 	logger.Info("data stores initialized")
 
 	// --- Initialize Services & Utilities ---
 	healthChecker := services.NewHealthChecker(mongoClient, redisClient)
 
-	jwtManager, err := utils.NewJWTManager(cfg.JWT)
-	if err != nil {
-		logger.Error("failed to initialize JWT Manager", "error", err)
-		return fmt.Errorf("failed to initialize JWT Manager: %w", err)
+	jwtManager := utils.NewJWTManager(cfg.JWT)
+	keyService := services.NewKeyService(signingKeyStore, jwtManager, cfg.JWT.SecretKey, cfg.Keys)
+	if err := keyService.Initialize(ctx, cfg.JWT.PrivateKeyBase64); err != nil {
+		logger.Error("failed to initialize signing keys", "error", err)
+		return fmt.Errorf("failed to initialize signing keys: %w", err)
 	}
 
+	// Keep the keyring in sync with storage and rotate keys on schedule until shutdown.
+	keyCtx, stopKeyService := context.WithCancel(context.Background())
+	defer stopKeyService()
+	go keyService.Run(keyCtx)
+
 	// --- Initialize Middleware Components ---
 	rateLimiter := middleware.NewRateLimiter(redisClient, cfg.RateLimit, logger)
 	metrics := middleware.NewMetricsMiddleware()
 	logger.Info("middleware components initialized")
 
-	clientService := services.NewClientService(dataStore.Client, cfg.BaseURL)
+	clientService := services.NewClientService(dataStore.Client, cfg.BaseURL, cfg.JWT.SecretKey)
+	// Client-supplied URLs, such as JWKS URLs and request URIs, are fetched with a client
+	// that cannot reach internal addresses.
+	outboundClient := utils.NewOutboundHTTPClient(cfg.Security.OutboundAllowPrivateNetworks)
+	jwksResolver := services.NewJWKSResolver(outboundClient)
 	authService := services.NewAuthService(dataStore.User)
-	tokenService := services.NewTokenService(jwtManager, dataStore.Token, pkceStore)
+	tokenService := services.NewTokenService(jwtManager, dataStore.Token, denylistStore, dataStore.User)
 	pkceService := services.NewPKCEService(pkceStore)
 	auditService := services.NewAuditService(auditStore)
 	sessionService := services.NewSessionService(sessionStore)
-	scopeService := services.NewScopeService()
+	scopeService := services.NewScopeService(scopeStore)
+	if err := scopeService.Initialize(ctx); err != nil {
+		return fmt.Errorf("failed to initialize scope registry: %w", err)
+	}
+
+	// Keep the scope registry in sync with changes made on other replicas until shutdown.
+	scopeCtx, stopScopeService := context.WithCancel(context.Background())
+	defer stopScopeService()
+	go scopeService.Run(scopeCtx)
+
+	clientPolicy := services.NewClientPolicy(scopeService)
+	resourceService := services.NewResourceService(resourceStore, scopeService)
+	clientAuth := services.NewClientAuthenticator(clientService, clientPolicy, jwksResolver, replayStore, cfg.JWT.Issuer)
+	trustedIssuerService := services.NewTrustedIssuerService(trustedIssuerStore, dataStore.User, jwksResolver, replayStore, cfg.JWT.Issuer, cfg.BaseURL)
+
 	userService := services.NewUserService(dataStore.User)
 	dashboardService := services.NewDashboardService(dataStore.Client, dataStore.User, dataStore.Token)
+	parService := services.NewPARService(parStore, cfg.PAR)
+	requestObjects := services.NewRequestObjectService(jwksResolver, jwtManager, outboundClient)
+	authRequests := services.NewAuthorizationRequestService(authRequestStore)
+	consentService := services.NewConsentService(consentStore, cfg.Consent)
+	registrationService := services.NewRegistrationService(clientService, scopeService, jwksResolver, cfg.Registration)
 
 	logger.Info("core services initialized")
 
 	// --- Initialize Handlers ---
 	healthHandler := handlers.NewHealthHandler(healthChecker)
-	introspectionHandler := handlers.NewIntrospectionHandler(logger, clientService, tokenService, jwtManager)
-	revocationHandler := handlers.NewRevocationHandler(logger, clientService, tokenService)
+	introspectionHandler := handlers.NewIntrospectionHandler(logger, clientService, clientAuth, tokenService)
+	revocationHandler := handlers.NewRevocationHandler(logger, clientService, clientAuth, tokenService, cfg.Token.RevokeAccessTokensWithRefreshToken)
 	jwksHandler := handlers.NewJWKSHandler(logger, jwtManager)
-	discoveryHandler := handlers.NewDiscoveryHandler(logger, clientService)
-	userInfoHandler := handlers.NewUserInfoHandler(logger, jwtManager, dataStore.User)
-	adminHandler := handlers.NewAdminHandler(logger, clientService, userService, dashboardService, auditService)
+	discoveryHandler := handlers.NewDiscoveryHandler(logger, clientService, scopeService, jwtManager, parService, cfg.Registration.Enabled)
+	userInfoHandler := handlers.NewUserInfoHandler(logger, tokenService, scopeService, dataStore.User)
+	registrationHandler := handlers.NewRegistrationHandler(logger, registrationService, auditService, cfg.BaseURL)
+	adminHandler := handlers.NewAdminHandler(logger, clientService, userService, dashboardService, auditService, keyService, scopeService, resourceService, trustedIssuerService)
 	logger.Info("metadata handlers initialized")
 
 	// --- Template Cache ---
@@ -176,21 +226,32 @@ func run() error {
 		SessionStore:     sessionStore,
 		PKCEStore:        pkceStore,
 		JWTManager:       jwtManager,
+		KeyService:       keyService,
 		ClientService:    clientService,
+		ClientPolicy:     clientPolicy,
+		ClientAuth:       clientAuth,
+		PARService:       parService,
+		RequestObjects:   requestObjects,
+		AuthRequests:     authRequests,
+		ConsentService:   consentService,
 		AuthService:      authService,
 		TokenService:     tokenService,
 		PKCEService:      pkceService,
 		SessionService:   sessionService,
 		ScopeService:     scopeService,
+		ResourceService:  resourceService,
 		UserService:      userService,
 		DashboardService: dashboardService,
 		AuditService:     auditService,
 
+		TrustedIssuerService: trustedIssuerService,
+
 		IntrospectionHandler: introspectionHandler,
 		RevocationHandler:    revocationHandler,
 		JWKSHandler:          jwksHandler,
 		DiscoveryHandler:     discoveryHandler,
 		UserInfoHandler:      userInfoHandler,
+		RegistrationHandler:  registrationHandler,
 		AdminHandler:         adminHandler,
 		HealthHandler:        healthHandler,
 
@@ -278,18 +339,28 @@ func (a *App) ToServerDependencies() server.AppDependencies {
 		AuthService:      a.AuthService,
 		SessionService:   a.SessionService,
 		ClientService:    a.ClientService,
+		ClientPolicy:     a.ClientPolicy,
+		ClientAuth:       a.ClientAuth,
+		PARService:       a.PARService,
+		RequestObjects:   a.RequestObjects,
+		AuthRequests:     a.AuthRequests,
+		ConsentService:   a.ConsentService,
 		ScopeService:     a.ScopeService,
+		ResourceService:  a.ResourceService,
 		TokenService:     a.TokenService,
 		UserStore:        a.DataStore.User,
 		UserService:      a.UserService,
 		DashboardService: a.DashboardService,
 		AuditService:     a.AuditService,
 
+		TrustedIssuerService: a.TrustedIssuerService,
+
 		IntrospectionHandler: a.IntrospectionHandler,
 		RevocationHandler:    a.RevocationHandler,
 		JWKSHandler:          a.JWKSHandler,
 		DiscoveryHandler:     a.DiscoveryHandler,
 		UserInfoHandler:      a.UserInfoHandler,
+		RegistrationHandler:  a.RegistrationHandler,
 		AdminHandler:         a.AdminHandler,
 		HealthHandler:        a.HealthHandler,
 
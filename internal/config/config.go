@@ -12,16 +12,21 @@ import (
 // Config stores all configuration for the application.
 // The values are read by viper from a config file or environment variables.
 type Config struct {
-	AppEnv    string          `mapstructure:"APP_ENV" validate:"required,oneof=development staging production"`
-	Server    ServerConfig    `mapstructure:",squash"`
-	Mongo     MongoConfig     `mapstructure:",squash"`
-	Redis     RedisConfig     `mapstructure:",squash"`
-	JWT       JWTConfig       `mapstructure:",squash"`
-	Log       LogConfig       `mapstructure:",squash"`
-	CSRF      CSRFConfig      `mapstructure:",squash"`
-	Security  SecurityConfig  `mapstructure:",squash"`
-	RateLimit RateLimitConfig `mapstructure:",squash"`
-	BaseURL   string          `mapstructure:"BASE_URL" validate:"required,url"`
+	AppEnv       string             `mapstructure:"APP_ENV" validate:"required,oneof=development staging production"`
+	Server       ServerConfig       `mapstructure:",squash"`
+	Mongo        MongoConfig        `mapstructure:",squash"`
+	Redis        RedisConfig        `mapstructure:",squash"`
+	JWT          JWTConfig          `mapstructure:",squash"`
+	Log          LogConfig          `mapstructure:",squash"`
+	CSRF         CSRFConfig         `mapstructure:",squash"`
+	Security     SecurityConfig     `mapstructure:",squash"`
+	RateLimit    RateLimitConfig    `mapstructure:",squash"`
+	Token        TokenConfig        `mapstructure:",squash"`
+	Keys         KeyConfig          `mapstructure:",squash"`
+	Registration RegistrationConfig `mapstructure:",squash"`
+	PAR          PARConfig          `mapstructure:",squash"`
+	Consent      ConsentConfig      `mapstructure:",squash"`
+	BaseURL      string             `mapstructure:"BASE_URL" validate:"required,url"`
 }
 
 // ServerConfig holds server-related configuration.
@@ -44,14 +49,24 @@ type RedisConfig struct {
 
 // JWTConfig holds JWT signing and validation details.
 type JWTConfig struct {
-	SecretKey        string `mapstructure:"JWT_SECRET_KEY" validate:"required,min=32"`
-	PrivateKeyBase64 string `mapstructure:"JWT_PRIVATE_KEY_BASE64" validate:"required"`
+	SecretKey string `mapstructure:"JWT_SECRET_KEY" validate:"required,min=32"`
+	// PrivateKeyBase64 seeds the key store with an initial signing key on first start.
+	// When empty, a key is generated instead.
+	PrivateKeyBase64 string `mapstructure:"JWT_PRIVATE_KEY_BASE64"`
 	Issuer           string `mapstructure:"JWT_ISSUER" validate:"required"`
 
 	// These fields are for viper to read the integer values from .env
 	AccessTokenLifespanMinutes int64 `mapstructure:"JWT_ACCESS_TOKEN_LIFESPAN_MINUTES" validate:"required"`
 	RefreshTokenLifespanHours  int64 `mapstructure:"JWT_REFRESH_TOKEN_LIFESPAN_HOURS" validate:"required"`
 
+	// AcceptLegacyAccessTokens keeps accepting access tokens issued before the RFC 9068
+	// profile, with a "JWT" typ and an array scope, until they have all expired.
+	AcceptLegacyAccessTokens bool `mapstructure:"JWT_ACCEPT_LEGACY_ACCESS_TOKENS"`
+
+	// AccessTokenRolesClaim adds the user's role to access tokens as a "roles" claim
+	// (RFC 9068 section 2.2.3.1). It is off by default, as not every resource server needs it.
+	AccessTokenRolesClaim bool `mapstructure:"JWT_ACCESS_TOKEN_ROLES_CLAIM"`
+
 	// These fields are for the application to use, populated after loading config.
 	// They don't have mapstructure tags, so viper ignores them.
 	AccessTokenLifespan  time.Duration
@@ -71,6 +86,9 @@ type LogConfig struct {
 // SecurityConfig holds security-related configuration.
 type SecurityConfig struct {
 	AllowedOrigins []string `mapstructure:"CORS_ALLOWED_ORIGINS"`
+	// OutboundAllowPrivateNetworks lets JWKS URLs and request URIs point at loopback and
+	// private addresses, for development setups where those services run locally.
+	OutboundAllowPrivateNetworks bool `mapstructure:"OUTBOUND_ALLOW_PRIVATE_NETWORKS"`
 }
 
 // RateLimitConfig holds rate limiting settings.
@@ -83,6 +101,66 @@ type RateLimitConfig struct {
 	TokenBurst    int  `mapstructure:"RATE_LIMIT_TOKEN_BURST"`
 }
 
+// TokenConfig holds token lifecycle settings.
+type TokenConfig struct {
+	// RevokeAccessTokensWithRefreshToken makes revoking a refresh token also revoke
+	// every access token minted from the same grant.
+	RevokeAccessTokensWithRefreshToken bool `mapstructure:"REVOKE_ACCESS_TOKENS_WITH_REFRESH_TOKEN"`
+}
+
+// KeyConfig holds signing key rotation settings.
+type KeyConfig struct {
+	// These fields are for viper to read the integer values from .env
+	RotationIntervalHours int64 `mapstructure:"SIGNING_KEY_ROTATION_INTERVAL_HOURS"`
+	GracePeriodHours      int64 `mapstructure:"SIGNING_KEY_GRACE_PERIOD_HOURS" validate:"min=1"`
+	ReloadIntervalSeconds int64 `mapstructure:"SIGNING_KEY_RELOAD_INTERVAL_SECONDS" validate:"min=1"`
+
+	// RotationInterval is how long a key stays active. Zero disables scheduled rotation.
+	RotationInterval time.Duration
+	// GracePeriod is how long a retired key is still published for verification.
+	GracePeriod time.Duration
+	// ReloadInterval is how often keys are reloaded from storage, so that every
+	// replica picks up rotations made elsewhere.
+	ReloadInterval time.Duration
+}
+
+// RegistrationConfig holds dynamic client registration settings.
+type RegistrationConfig struct {
+	Enabled bool `mapstructure:"REGISTRATION_ENABLED"`
+	// InitialAccessTokens, when set, restricts registration to requests bearing one of them.
+	InitialAccessTokens []string `mapstructure:"REGISTRATION_INITIAL_ACCESS_TOKENS"`
+	// SoftwareStatementJWKSURL holds the keys software statements are verified with.
+	// Software statements are rejected when it is empty.
+	SoftwareStatementJWKSURL string `mapstructure:"REGISTRATION_SOFTWARE_STATEMENT_JWKS_URL" validate:"omitempty,url"`
+	SoftwareStatementIssuer  string `mapstructure:"REGISTRATION_SOFTWARE_STATEMENT_ISSUER"`
+	RequireSoftwareStatement bool   `mapstructure:"REGISTRATION_REQUIRE_SOFTWARE_STATEMENT"`
+
+	// AllowedGrantTypes and AllowedScopes limit what dynamically registered clients may
+	// request. Other grant types and scopes can only be given to clients by an admin.
+	AllowedGrantTypes []string `mapstructure:"REGISTRATION_ALLOWED_GRANT_TYPES"`
+	AllowedScopes     []string `mapstructure:"REGISTRATION_ALLOWED_SCOPES"`
+}
+
+// PARConfig holds pushed authorization request settings.
+type PARConfig struct {
+	// Required makes every client use the PAR endpoint, regardless of its own setting.
+	Required bool `mapstructure:"REQUIRE_PUSHED_AUTHORIZATION_REQUESTS"`
+	// This field is for viper to read the integer value from .env
+	RequestURILifetimeSeconds int64 `mapstructure:"PAR_REQUEST_URI_LIFETIME_SECONDS" validate:"min=1"`
+
+	// RequestURILifetime is how long a request_uri can be used at the authorization endpoint.
+	RequestURILifetime time.Duration
+}
+
+// ConsentConfig holds consent grant settings.
+type ConsentConfig struct {
+	// This field is for viper to read the integer value from .env
+	LifetimeDays int64 `mapstructure:"CONSENT_LIFETIME_DAYS" validate:"min=0"`
+
+	// Lifetime is how long an approved consent is remembered. Zero keeps it until revoked.
+	Lifetime time.Duration
+}
+
 // LoadConfig reads configuration from file or environment variables.
 func LoadConfig() (*Config, error) {
 	// Set default values
@@ -92,9 +170,18 @@ func LoadConfig() (*Config, error) {
 	viper.SetDefault("LOG_LEVEL", "info")
 	viper.SetDefault("JWT_ACCESS_TOKEN_LIFESPAN_MINUTES", 15)
 	viper.SetDefault("JWT_REFRESH_TOKEN_LIFESPAN_HOURS", 168)
-	viper.SetDefault("JWT_ISSUER", "oauth2-provider")
+	viper.SetDefault("JWT_ACCEPT_LEGACY_ACCESS_TOKENS", true)
+	viper.SetDefault("JWT_ACCESS_TOKEN_ROLES_CLAIM", false)
 	viper.SetDefault("BASE_URL", "http://localhost:8080")
 	viper.SetDefault("CSRF_AUTH_KEY", "01234567890123456789012345678901")
+	viper.SetDefault("REVOKE_ACCESS_TOKENS_WITH_REFRESH_TOKEN", true)
+	viper.SetDefault("SIGNING_KEY_ROTATION_INTERVAL_HOURS", 720)
+	viper.SetDefault("SIGNING_KEY_GRACE_PERIOD_HOURS", 24)
+	viper.SetDefault("SIGNING_KEY_RELOAD_INTERVAL_SECONDS", 60)
+	viper.SetDefault("REGISTRATION_ALLOWED_GRANT_TYPES", []string{"authorization_code", "refresh_token"})
+	viper.SetDefault("REGISTRATION_ALLOWED_SCOPES", []string{"openid", "profile", "email", "offline"})
+	viper.SetDefault("PAR_REQUEST_URI_LIFETIME_SECONDS", 90)
+	viper.SetDefault("CONSENT_LIFETIME_DAYS", 90)
 
 	// Tell viper to look for a file named .env in the current directory
 	viper.AddConfigPath(".")
@@ -118,6 +205,17 @@ func LoadConfig() (*Config, error) {
 	// Manually convert the loaded integer values into time.Duration
 	config.JWT.AccessTokenLifespan = time.Duration(config.JWT.AccessTokenLifespanMinutes) * time.Minute
 	config.JWT.RefreshTokenLifespan = time.Duration(config.JWT.RefreshTokenLifespanHours) * time.Hour
+	config.Keys.RotationInterval = time.Duration(config.Keys.RotationIntervalHours) * time.Hour
+	config.Keys.GracePeriod = time.Duration(config.Keys.GracePeriodHours) * time.Hour
+	config.Keys.ReloadInterval = time.Duration(config.Keys.ReloadIntervalSeconds) * time.Second
+	config.PAR.RequestURILifetime = time.Duration(config.PAR.RequestURILifetimeSeconds) * time.Second
+	config.Consent.Lifetime = time.Duration(config.Consent.LifetimeDays) * 24 * time.Hour
+
+	// OpenID Connect requires the "iss" claim to equal the URL the discovery document
+	// is served from, so the issuer falls back to the base URL when not set explicitly.
+	if config.JWT.Issuer == "" {
+		config.JWT.Issuer = config.BaseURL
+	}
 
 	// Validate the configuration
 	validate := validator.New()
@@ -21,10 +21,15 @@ type AdminHandler struct {
 	validate         *validator.Validate
 	dashboardService *services.DashboardService
 	auditService     *services.AuditService
+	keyService       *services.KeyService
+	scopeService     *services.ScopeService
+	resourceService  *services.ResourceService
+
+	trustedIssuerService *services.TrustedIssuerService
 }
 
 // NewAdminHandler creates a new AdminHandler.
-func NewAdminHandler(logger *slog.Logger, clientService *services.ClientService, userService *services.UserService, dashboardService *services.DashboardService, auditService *services.AuditService) *AdminHandler {
+func NewAdminHandler(logger *slog.Logger, clientService *services.ClientService, userService *services.UserService, dashboardService *services.DashboardService, auditService *services.AuditService, keyService *services.KeyService, scopeService *services.ScopeService, resourceService *services.ResourceService, trustedIssuerService *services.TrustedIssuerService) *AdminHandler {
 	return &AdminHandler{
 		logger:           logger,
 		clientService:    clientService,
@@ -32,6 +37,11 @@ func NewAdminHandler(logger *slog.Logger, clientService *services.ClientService,
 		validate:         validator.New(),
 		dashboardService: dashboardService,
 		auditService:     auditService,
+		keyService:       keyService,
+		scopeService:     scopeService,
+		resourceService:  resourceService,
+
+		trustedIssuerService: trustedIssuerService,
 	}
 }
 
@@ -56,25 +66,9 @@ func (h *AdminHandler) ListClients(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// For security, we don't expose the secret hash in the list view.
-	type clientResponse struct {
-		ClientID      string   `json:"client_id"`
-		Name          string   `json:"name"`
-		RedirectURIs  []string `json:"redirect_uris"`
-		GrantTypes    []string `json:"grant_types"`
-		ResponseTypes []string `json:"response_types"`
-		Scopes        []string `json:"scopes"`
-	}
-
-	response := make([]clientResponse, len(clients))
-	for i, c := range clients {
-		response[i] = clientResponse{
-			ClientID:      c.ClientID,
-			Name:          c.Name,
-			RedirectURIs:  c.RedirectURIs,
-			GrantTypes:    c.GrantTypes,
-			ResponseTypes: c.ResponseTypes,
-			Scopes:        c.Scopes,
-		}
+	response := make([]map[string]any, len(clients))
+	for i := range clients {
+		response[i] = clientResponse(&clients[i])
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -100,15 +94,9 @@ func (h *AdminHandler) CreateClient(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response := map[string]any{
-		"client_id":      client.ClientID,
-		"client_secret":  plaintextSecret, // IMPORTANT: Show the secret only on creation
-		"name":           client.Name,
-		"redirect_uris":  client.RedirectURIs,
-		"grant_types":    client.GrantTypes,
-		"response_types": client.ResponseTypes,
-		"scopes":         client.Scopes,
-		"jwks_url":       client.JWKSURL,
+	response := clientResponse(client)
+	if plaintextSecret != "" {
+		response["client_secret"] = plaintextSecret // IMPORTANT: Show the secret only on creation
 	}
 
 	user, _ := middleware.GetUserFromContext(r)
@@ -151,19 +139,8 @@ func (h *AdminHandler) GetClient(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// We don't expose the secret hash.
-	response := map[string]any{
-		"client_id":      client.ClientID,
-		"name":           client.Name,
-		"redirect_uris":  client.RedirectURIs,
-		"grant_types":    client.GrantTypes,
-		"response_types": client.ResponseTypes,
-		"scopes":         client.Scopes,
-		"jwks_url":       client.JWKSURL,
-	}
-
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	json.NewEncoder(w).Encode(clientResponse(client))
 }
 
 // UpdateClient handles the request to update a client.
@@ -188,18 +165,43 @@ func (h *AdminHandler) UpdateClient(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Respond with the updated client data (no secret).
-	response := map[string]any{
-		"client_id":      updatedClient.ClientID,
-		"name":           updatedClient.Name,
-		"redirect_uris":  updatedClient.RedirectURIs,
-		"grant_types":    updatedClient.GrantTypes,
-		"response_types": updatedClient.ResponseTypes,
-		"scopes":         updatedClient.Scopes,
-		"jwks_url":       updatedClient.JWKSURL,
-	}
-
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	json.NewEncoder(w).Encode(clientResponse(updatedClient))
+}
+
+// clientResponse builds the admin API representation of a client.
+// The secret hash is never exposed.
+func clientResponse(client *models.Client) map[string]any {
+	resp := map[string]any{
+		"client_id":      client.ClientID,
+		"name":           client.Name,
+		"redirect_uris":  client.RedirectURIs,
+		"grant_types":    client.GrantTypes,
+		"response_types": client.ResponseTypes,
+		"scopes":         client.Scopes,
+		"jwks_url":       client.JWKSURL,
+
+		"post_logout_redirect_uris":             client.PostLogoutRedirectURIs,
+		"token_endpoint_auth_method":            client.TokenEndpointAuthMethod,
+		"refresh_token_rotation_disabled":       client.RefreshTokenRotationDisabled,
+		"require_pushed_authorization_requests": client.RequirePushedAuthorizationRequests,
+		"id_token_signed_response_alg":          client.IDTokenSignedResponseAlg,
+		"access_token_signing_alg":              client.AccessTokenSigningAlg,
+		"access_token_format":                   client.AccessTokenFormat,
+		"request_object_signing_alg":            client.RequestObjectSigningAlg,
+		"request_uris":                          client.RequestURIs,
+		"require_signed_request_object":         client.RequireSignedRequestObject,
+		"authorization_signed_response_alg":     client.AuthorizationSignedResponseAlg,
+		"default_response_mode":                 client.DefaultResponseMode,
+		"trusted":                               client.Trusted,
+		"required_scopes":                       client.RequiredScopes,
+		"token_exchange_audiences":              client.TokenExchangeAudiences,
+		"token_exchange_scopes":                 client.TokenExchangeScopes,
+	}
+	if client.JWKS != "" {
+		resp["jwks"] = json.RawMessage(client.JWKS)
+	}
+	return resp
 }
 
 // ListUsers handles the request to list all users.
@@ -342,3 +344,437 @@ func (h *AdminHandler) ListAuditEvents(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
+
+// ListKeys handles the request to list all JWT signing keys. Private key material is never exposed.
+func (h *AdminHandler) ListKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.keyService.ListKeys(r.Context())
+	if err != nil {
+		utils.HandleAPIError(w, r, h.logger, err)
+		return
+	}
+
+	response := make([]map[string]any, len(keys))
+	for i := range keys {
+		response[i] = keyResponse(&keys[i])
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// RotateKeys handles the request to rotate the active signing keys on demand.
+// An optional "alg" query parameter limits the rotation to one algorithm.
+func (h *AdminHandler) RotateKeys(w http.ResponseWriter, r *http.Request) {
+	var algs []string
+	if alg := r.URL.Query().Get("alg"); alg != "" {
+		algs = append(algs, alg)
+	}
+
+	keys, err := h.keyService.Rotate(r.Context(), algs...)
+	if err != nil {
+		utils.HandleAPIError(w, r, h.logger, err)
+		return
+	}
+
+	response := make([]map[string]any, len(keys))
+	for i := range keys {
+		response[i] = keyResponse(&keys[i])
+		h.recordKeyEvent(r, models.SigningKeyRotated, keys[i].KeyID, "Admin rotated the "+keys[i].Algorithm+" signing key via API.")
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// RevokeKey handles the request to revoke a signing key immediately.
+func (h *AdminHandler) RevokeKey(w http.ResponseWriter, r *http.Request) {
+	keyID := r.PathValue("kid")
+	if err := h.keyService.Revoke(r.Context(), keyID); err != nil {
+		utils.HandleAPIError(w, r, h.logger, err)
+		return
+	}
+
+	h.recordKeyEvent(r, models.SigningKeyRevoked, keyID, "Admin revoked the signing key via API.")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// recordKeyEvent writes an audit event for a signing key operation.
+func (h *AdminHandler) recordKeyEvent(r *http.Request, eventType models.EventType, keyID, details string) {
+	user, _ := middleware.GetUserFromContext(r)
+	eventData := services.RecordEventData{
+		EventType: eventType,
+		ActorID:   user.ID.Hex(),
+		TargetID:  keyID,
+		IPAddress: middleware.GetClientIP(r),
+		UserAgent: r.UserAgent(),
+		Details:   details,
+	}
+	_ = h.auditService.Record(r.Context(), eventData)
+}
+
+// keyResponse builds the admin API representation of a signing key.
+func keyResponse(key *models.SigningKey) map[string]any {
+	response := map[string]any{
+		"kid":        key.KeyID,
+		"alg":        key.Algorithm,
+		"state":      key.State,
+		"created_at": key.CreatedAt.Format(time.RFC3339),
+	}
+	for name, t := range map[string]time.Time{
+		"activated_at": key.ActivatedAt,
+		"retired_at":   key.RetiredAt,
+		"revoked_at":   key.RevokedAt,
+	} {
+		if !t.IsZero() {
+			response[name] = t.Format(time.RFC3339)
+		}
+	}
+	return response
+}
+
+// ListScopes handles the request to list the scope registry.
+func (h *AdminHandler) ListScopes(w http.ResponseWriter, r *http.Request) {
+	scopes, err := h.scopeService.ListScopes(r.Context())
+	if err != nil {
+		utils.HandleAPIError(w, r, h.logger, err)
+		return
+	}
+
+	response := make([]map[string]any, len(scopes))
+	for i := range scopes {
+		response[i] = scopeResponse(&scopes[i])
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// CreateScope handles the request to register a new scope.
+func (h *AdminHandler) CreateScope(w http.ResponseWriter, r *http.Request) {
+	var req services.CreateScopeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.HandleAPIError(w, r, h.logger, utils.ErrBadRequest)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		utils.HandleAPIError(w, r, h.logger, &utils.AppError{Code: "VALIDATION_ERROR", Message: err.Error(), HTTPStatus: http.StatusBadRequest})
+		return
+	}
+
+	scope, err := h.scopeService.CreateScope(r.Context(), req)
+	if err != nil {
+		utils.HandleAPIError(w, r, h.logger, err)
+		return
+	}
+
+	h.recordRegistryEvent(r, models.ScopeCreated, scope.Name, "Admin created new scope via API.")
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(scopeResponse(scope))
+}
+
+// GetScope handles the request to retrieve a single scope.
+func (h *AdminHandler) GetScope(w http.ResponseWriter, r *http.Request) {
+	scope, err := h.scopeService.GetScope(r.Context(), r.PathValue("name"))
+	if err != nil {
+		utils.HandleAPIError(w, r, h.logger, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(scopeResponse(scope))
+}
+
+// UpdateScope handles the request to update a scope.
+func (h *AdminHandler) UpdateScope(w http.ResponseWriter, r *http.Request) {
+	var req services.UpdateScopeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.HandleAPIError(w, r, h.logger, utils.ErrBadRequest)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		utils.HandleAPIError(w, r, h.logger, &utils.AppError{Code: "VALIDATION_ERROR", Message: err.Error(), HTTPStatus: http.StatusBadRequest})
+		return
+	}
+
+	scope, err := h.scopeService.UpdateScope(r.Context(), r.PathValue("name"), req)
+	if err != nil {
+		utils.HandleAPIError(w, r, h.logger, err)
+		return
+	}
+
+	h.recordRegistryEvent(r, models.ScopeUpdated, scope.Name, "Admin updated scope via API.")
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(scopeResponse(scope))
+}
+
+// DeleteScope handles the request to remove a scope from the registry.
+func (h *AdminHandler) DeleteScope(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if err := h.scopeService.DeleteScope(r.Context(), name); err != nil {
+		utils.HandleAPIError(w, r, h.logger, err)
+		return
+	}
+
+	h.recordRegistryEvent(r, models.ScopeDeleted, name, "Admin deleted scope via API.")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// recordRegistryEvent writes an audit event for a change to the scope, resource server or
+// trusted issuer registry.
+func (h *AdminHandler) recordRegistryEvent(r *http.Request, eventType models.EventType, name, details string) {
+	user, _ := middleware.GetUserFromContext(r)
+	eventData := services.RecordEventData{
+		EventType: eventType,
+		ActorID:   user.ID.Hex(),
+		TargetID:  name,
+		IPAddress: middleware.GetClientIP(r),
+		UserAgent: r.UserAgent(),
+		Details:   details,
+	}
+	_ = h.auditService.Record(r.Context(), eventData)
+}
+
+// scopeResponse builds the admin API representation of a scope.
+func scopeResponse(scope *models.Scope) map[string]any {
+	return map[string]any{
+		"name":             scope.Name,
+		"description":      scope.Description,
+		"api":              scope.API,
+		"claims":           scope.Claims,
+		"consent_required": scope.ConsentRequired,
+		"default":          scope.Default,
+		"parameters":       scopeParametersResponse(scope.Parameters),
+		"implies":          scope.Implies,
+		"created_at":       scope.CreatedAt.Format(time.RFC3339),
+		"updated_at":       scope.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+// scopeParametersResponse converts the parameters of a scope template to their JSON form.
+func scopeParametersResponse(params []models.ScopeParameter) []map[string]string {
+	resp := make([]map[string]string, 0, len(params))
+	for _, p := range params {
+		resp = append(resp, map[string]string{"name": p.Name, "type": p.Type})
+	}
+	return resp
+}
+
+// ListResourceServers handles the request to list the resource server registry.
+func (h *AdminHandler) ListResourceServers(w http.ResponseWriter, r *http.Request) {
+	resources, err := h.resourceService.ListResourceServers(r.Context())
+	if err != nil {
+		utils.HandleAPIError(w, r, h.logger, err)
+		return
+	}
+
+	response := make([]map[string]any, len(resources))
+	for i := range resources {
+		response[i] = h.resourceServerResponse(&resources[i])
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// CreateResourceServer handles the request to register a new resource server.
+func (h *AdminHandler) CreateResourceServer(w http.ResponseWriter, r *http.Request) {
+	var req services.ResourceServerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.HandleAPIError(w, r, h.logger, utils.ErrBadRequest)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		utils.HandleAPIError(w, r, h.logger, &utils.AppError{Code: "VALIDATION_ERROR", Message: err.Error(), HTTPStatus: http.StatusBadRequest})
+		return
+	}
+
+	resource, err := h.resourceService.CreateResourceServer(r.Context(), req)
+	if err != nil {
+		utils.HandleAPIError(w, r, h.logger, err)
+		return
+	}
+
+	h.recordRegistryEvent(r, models.ResourceServerCreated, resource.Identifier, "Admin created new resource server via API.")
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(h.resourceServerResponse(resource))
+}
+
+// GetResourceServer handles the request to retrieve a single resource server.
+func (h *AdminHandler) GetResourceServer(w http.ResponseWriter, r *http.Request) {
+	resource, err := h.resourceService.GetResourceServer(r.Context(), r.PathValue("id"))
+	if err != nil {
+		utils.HandleAPIError(w, r, h.logger, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.resourceServerResponse(resource))
+}
+
+// UpdateResourceServer handles the request to update a resource server.
+func (h *AdminHandler) UpdateResourceServer(w http.ResponseWriter, r *http.Request) {
+	var req services.ResourceServerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.HandleAPIError(w, r, h.logger, utils.ErrBadRequest)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		utils.HandleAPIError(w, r, h.logger, &utils.AppError{Code: "VALIDATION_ERROR", Message: err.Error(), HTTPStatus: http.StatusBadRequest})
+		return
+	}
+
+	resource, err := h.resourceService.UpdateResourceServer(r.Context(), r.PathValue("id"), req)
+	if err != nil {
+		utils.HandleAPIError(w, r, h.logger, err)
+		return
+	}
+
+	h.recordRegistryEvent(r, models.ResourceServerUpdated, resource.Identifier, "Admin updated resource server via API.")
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.resourceServerResponse(resource))
+}
+
+// DeleteResourceServer handles the request to remove a resource server from the registry.
+func (h *AdminHandler) DeleteResourceServer(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if err := h.resourceService.DeleteResourceServer(r.Context(), id); err != nil {
+		utils.HandleAPIError(w, r, h.logger, err)
+		return
+	}
+
+	h.recordRegistryEvent(r, models.ResourceServerDeleted, id, "Admin deleted resource server via API.")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// resourceServerResponse builds the admin API representation of a resource server,
+// including the registry scopes it owns.
+func (h *AdminHandler) resourceServerResponse(resource *models.ResourceServer) map[string]any {
+	return map[string]any{
+		"id":         resource.ID.Hex(),
+		"identifier": resource.Identifier,
+		"name":       resource.Name,
+		"scopes":     h.resourceService.OwnedScopes(resource),
+		"created_at": resource.CreatedAt.Format(time.RFC3339),
+		"updated_at": resource.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+// ListTrustedIssuers handles the request to list the trusted issuers of JWT bearer assertions.
+func (h *AdminHandler) ListTrustedIssuers(w http.ResponseWriter, r *http.Request) {
+	issuers, err := h.trustedIssuerService.ListTrustedIssuers(r.Context())
+	if err != nil {
+		utils.HandleAPIError(w, r, h.logger, err)
+		return
+	}
+
+	response := make([]map[string]any, len(issuers))
+	for i := range issuers {
+		response[i] = trustedIssuerResponse(&issuers[i])
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// CreateTrustedIssuer handles the request to register a new trusted issuer.
+func (h *AdminHandler) CreateTrustedIssuer(w http.ResponseWriter, r *http.Request) {
+	var req services.TrustedIssuerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.HandleAPIError(w, r, h.logger, utils.ErrBadRequest)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		utils.HandleAPIError(w, r, h.logger, &utils.AppError{Code: "VALIDATION_ERROR", Message: err.Error(), HTTPStatus: http.StatusBadRequest})
+		return
+	}
+
+	issuer, err := h.trustedIssuerService.CreateTrustedIssuer(r.Context(), req)
+	if err != nil {
+		utils.HandleAPIError(w, r, h.logger, err)
+		return
+	}
+
+	h.recordRegistryEvent(r, models.TrustedIssuerCreated, issuer.Issuer, "Admin created new trusted issuer via API.")
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(trustedIssuerResponse(issuer))
+}
+
+// GetTrustedIssuer handles the request to retrieve a single trusted issuer.
+func (h *AdminHandler) GetTrustedIssuer(w http.ResponseWriter, r *http.Request) {
+	issuer, err := h.trustedIssuerService.GetTrustedIssuer(r.Context(), r.PathValue("id"))
+	if err != nil {
+		utils.HandleAPIError(w, r, h.logger, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(trustedIssuerResponse(issuer))
+}
+
+// UpdateTrustedIssuer handles the request to update a trusted issuer.
+func (h *AdminHandler) UpdateTrustedIssuer(w http.ResponseWriter, r *http.Request) {
+	var req services.TrustedIssuerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.HandleAPIError(w, r, h.logger, utils.ErrBadRequest)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		utils.HandleAPIError(w, r, h.logger, &utils.AppError{Code: "VALIDATION_ERROR", Message: err.Error(), HTTPStatus: http.StatusBadRequest})
+		return
+	}
+
+	issuer, err := h.trustedIssuerService.UpdateTrustedIssuer(r.Context(), r.PathValue("id"), req)
+	if err != nil {
+		utils.HandleAPIError(w, r, h.logger, err)
+		return
+	}
+
+	h.recordRegistryEvent(r, models.TrustedIssuerUpdated, issuer.Issuer, "Admin updated trusted issuer via API.")
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(trustedIssuerResponse(issuer))
+}
+
+// DeleteTrustedIssuer handles the request to remove a trusted issuer from the registry.
+func (h *AdminHandler) DeleteTrustedIssuer(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if err := h.trustedIssuerService.DeleteTrustedIssuer(r.Context(), id); err != nil {
+		utils.HandleAPIError(w, r, h.logger, err)
+		return
+	}
+
+	h.recordRegistryEvent(r, models.TrustedIssuerDeleted, id, "Admin deleted trusted issuer via API.")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// trustedIssuerResponse builds the admin API representation of a trusted issuer.
+func trustedIssuerResponse(issuer *models.TrustedIssuer) map[string]any {
+	mappings := make([]map[string]string, 0, len(issuer.SubjectMappings))
+	for _, m := range issuer.SubjectMappings {
+		mappings = append(mappings, map[string]string{"subject": m.Subject, "user_id": m.UserID})
+	}
+	resp := map[string]any{
+		"id":               issuer.ID.Hex(),
+		"issuer":           issuer.Issuer,
+		"name":             issuer.Name,
+		"jwks_url":         issuer.JWKSURL,
+		"allowed_subjects": issuer.AllowedSubjects,
+		"subject_mappings": mappings,
+		"scopes":           issuer.Scopes,
+		"allowed_clients":  issuer.AllowedClients,
+		"created_at":       issuer.CreatedAt.Format(time.RFC3339),
+		"updated_at":       issuer.UpdatedAt.Format(time.RFC3339),
+	}
+	if issuer.JWKS != "" {
+		resp["jwks"] = json.RawMessage(issuer.JWKS)
+	}
+	return resp
+}
@@ -2,11 +2,14 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"log/slog"
 	"net/http"
 	"net/url"
 	"slices"
+	"strconv"
 	"strings"
 	"time"
 
@@ -14,17 +17,24 @@ import (
 	"github.com/aminshahid573/authexa/internal/models"
 	"github.com/aminshahid573/authexa/internal/services"
 	"github.com/aminshahid573/authexa/internal/utils"
-	"github.com/golang-jwt/jwt/v5"
-	"github.com/lestrrat-go/jwx/v2/jwk"
 )
 
 // AuthHandler handles OAuth2 authorization and token requests.
 type AuthHandler struct {
-	logger        *slog.Logger
-	templateCache utils.TemplateCache
-	clientService *services.ClientService
-	scopeService  *services.ScopeService
-	tokenService  *services.TokenService
+	logger               *slog.Logger
+	templateCache        utils.TemplateCache
+	clientService        *services.ClientService
+	clientPolicy         *services.ClientPolicy
+	clientAuthenticator  *services.ClientAuthenticator
+	parService           *services.PARService
+	requestObjectService *services.RequestObjectService
+	authRequestService   *services.AuthorizationRequestService
+	consentService       *services.ConsentService
+	scopeService         *services.ScopeService
+	resourceService      *services.ResourceService
+	trustedIssuerService *services.TrustedIssuerService
+	tokenService         *services.TokenService
+	auditService         *services.AuditService
 }
 
 // NewAuthHandler creates a new AuthHandler.
@@ -32,20 +42,59 @@ func NewAuthHandler(
 	logger *slog.Logger,
 	templateCache utils.TemplateCache,
 	clientService *services.ClientService,
+	clientPolicy *services.ClientPolicy,
+	clientAuthenticator *services.ClientAuthenticator,
+	parService *services.PARService,
+	requestObjectService *services.RequestObjectService,
+	authRequestService *services.AuthorizationRequestService,
+	consentService *services.ConsentService,
 	scopeService *services.ScopeService,
+	resourceService *services.ResourceService,
+	trustedIssuerService *services.TrustedIssuerService,
 	tokenService *services.TokenService,
+	auditService *services.AuditService,
 ) *AuthHandler {
 	return &AuthHandler{
-		logger:        logger,
-		templateCache: templateCache,
-		clientService: clientService,
-		scopeService:  scopeService,
-		tokenService:  tokenService,
+		logger:               logger,
+		templateCache:        templateCache,
+		clientService:        clientService,
+		clientPolicy:         clientPolicy,
+		clientAuthenticator:  clientAuthenticator,
+		parService:           parService,
+		requestObjectService: requestObjectService,
+		authRequestService:   authRequestService,
+		consentService:       consentService,
+		scopeService:         scopeService,
+		resourceService:      resourceService,
+		trustedIssuerService: trustedIssuerService,
+		tokenService:         tokenService,
+		auditService:         auditService,
 	}
 }
 
 // --- Standard Authorization Code Flow ---
 
+// errPKCERequired is returned when an authorization request is made without PKCE.
+var errPKCERequired = &utils.AppError{Code: "invalid_request", Message: "PKCE with code_challenge_method S256 is required.", HTTPStatus: http.StatusBadRequest}
+
+// errAccessDenied is sent to the client when the user denies the authorization request.
+var errAccessDenied = &utils.AppError{Code: "access_denied", Message: "The user denied the authorization request.", HTTPStatus: http.StatusForbidden}
+
+// errLoginRequired and errConsentRequired are sent to the client when prompt=none forbids
+// showing the login or consent page the request needs.
+var (
+	errLoginRequired   = &utils.AppError{Code: "login_required", Message: "The user must log in.", HTTPStatus: http.StatusUnauthorized}
+	errConsentRequired = &utils.AppError{Code: "consent_required", Message: "The user must consent to the request.", HTTPStatus: http.StatusForbidden}
+)
+
+// errInvalidIDTokenHint is sent to the client when the id_token_hint was not issued by this
+// server to the client.
+var errInvalidIDTokenHint = &utils.AppError{Code: "invalid_request", Message: "The id_token_hint is invalid.", HTTPStatus: http.StatusBadRequest}
+
+// errSignedRequestObjectRequired is returned when a client that must sign its authorization
+// requests sends plain parameters instead.
+var errSignedRequestObjectRequired = &utils.AppError{Code: "invalid_request", Message: "This client must send a signed request object.", HTTPStatus: http.StatusBadRequest}
+
 // AuthorizeFlow is a single handler that routes to GET or POST logic for the standard user-facing flow.
 func (h *AuthHandler) AuthorizeFlow(w http.ResponseWriter, r *http.Request) {
 	if r.Method == http.MethodGet {
@@ -59,60 +108,183 @@ func (h *AuthHandler) AuthorizeFlow(w http.ResponseWriter, r *http.Request) {
 
 // showConsentPage handles the GET request to the authorization endpoint.
 func (h *AuthHandler) showConsentPage(w http.ResponseWriter, r *http.Request) {
-	queryParams := r.URL.Query()
-	clientID := queryParams.Get("client_id")
-	redirectURI := queryParams.Get("redirect_uri")
-	responseType := queryParams.Get("response_type")
-	scope := queryParams.Get("scope")
-
-	codeChallenge := queryParams.Get("code_challenge")
-	codeChallengeMethod := queryParams.Get("code_challenge_method")
-
-	if codeChallenge != "" {
-		if codeChallengeMethod != "S256" {
-			utils.HandleError(w, r, h.logger, h.templateCache, &utils.AppError{Code: "invalid_request", Message: "code_challenge_method must be S256.", HTTPStatus: http.StatusBadRequest})
+	// A request the user was sent to log in for continues from its server-side copy.
+	if id := r.URL.Query().Get("resume"); id != "" {
+		h.resumeAuthorization(w, r, id)
+		return
+	}
+
+	params, client, err := h.resolveAuthorizationRequest(r.Context(), r.URL.Query())
+	if err != nil {
+		utils.HandleError(w, r, h.logger, h.templateCache, err)
+		return
+	}
+	// Until the redirect_uri is known to belong to the client, errors must not be sent to it.
+	if err := h.validateRedirectURI(client, params); err != nil {
+		utils.HandleError(w, r, h.logger, h.templateCache, err)
+		return
+	}
+
+	// Errors from here on are delivered to the client the way its response would be.
+	target := &models.AuthorizationRequest{
+		ClientID:     client.ClientID,
+		RedirectURI:  params.Get("redirect_uri"),
+		State:        params.Get("state"),
+		ResponseMode: params.Get("response_mode"),
+	}
+	if err := h.validateAuthorizationParameters(r.Context(), client, params); err != nil {
+		h.sendAuthorizationError(w, r, client, target, err)
+		return
+	}
+
+	authReq := h.authRequestService.New(client.ClientID, params)
+	if len(authReq.Scopes) == 0 {
+		authReq.Scopes = h.clientPolicy.DefaultScopes(client)
+	}
+	if hint := params.Get("id_token_hint"); hint != "" {
+		claims, err := h.tokenService.ParseIDTokenHint(hint)
+		if err != nil || !slices.Contains(claims.Audience, client.ClientID) {
+			h.sendAuthorizationError(w, r, client, target, errInvalidIDTokenHint)
 			return
 		}
+		authReq.HintSubject = claims.Subject
 	}
+	h.authenticateUser(w, r, client, authReq)
+}
 
-	if clientID == "" || redirectURI == "" || responseType != "code" {
-		utils.HandleError(w, r, h.logger, h.templateCache, utils.ErrBadRequest)
+// resumeAuthorization continues an authorization request once the user has logged in.
+func (h *AuthHandler) resumeAuthorization(w http.ResponseWriter, r *http.Request, id string) {
+	// Requests waiting for a login are not bound to a session yet.
+	authReq, err := h.authRequestService.Consume(r.Context(), id, "")
+	if err != nil {
+		utils.HandleError(w, r, h.logger, h.templateCache, err)
 		return
 	}
-
-	client, err := h.clientService.GetClient(r.Context(), clientID)
+	client, err := h.clientService.GetClient(r.Context(), authReq.ClientID)
 	if err != nil {
 		utils.HandleError(w, r, h.logger, h.templateCache, utils.ErrInvalidClient)
 		return
 	}
+	h.authenticateUser(w, r, client, authReq)
+}
 
-	// The provided redirect_uri MUST be one of the URIs registered by the client.
-	if !slices.Contains(client.RedirectURIs, redirectURI) {
-		h.logger.Warn("invalid redirect_uri provided", "client_id", clientID, "provided_uri", redirectURI)
-		utils.HandleError(w, r, h.logger, h.templateCache, &utils.AppError{Code: "invalid_request", Message: "The provided redirect_uri is not registered for this client.", HTTPStatus: http.StatusBadRequest})
+// authenticateUser checks the user's session against the prompt, max_age and hints of an
+// authorization request. When the user has to log in, the request is stored and the user is
+// sent to the login page, which returns them to resume it. Otherwise the consent page is shown.
+func (h *AuthHandler) authenticateUser(w http.ResponseWriter, r *http.Request, client *models.Client, authReq *models.AuthorizationRequest) {
+	user, _ := middleware.GetUserFromContext(r)
+	session, _ := middleware.GetSessionFromContext(r)
+
+	if loginRequired(user, session, authReq) {
+		if authReq.HasPrompt(models.PromptNone) {
+			h.sendAuthorizationError(w, r, client, authReq, errLoginRequired)
+			return
+		}
+		if err := h.authRequestService.Save(r.Context(), authReq, ""); err != nil {
+			h.sendAuthorizationError(w, r, client, authReq, err)
+			return
+		}
+		query := url.Values{"return_to": {"/oauth2/authorize?resume=" + url.QueryEscape(authReq.ID)}}
+		if authReq.LoginHint != "" {
+			query.Set("login_hint", authReq.LoginHint)
+		}
+		http.Redirect(w, r, "/login?"+query.Encode(), http.StatusSeeOther)
 		return
 	}
 
-	requestedScopes := strings.Fields(scope)
-	if !h.scopeService.ValidateScopes(requestedScopes) {
-		utils.HandleError(w, r, h.logger, h.templateCache, utils.ErrBadRequest)
+	grant, err := h.consentService.GetGrant(r.Context(), user.ID.Hex(), client.ClientID)
+	if err != nil {
+		h.sendAuthorizationError(w, r, client, authReq, err)
 		return
 	}
+	var grantedScopes []string
+	if grant != nil {
+		grantedScopes = grant.Scopes
+	}
+	// Scopes approved before, and those the registry grants without consent, are not asked for.
+	newScopes := slices.DeleteFunc(slices.Clone(authReq.Scopes), func(scope string) bool {
+		return h.scopeService.Covers(grantedScopes, scope) || !h.scopeService.RequiresConsent(scope)
+	})
+	if authReq.IncludeGrantedScopes {
+		for _, scope := range grantedScopes {
+			if !slices.Contains(authReq.Scopes, scope) {
+				authReq.Scopes = append(authReq.Scopes, scope)
+			}
+		}
+	}
 
-	scopeDetails := h.scopeService.GetScopeDetails(requestedScopes)
+	// Trusted clients have implicit consent, and so does a request with nothing left to approve,
+	// unless the client asks for the user to be prompted again. A request without any scope
+	// still needs the user's approval the first time.
+	consented := client.Trusted || (len(newScopes) == 0 && (grant != nil || len(authReq.Scopes) > 0))
+	if consented && !authReq.HasPrompt(models.PromptConsent) {
+		h.issueAuthorizationCode(w, r, client, authReq, user, session)
+		return
+	}
+	if authReq.HasPrompt(models.PromptNone) {
+		h.sendAuthorizationError(w, r, client, authReq, errConsentRequired)
+		return
+	}
+
+	// The validated request is kept server-side; the consent form only references it.
+	authReq.PendingScopes = newScopes
+	if err := h.authRequestService.Save(r.Context(), authReq, session.ID); err != nil {
+		h.sendAuthorizationError(w, r, client, authReq, err)
+		return
+	}
+
+	// Only the scopes the user has not approved yet are shown.
+	var scopeDetails []consentScope
+	for _, scope := range h.scopeService.GetScopeDetails(newScopes) {
+		scopeDetails = append(scopeDetails, consentScope{Scope: scope, Required: client.IsScopeRequired(scope.Name)})
+	}
 	data := map[string]any{
-		"ClientName":  client.Name,
-		"Scopes":      scopeDetails,
-		"QueryParams": queryParams,
+		"ClientName": client.Name,
+		"Scopes":     scopeDetails,
+		"RequestID":  authReq.ID,
 	}
 	h.templateCache.Render(w, r, "base.html", "consent.html", data)
 }
 
+// consentScope is a scope as listed on the consent page.
+type consentScope struct {
+	models.Scope
+	Required bool // Whether the user can deselect the scope
+}
+
+// loginRequired reports whether the user has to log in before answering an authorization
+// request. A login completed after the request was made satisfies prompt=login, select_account,
+// max_age and hints naming another account, so the user is never asked twice.
+func loginRequired(user *models.User, session *models.Session, authReq *models.AuthorizationRequest) bool {
+	if user == nil || session == nil {
+		return true
+	}
+	if session.AuthTime.After(authReq.CreatedAt) {
+		return false
+	}
+
+	if authReq.HasPrompt(models.PromptLogin) || authReq.HasPrompt(models.PromptSelectAccount) {
+		return true
+	}
+	if authReq.MaxAge != nil && time.Since(session.AuthTime) > time.Duration(*authReq.MaxAge)*time.Second {
+		return true
+	}
+	if authReq.HintSubject != "" && authReq.HintSubject != user.ID.Hex() {
+		return true
+	}
+	return authReq.LoginHint != "" && authReq.LoginHint != user.Username
+}
+
 // handleConsent handles the POST request from the consent form.
 func (h *AuthHandler) handleConsent(w http.ResponseWriter, r *http.Request) {
 	user, ok := middleware.GetUserFromContext(r)
 	if !ok {
-		utils.HandleError(w, r, h.logger, h.templateCache, utils.ErrInternal)
+		utils.HandleError(w, r, h.logger, h.templateCache, utils.ErrUnauthorized)
+		return
+	}
+	session, ok := middleware.GetSessionFromContext(r)
+	if !ok {
+		utils.HandleError(w, r, h.logger, h.templateCache, utils.ErrUnauthorized)
 		return
 	}
 
@@ -121,52 +293,181 @@ func (h *AuthHandler) handleConsent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	clientID := r.PostForm.Get("client_id")
-	redirectURIStr := r.PostForm.Get("redirect_uri")
-	state := r.PostForm.Get("state")
-	scope := r.PostForm.Get("scope")
-
-	codeChallenge := r.PostForm.Get("code_challenge")
-	codeChallengeMethod := r.PostForm.Get("code_challenge_method")
-
-	redirectURL, err := url.Parse(redirectURIStr)
+	// Only the request ID comes from the form. Everything else was validated when the
+	// consent page was shown and is loaded from the server-side request.
+	authReq, err := h.authRequestService.Consume(r.Context(), r.PostForm.Get("request_id"), session.ID)
 	if err != nil {
-		utils.HandleError(w, r, h.logger, h.templateCache, utils.ErrBadRequest)
+		utils.HandleError(w, r, h.logger, h.templateCache, err)
+		return
+	}
+	client, err := h.clientService.GetClient(r.Context(), authReq.ClientID)
+	if err != nil {
+		utils.HandleError(w, r, h.logger, h.templateCache, utils.ErrInvalidClient)
 		return
 	}
-	query := redirectURL.Query()
 
 	if r.PostForm.Get("consent") != "allow" {
-		query.Set("error", "access_denied")
-		if state != "" {
-			query.Set("state", state)
-		}
-		redirectURL.RawQuery = query.Encode()
-		http.Redirect(w, r, redirectURL.String(), http.StatusSeeOther)
+		h.sendAuthorizationError(w, r, client, authReq, errAccessDenied)
 		return
 	}
 
-	requestedScopes := strings.Fields(scope)
-	code, err := h.tokenService.GenerateAndStoreAuthorizationCode(r.Context(), user.ID.Hex(), clientID, requestedScopes)
+	// The user may deselect pending scopes, except those the client requires. Scopes
+	// approved before were not shown and stay granted.
+	selected := r.PostForm["scope"]
+	authReq.Scopes = slices.DeleteFunc(authReq.Scopes, func(scope string) bool {
+		return slices.Contains(authReq.PendingScopes, scope) && !client.IsScopeRequired(scope) && !slices.Contains(selected, scope)
+	})
+
+	if err := h.consentService.Grant(r.Context(), user.ID.Hex(), client.ClientID, authReq.Scopes); err != nil {
+		h.sendAuthorizationError(w, r, client, authReq, err)
+		return
+	}
+	h.issueAuthorizationCode(w, r, client, authReq, user, session)
+}
+
+// issueAuthorizationCode sends the client an authorization code for an approved request.
+func (h *AuthHandler) issueAuthorizationCode(w http.ResponseWriter, r *http.Request, client *models.Client, authReq *models.AuthorizationRequest, user *models.User, session *models.Session) {
+	// Bind the full authorization request context to the code so the token endpoint
+	// can enforce an exact redirect_uri match, PKCE and the OIDC nonce.
+	authCodeParams := services.AuthorizationCodeParams{
+		UserID:              user.ID.Hex(),
+		ClientID:            authReq.ClientID,
+		Scopes:              authReq.Scopes,
+		RedirectURI:         authReq.RedirectURI,
+		Nonce:               authReq.Nonce,
+		CodeChallenge:       authReq.CodeChallenge,
+		CodeChallengeMethod: authReq.CodeChallengeMethod,
+		SessionID:           session.ID,
+		AuthTime:            session.AuthTime,
+		AMR:                 session.AMR,
+		ACR:                 session.ACR,
+		Resources:           authReq.Resources,
+	}
+
+	code, err := h.tokenService.GenerateAndStoreAuthorizationCode(r.Context(), authCodeParams)
 	if err != nil {
-		utils.HandleError(w, r, h.logger, h.templateCache, err)
+		h.sendAuthorizationError(w, r, client, authReq, err)
 		return
 	}
 
-	if codeChallenge != "" && codeChallengeMethod == "S256" {
-		err := h.tokenService.StorePKCEChallenge(r.Context(), code, codeChallenge)
-		if err != nil {
-			utils.HandleError(w, r, h.logger, h.templateCache, err)
-			return
+	response := url.Values{"code": {code}}
+	if authReq.State != "" {
+		response.Set("state", authReq.State)
+	}
+	h.sendAuthorizationResponse(w, r, client, authReq, response)
+}
+
+// resolveAuthorizationRequest returns the parameters of an authorization request and its client.
+// A request_uri from the PAR endpoint replaces every other parameter but client_id, and is
+// invalidated once used. Clients required to use PAR must send a request_uri.
+// Otherwise a request object, by value or by reference, is verified and its claims merged in.
+func (h *AuthHandler) resolveAuthorizationRequest(ctx context.Context, params url.Values) (url.Values, *models.Client, error) {
+	clientID := params.Get("client_id")
+	if clientID == "" {
+		return nil, nil, utils.ErrBadRequest
+	}
+
+	client, err := h.clientService.GetClient(ctx, clientID)
+	if err != nil {
+		return nil, nil, utils.ErrInvalidClient
+	}
+
+	requestURI := params.Get("request_uri")
+	if !strings.HasPrefix(requestURI, models.RequestURIPrefix) {
+		if h.parService.Required(client) {
+			return nil, nil, &utils.AppError{Code: "invalid_request", Message: "This client must use pushed authorization requests.", HTTPStatus: http.StatusBadRequest}
+		}
+		if !services.HasRequestObject(params) {
+			if client.RequireSignedRequestObject {
+				return nil, nil, errSignedRequestObjectRequired
+			}
+			return params, client, nil
+		}
+		if params, err = h.requestObjectService.Resolve(ctx, client, params); err != nil {
+			return nil, nil, err
+		}
+		return params, client, nil
+	}
+
+	if params, err = h.parService.Consume(ctx, clientID, requestURI); err != nil {
+		return nil, nil, err
+	}
+	return params, client, nil
+}
+
+// validateAuthorizationRequest checks the parameters of an authorization request against
+// the client's registration, as the PAR endpoint does before storing a request.
+func (h *AuthHandler) validateAuthorizationRequest(ctx context.Context, client *models.Client, params url.Values) error {
+	if err := h.validateRedirectURI(client, params); err != nil {
+		return err
+	}
+	return h.validateAuthorizationParameters(ctx, client, params)
+}
+
+// validateRedirectURI checks that the request names one of the client's registered redirect URIs.
+func (h *AuthHandler) validateRedirectURI(client *models.Client, params url.Values) error {
+	redirectURI := params.Get("redirect_uri")
+	if redirectURI == "" {
+		return &utils.AppError{Code: "invalid_request", Message: "The redirect_uri parameter is required.", HTTPStatus: http.StatusBadRequest}
+	}
+
+	// The provided redirect_uri MUST be one of the URIs registered by the client.
+	if !slices.Contains(client.RedirectURIs, redirectURI) {
+		h.logger.Warn("invalid redirect_uri provided", "client_id", client.ClientID, "provided_uri", redirectURI)
+		return &utils.AppError{Code: "invalid_request", Message: "The provided redirect_uri is not registered for this client.", HTTPStatus: http.StatusBadRequest}
+	}
+	return nil
+}
+
+// validateAuthorizationParameters checks the remaining parameters of an authorization request
+// once its redirect URI is trusted. The errors carry RFC 6749 error codes for the client.
+func (h *AuthHandler) validateAuthorizationParameters(ctx context.Context, client *models.Client, params url.Values) error {
+	codeChallenge := params.Get("code_challenge")
+
+	responseType := params.Get("response_type")
+	if responseType == "" {
+		return &utils.AppError{Code: "invalid_request", Message: "The response_type parameter is required.", HTTPStatus: http.StatusBadRequest}
+	}
+	if err := h.clientPolicy.CheckResponseType(client, responseType); err != nil {
+		return err
+	}
+
+	if mode := params.Get("response_mode"); mode != "" && !slices.Contains(models.SupportedResponseModes, mode) {
+		return &utils.AppError{Code: "invalid_request", Message: "The response_mode is not supported.", HTTPStatus: http.StatusBadRequest}
+	}
+
+	prompt := strings.Fields(params.Get("prompt"))
+	for _, value := range prompt {
+		if !slices.Contains(models.SupportedPrompts, value) {
+			return &utils.AppError{Code: "invalid_request", Message: "The prompt value " + value + " is not supported.", HTTPStatus: http.StatusBadRequest}
+		}
+	}
+	if slices.Contains(prompt, models.PromptNone) && len(prompt) > 1 {
+		return &utils.AppError{Code: "invalid_request", Message: "prompt=none cannot be combined with other values.", HTTPStatus: http.StatusBadRequest}
+	}
+	if maxAge := params.Get("max_age"); maxAge != "" {
+		if seconds, err := strconv.Atoi(maxAge); err != nil || seconds < 0 {
+			return &utils.AppError{Code: "invalid_request", Message: "max_age must be a non-negative number of seconds.", HTTPStatus: http.StatusBadRequest}
 		}
 	}
 
-	query.Set("code", code)
-	if state != "" {
-		query.Set("state", state)
+	if codeChallenge == "" && params.Has("code_challenge_method") {
+		return &utils.AppError{Code: "invalid_request", Message: "code_challenge_method was sent without a code_challenge.", HTTPStatus: http.StatusBadRequest}
+	}
+	if codeChallenge != "" && params.Get("code_challenge_method") != "S256" {
+		return &utils.AppError{Code: "invalid_request", Message: "code_challenge_method must be S256.", HTTPStatus: http.StatusBadRequest}
+	}
+
+	// PKCE protects every client against an intercepted authorization code, and is the only
+	// protection for public clients, which cannot authenticate at the token endpoint.
+	if codeChallenge == "" {
+		return errPKCERequired
+	}
+
+	if err := h.clientPolicy.CheckScopes(client, strings.Fields(params.Get("scope"))); err != nil {
+		return err
 	}
-	redirectURL.RawQuery = query.Encode()
-	http.Redirect(w, r, redirectURL.String(), http.StatusSeeOther)
+	return h.resourceService.ValidateResources(ctx, params["resource"])
 }
 
 // --- Device Authorization Flow ---
@@ -178,18 +479,31 @@ func (h *AuthHandler) DeviceAuthorization(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	clientID := r.PostForm.Get("client_id")
-	scope := r.PostForm.Get("scope")
-
-	client, err := h.clientService.GetClient(r.Context(), clientID)
+	// Confidential clients authenticate as at the token endpoint (RFC 8628 section 3.1).
+	client, err := h.authenticateClient(r, "/oauth2/device_authorization")
 	if err != nil {
-		utils.HandleError(w, r, h.logger, h.templateCache, utils.ErrInvalidClient)
+		h.writeTokenError(w, "invalid_client", "Client authentication failed.")
+		return
+	}
+	if err := h.clientPolicy.CheckGrantType(client, models.GrantTypeDeviceCode); err != nil {
+		h.writeTokenRequestError(w, err)
+		return
+	}
+	scopes := strings.Fields(r.PostForm.Get("scope"))
+	if err := h.clientPolicy.CheckScopes(client, scopes); err != nil {
+		h.writeTokenRequestError(w, err)
+		return
+	}
+	resources := r.PostForm["resource"]
+	if err := h.resourceService.ValidateResources(r.Context(), resources); err != nil {
+		h.writeTokenRequestError(w, err)
 		return
 	}
 
-	deviceCode, userCode, err := h.tokenService.GenerateAndStoreDeviceCode(r.Context(), client.ClientID, strings.Fields(scope))
+	deviceCode, userCode, err := h.tokenService.GenerateAndStoreDeviceCode(r.Context(), client.ClientID, scopes, resources)
 	if err != nil {
-		utils.HandleError(w, r, h.logger, h.templateCache, err)
+		h.logger.Error("failed to generate device code", "error", err)
+		h.writeTokenError(w, "server_error", "The server encountered an error.")
 		return
 	}
 
@@ -203,6 +517,7 @@ func (h *AuthHandler) DeviceAuthorization(w http.ResponseWriter, r *http.Request
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(response)
 }
@@ -271,6 +586,26 @@ func (h *AuthHandler) HandleDeviceConsent(w http.ResponseWriter, r *http.Request
 
 // --- Token Endpoint ---
 
+// supportedGrantTypes lists every grant type routed by Token.
+// It is advertised in the discovery document and must be kept in sync with the switch below.
+var supportedGrantTypes = []string{
+	models.GrantTypeAuthorizationCode,
+	models.GrantTypeClientCredentials,
+	models.GrantTypeRefreshToken,
+	models.GrantTypeDeviceCode,
+	models.GrantTypeJWTBearer,
+	models.GrantTypeTokenExchange,
+}
+
+// tokenEndpointAuthMethods lists the client authentication methods accepted by Token.
+var tokenEndpointAuthMethods = []string{
+	models.ClientAuthMethodSecretBasic,
+	models.ClientAuthMethodSecretPost,
+	models.ClientAuthMethodNone,
+	models.ClientAuthMethodPrivateKeyJWT,
+	models.ClientAuthMethodSecretJWT,
+}
+
 // Token handles POST requests to the token endpoint for all grant types.
 func (h *AuthHandler) Token(w http.ResponseWriter, r *http.Request) {
 	if err := r.ParseForm(); err != nil {
@@ -283,105 +618,72 @@ func (h *AuthHandler) Token(w http.ResponseWriter, r *http.Request) {
 	h.logger.Info("token request received", "grant_type", grantType)
 
 	switch grantType {
-	case "authorization_code":
+	case models.GrantTypeAuthorizationCode:
 		h.handleAuthorizationCodeGrant(w, r)
-	case "client_credentials":
+	case models.GrantTypeClientCredentials:
 		h.handleClientCredentialsGrant(w, r)
-	case "refresh_token":
+	case models.GrantTypeRefreshToken:
 		h.handleRefreshTokenGrant(w, r)
-	case "urn:ietf:params:oauth:grant-type:device_code":
+	case models.GrantTypeDeviceCode:
 		h.handleDeviceCodeGrant(w, r)
-	case "urn:ietf:params:oauth:grant-type:jwt-bearer":
+	case models.GrantTypeJWTBearer:
 		h.handleJWTBearerGrant(w, r)
+	case models.GrantTypeTokenExchange:
+		h.handleTokenExchangeGrant(w, r)
 	default:
 		h.logger.Warn("unsupported grant type requested", "grant_type", grantType)
 		h.writeTokenError(w, "unsupported_grant_type", "The authorization grant type is not supported.")
 	}
 }
 
-// handleJWTBearerGrant processes the jwt-bearer grant type.
+// handleJWTBearerGrant processes the jwt-bearer grant type (RFC 7523 section 2.1). The
+// authenticated client presents an assertion signed by a trusted issuer, and receives an
+// access token for the local user the assertion's subject maps to.
 func (h *AuthHandler) handleJWTBearerGrant(w http.ResponseWriter, r *http.Request) {
-	assertionStr := r.PostForm.Get("assertion")
-	if assertionStr == "" {
-		h.writeTokenError(w, "invalid_request", "Missing assertion parameter.")
+	client, err := h.authenticateClient(r, "/oauth2/token")
+	if err != nil {
+		h.writeTokenError(w, "invalid_client", "Client authentication failed.")
 		return
 	}
 
-	// 1. Parse the assertion without validating the signature yet.
-	// This allows us to read the claims and find out which client is making the request.
-	token, _, err := new(jwt.Parser).ParseUnverified(assertionStr, jwt.MapClaims{})
-	if err != nil {
-		h.writeTokenError(w, "invalid_grant", "Assertion is malformed.")
+	if err := h.clientPolicy.CheckGrantType(client, models.GrantTypeJWTBearer); err != nil {
+		h.writeTokenRequestError(w, err)
 		return
 	}
 
-	// 2. Extract client_id from the 'iss' (issuer) claim.
-	clientID, err := token.Claims.GetIssuer()
-	if err != nil {
-		h.writeTokenError(w, "invalid_grant", "Assertion is missing 'iss' claim.")
+	assertion := r.PostForm.Get("assertion")
+	if assertion == "" {
+		h.writeTokenError(w, "invalid_request", "Missing assertion parameter.")
 		return
 	}
-
-	// 3. Fetch the client from our database.
-	client, err := h.clientService.GetClient(r.Context(), clientID)
+	subject, err := h.trustedIssuerService.VerifyAssertion(r.Context(), assertion, client.ClientID)
 	if err != nil {
-		h.writeTokenError(w, "invalid_client", "Client not found.")
+		if errors.Is(err, services.ErrInvalidAssertion) {
+			h.logger.Warn("rejected JWT bearer assertion", "client_id", client.ClientID, "error", err)
+		}
+		h.writeTokenRequestError(w, err)
 		return
 	}
 
-	// 4. Validate that the client is allowed to use this grant type.
-	if !slices.Contains(client.GrantTypes, models.GrantTypeJWTBearer) {
-		h.writeTokenError(w, "unauthorized_client", "The client is not authorized to use this grant type.")
-		return
-	}
-	if client.JWKSURL == "" {
-		h.writeTokenError(w, "unauthorized_client", "Client is not configured for JWT Bearer grant (missing JWKS URL).")
+	scopes, err := h.clientPolicy.AssertionScopes(client, subject.Issuer, strings.Fields(r.PostForm.Get("scope")))
+	if err != nil {
+		h.writeTokenRequestError(w, err)
 		return
 	}
-
-	// 5. Fetch the client's public key from their JWKS URL and validate the assertion signature.
-	keySet, err := jwk.Fetch(r.Context(), client.JWKSURL)
+	resource, scopes, err := h.resourceService.Target(r.Context(), r.PostForm["resource"], nil, scopes)
 	if err != nil {
-		h.logger.Error("failed to fetch client JWKS", "url", client.JWKSURL, "error", err)
-		h.writeTokenError(w, "invalid_grant", "Could not fetch client's public keys.")
+		h.writeTokenRequestError(w, err)
 		return
 	}
 
-	// The jwt.Parse function will find the correct key from the key set based on the 'kid' header in the JWT.
-	parsedToken, err := jwt.Parse(assertionStr, func(t *jwt.Token) (interface{}, error) {
-		// Find the key that matches the 'kid' in the JWT header.
-		kid, ok := t.Header["kid"].(string)
-		if !ok {
-			return nil, jwt.ErrTokenUnverifiable
-		}
-		key, found := keySet.LookupKeyID(kid)
-		if !found {
-			return nil, jwt.ErrTokenUnverifiable
-		}
-		var pubKey interface{}
-		if err := key.Raw(&pubKey); err != nil {
-			return nil, jwt.ErrTokenUnverifiable
-		}
-		return pubKey, nil
+	accessToken, err := h.tokenService.GenerateAccessToken(r.Context(), services.AccessTokenRequest{
+		UserID:     subject.UserID,
+		ClientID:   client.ClientID,
+		Scopes:     scopes,
+		SigningAlg: client.AccessTokenSigningAlg,
+		Format:     client.AccessTokenFormat,
+		Audience:   resource,
 	})
-
-	if err != nil || !parsedToken.Valid {
-		h.writeTokenError(w, "invalid_grant", "Assertion validation failed.")
-		return
-	}
-
-	// 6. Validate other claims ('aud', 'exp').
-	// The 'aud' (audience) claim MUST be our token endpoint URL.
-	expectedAudience := h.clientService.GetBaseURL() + "/oauth2/token"
-	audience, err := parsedToken.Claims.GetAudience()
-	if err != nil || len(audience) == 0 || audience[0] != expectedAudience {
-		h.writeTokenError(w, "invalid_grant", "Invalid 'aud' claim in assertion.")
-		return
-	}
-
-	// 7. If everything is valid, issue an access token.
-	requestedScopes := client.Scopes // For this flow, grant all allowed scopes.
-	accessToken, err := h.tokenService.GenerateAccessToken(client.ClientID, client.ClientID, requestedScopes)
 	if err != nil {
 		h.logger.Error("failed to generate access token for JWT bearer", "error", err)
 		h.writeTokenError(w, "server_error", "The server encountered an error.")
@@ -392,10 +694,12 @@ func (h *AuthHandler) handleJWTBearerGrant(w http.ResponseWriter, r *http.Reques
 		"access_token": accessToken,
 		"token_type":   "Bearer",
 		"expires_in":   int(h.tokenService.GetAccessTokenLifespan().Seconds()),
-		"scope":        strings.Join(requestedScopes, " "),
+		"scope":        strings.Join(scopes, " "),
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(tokenResponse)
 }
@@ -404,30 +708,19 @@ func (h *AuthHandler) handleJWTBearerGrant(w http.ResponseWriter, r *http.Reques
 func (h *AuthHandler) handleAuthorizationCodeGrant(w http.ResponseWriter, r *http.Request) {
 	code := r.PostForm.Get("code")
 	redirectURI := r.PostForm.Get("redirect_uri")
-	clientID := r.PostForm.Get("client_id")
-	clientSecret := r.PostForm.Get("client_secret")
 	codeVerifier := r.PostForm.Get("code_verifier")
 
-	client, err := h.clientService.ValidateClientCredentials(r.Context(), clientID, clientSecret)
+	client, err := h.authenticateClient(r, "/oauth2/token")
 	if err != nil {
 		h.writeTokenError(w, "invalid_client", "Client authentication failed.")
 		return
 	}
-
-	// The redirect_uri in the token request MUST match the one from the authorization request.
-	// While we don't store it with the code, we can validate it's a valid one for the client.
-	if !slices.Contains(client.RedirectURIs, redirectURI) {
-		h.writeTokenError(w, "invalid_grant", "The redirect_uri does not match the one registered for the client.")
-		return
-	}
-
-	err = h.tokenService.ValidatePKCE(r.Context(), code, codeVerifier)
-	if err != nil {
-		// err could be ErrNotFound (no challenge stored) or a mismatch.
-		h.writeTokenError(w, "invalid_grant", err.Error())
+	if err := h.clientPolicy.CheckGrantType(client, models.GrantTypeAuthorizationCode); err != nil {
+		h.writeTokenRequestError(w, err)
 		return
 	}
 
+	// The code is consumed before any further checks, so a failed exchange still burns it.
 	authCodeToken, err := h.tokenService.ValidateAndConsumeAuthCode(r.Context(), code)
 	if err != nil {
 		h.writeTokenError(w, "invalid_grant", "The authorization code is invalid or expired.")
@@ -439,30 +732,69 @@ func (h *AuthHandler) handleAuthorizationCodeGrant(w http.ResponseWriter, r *htt
 		return
 	}
 
-	accessToken, err := h.tokenService.GenerateAccessToken(authCodeToken.UserID, client.ClientID, authCodeToken.Scopes)
+	// The redirect_uri in the token request MUST exactly match the one from the authorization request.
+	if authCodeToken.RedirectURI == "" || redirectURI != authCodeToken.RedirectURI {
+		h.writeTokenError(w, "invalid_grant", "The redirect_uri does not match the one used in the authorization request.")
+		return
+	}
+
+	if err := h.tokenService.VerifyPKCE(authCodeToken, codeVerifier); err != nil {
+		h.writeTokenError(w, "invalid_grant", err.Error())
+		return
+	}
+
+	audience, accessScopes, err := h.resourceService.Target(r.Context(), r.PostForm["resource"], authCodeToken.Resources, authCodeToken.Scopes)
 	if err != nil {
-		h.logger.Error("failed to generate access token", "error", err)
-		h.writeTokenError(w, "server_error", "The server encountered an error.")
+		h.writeTokenRequestError(w, err)
 		return
 	}
 
-	refreshToken, err := h.tokenService.GenerateAndStoreRefreshToken(r.Context(), authCodeToken.UserID, client.ClientID, authCodeToken.Scopes)
+	// The refresh token is created first so the access token can be tied to its family.
+	refreshToken, grantID, err := h.issueRefreshToken(r.Context(), client, services.RefreshTokenParams{
+		UserID:    authCodeToken.UserID,
+		ClientID:  client.ClientID,
+		Scopes:    authCodeToken.Scopes,
+		AuthTime:  authCodeToken.AuthTime,
+		AMR:       authCodeToken.AMR,
+		ACR:       authCodeToken.ACR,
+		Resources: authCodeToken.Resources,
+	})
 	if err != nil {
 		h.logger.Error("failed to generate refresh token", "error", err)
 		h.writeTokenError(w, "server_error", "The server encountered an error.")
 		return
 	}
 
+	accessToken, err := h.tokenService.GenerateAccessToken(r.Context(), services.AccessTokenRequest{
+		UserID:     authCodeToken.UserID,
+		ClientID:   client.ClientID,
+		Scopes:     accessScopes,
+		GrantID:    grantID,
+		SigningAlg: client.AccessTokenSigningAlg,
+		Format:     client.AccessTokenFormat,
+		Audience:   audience,
+		AuthTime:   authCodeToken.AuthTime,
+		AMR:        authCodeToken.AMR,
+		ACR:        authCodeToken.ACR,
+	})
+	if err != nil {
+		h.logger.Error("failed to generate access token", "error", err)
+		h.writeTokenError(w, "server_error", "The server encountered an error.")
+		return
+	}
+
 	tokenResponse := map[string]any{
-		"access_token":  accessToken,
-		"token_type":    "Bearer",
-		"expires_in":    int(h.tokenService.GetAccessTokenLifespan().Seconds()),
-		"scope":         strings.Join(authCodeToken.Scopes, " "),
-		"refresh_token": refreshToken,
+		"access_token": accessToken,
+		"token_type":   "Bearer",
+		"expires_in":   int(h.tokenService.GetAccessTokenLifespan().Seconds()),
+		"scope":        strings.Join(accessScopes, " "),
+	}
+	if refreshToken != "" {
+		tokenResponse["refresh_token"] = refreshToken
 	}
 
 	if slices.Contains(authCodeToken.Scopes, "openid") {
-		idToken, err := h.tokenService.GenerateIDToken(authCodeToken.UserID, client.ClientID, "", time.Time{})
+		idToken, err := h.tokenService.GenerateIDToken(authCodeToken.UserID, client.ClientID, authCodeToken.Nonce, authCodeToken.AuthTime, authCodeToken.AMR, client.IDTokenSignedResponseAlg)
 		if err == nil {
 			tokenResponse["id_token"] = idToken
 		} else {
@@ -479,34 +811,40 @@ func (h *AuthHandler) handleAuthorizationCodeGrant(w http.ResponseWriter, r *htt
 
 // handleClientCredentialsGrant processes the client_credentials grant type.
 func (h *AuthHandler) handleClientCredentialsGrant(w http.ResponseWriter, r *http.Request) {
-	clientID := r.PostForm.Get("client_id")
-	clientSecret := r.PostForm.Get("client_secret")
 	scope := r.PostForm.Get("scope")
 
-	client, err := h.clientService.ValidateClientCredentials(r.Context(), clientID, clientSecret)
+	client, err := h.authenticateClient(r, "/oauth2/token")
 	if err != nil {
 		h.writeTokenError(w, "invalid_client", "Client authentication failed.")
 		return
 	}
 
-	if !slices.Contains(client.GrantTypes, models.GrantTypeClientCredentials) {
-		h.writeTokenError(w, "unauthorized_client", "The client is not authorized to use this grant type.")
+	if err := h.clientPolicy.CheckGrantType(client, models.GrantTypeClientCredentials); err != nil {
+		h.writeTokenRequestError(w, err)
 		return
 	}
 
 	requestedScopes := strings.Fields(scope)
 	if len(requestedScopes) == 0 {
 		requestedScopes = client.Scopes
-	} else {
-		for _, s := range requestedScopes {
-			if !slices.Contains(client.Scopes, s) {
-				h.writeTokenError(w, "invalid_scope", "The requested scope is invalid, unknown, or malformed.")
-				return
-			}
-		}
+	} else if err := h.clientPolicy.CheckScopes(client, requestedScopes); err != nil {
+		h.writeTokenRequestError(w, err)
+		return
+	}
+	resource, requestedScopes, err := h.resourceService.Target(r.Context(), r.PostForm["resource"], nil, requestedScopes)
+	if err != nil {
+		h.writeTokenRequestError(w, err)
+		return
 	}
 
-	accessToken, err := h.tokenService.GenerateAccessToken(client.ClientID, client.ClientID, requestedScopes)
+	accessToken, err := h.tokenService.GenerateAccessToken(r.Context(), services.AccessTokenRequest{
+		UserID:     client.ClientID,
+		ClientID:   client.ClientID,
+		Scopes:     requestedScopes,
+		SigningAlg: client.AccessTokenSigningAlg,
+		Format:     client.AccessTokenFormat,
+		Audience:   resource,
+	})
 	if err != nil {
 		h.logger.Error("failed to generate access token for client credentials", "error", err)
 		h.writeTokenError(w, "server_error", "The server encountered an error.")
@@ -527,20 +865,156 @@ func (h *AuthHandler) handleClientCredentialsGrant(w http.ResponseWriter, r *htt
 	json.NewEncoder(w).Encode(tokenResponse)
 }
 
+// handleTokenExchangeGrant processes the token exchange grant type (RFC 8693). The client
+// exchanges a subject token, and optionally an actor token, for an access token for another
+// audience or with fewer scopes, as its token exchange policy allows. With an actor token the
+// new token carries an act claim naming the actor, nesting the subject token's own act claim.
+func (h *AuthHandler) handleTokenExchangeGrant(w http.ResponseWriter, r *http.Request) {
+	client, err := h.authenticateClient(r, "/oauth2/token")
+	if err != nil {
+		h.writeTokenError(w, "invalid_client", "Client authentication failed.")
+		return
+	}
+
+	if err := h.clientPolicy.CheckGrantType(client, models.GrantTypeTokenExchange); err != nil {
+		h.writeTokenRequestError(w, err)
+		return
+	}
+
+	if requested := r.PostForm.Get("requested_token_type"); requested != "" && requested != services.TokenTypeURIAccessToken {
+		h.writeTokenError(w, "invalid_request", "Only access tokens can be requested.")
+		return
+	}
+
+	subjectToken := r.PostForm.Get("subject_token")
+	subjectTokenType := r.PostForm.Get("subject_token_type")
+	if subjectToken == "" || subjectTokenType == "" {
+		h.writeTokenError(w, "invalid_request", "Missing subject_token or subject_token_type parameter.")
+		return
+	}
+	subject, err := h.resolveExchangeToken(r.Context(), client, subjectToken, subjectTokenType)
+	if err != nil {
+		h.writeExchangeTokenError(w, "subject_token", err)
+		return
+	}
+	if !subject.Scoped {
+		// ID tokens carry no scopes, so the new token is limited to those the user granted
+		// the client the ID token was issued to, which is the requesting client.
+		if subject.Scopes, err = h.consentService.GrantedScopes(r.Context(), client, subject.Subject); err != nil {
+			h.logger.Error("failed to look up consent for token exchange", "error", err)
+			h.writeTokenError(w, "server_error", "The server encountered an error.")
+			return
+		}
+		subject.Scoped = true
+	}
+
+	act := subject.Act
+	actorToken := r.PostForm.Get("actor_token")
+	actorTokenType := r.PostForm.Get("actor_token_type")
+	if actorToken != "" || actorTokenType != "" {
+		if actorToken == "" || actorTokenType == "" {
+			h.writeTokenError(w, "invalid_request", "actor_token and actor_token_type must be sent together.")
+			return
+		}
+		actor, err := h.resolveExchangeToken(r.Context(), client, actorToken, actorTokenType)
+		if err != nil {
+			h.writeExchangeTokenError(w, "actor_token", err)
+			return
+		}
+		act = &models.Actor{Subject: actor.Subject, ClientID: actor.ClientID, Act: subject.Act}
+	}
+
+	scopes, err := h.clientPolicy.ExchangeScopes(client, subject, strings.Fields(r.PostForm.Get("scope")))
+	if err != nil {
+		h.writeTokenRequestError(w, err)
+		return
+	}
+	audience, scopes, err := h.resourceService.ExchangeTarget(r.Context(), r.PostForm["resource"], r.PostForm["audience"], scopes)
+	if err != nil {
+		h.writeTokenRequestError(w, err)
+		return
+	}
+	if err := h.clientPolicy.CheckExchangeAudience(client, audience); err != nil {
+		h.writeTokenRequestError(w, err)
+		return
+	}
+
+	// The new token cannot outlive the subject token, and is revoked with its grant.
+	expiresAt := h.tokenService.AccessTokenExpiresAt(subject.ExpiresAt)
+	accessToken, err := h.tokenService.GenerateAccessToken(r.Context(), services.AccessTokenRequest{
+		UserID:     subject.Subject,
+		ClientID:   client.ClientID,
+		Scopes:     scopes,
+		GrantID:    subject.GrantID,
+		SigningAlg: client.AccessTokenSigningAlg,
+		Format:     client.AccessTokenFormat,
+		Audience:   audience,
+		AuthTime:   subject.AuthTime,
+		AMR:        subject.AMR,
+		ACR:        subject.ACR,
+		Act:        act,
+		ExpiresAt:  expiresAt,
+	})
+	if err != nil {
+		h.logger.Error("failed to generate access token for token exchange", "error", err)
+		h.writeTokenError(w, "server_error", "The server encountered an error.")
+		return
+	}
+
+	tokenResponse := map[string]any{
+		"access_token":      accessToken,
+		"issued_token_type": services.TokenTypeURIAccessToken,
+		"token_type":        "Bearer",
+		"expires_in":        int(time.Until(expiresAt).Seconds()),
+		"scope":             strings.Join(scopes, " "),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(tokenResponse)
+}
+
+// resolveExchangeToken validates a subject or actor token presented by client. A refresh token
+// stands for the refresh_token grant, so only clients the policy allows that grant may exchange one.
+func (h *AuthHandler) resolveExchangeToken(ctx context.Context, client *models.Client, token, tokenType string) (*services.TokenSubject, error) {
+	if tokenType == services.TokenTypeURIRefreshToken {
+		if err := h.clientPolicy.CheckGrantType(client, models.GrantTypeRefreshToken); err != nil {
+			return nil, err
+		}
+	}
+	return h.tokenService.ResolveExchangeToken(ctx, token, tokenType, client)
+}
+
+// writeExchangeTokenError reports a subject or actor token that cannot be exchanged.
+// RFC 8693 section 2.2.2 uses invalid_request for invalid tokens.
+func (h *AuthHandler) writeExchangeTokenError(w http.ResponseWriter, param string, err error) {
+	if errors.Is(err, services.ErrUnsupportedTokenType) {
+		h.writeTokenError(w, "invalid_request", "The "+param+"_type is not supported.")
+		return
+	}
+	h.logger.Warn("invalid token presented for token exchange", "parameter", param, "error", err)
+	h.writeTokenError(w, "invalid_request", "The "+param+" is invalid or expired.")
+}
+
 // handleRefreshTokenGrant processes the refresh_token grant type.
 func (h *AuthHandler) handleRefreshTokenGrant(w http.ResponseWriter, r *http.Request) {
 	refreshTokenStr := r.PostForm.Get("refresh_token")
-	clientID := r.PostForm.Get("client_id")
-	clientSecret := r.PostForm.Get("client_secret")
 
-	client, err := h.clientService.ValidateClientCredentials(r.Context(), clientID, clientSecret)
+	client, err := h.authenticateClient(r, "/oauth2/token")
 	if err != nil {
 		h.writeTokenError(w, "invalid_client", "Client authentication failed.")
 		return
 	}
+	if err := h.clientPolicy.CheckGrantType(client, models.GrantTypeRefreshToken); err != nil {
+		h.writeTokenRequestError(w, err)
+		return
+	}
 
 	refreshToken, err := h.tokenService.ValidateAndConsumeRefreshToken(r.Context(), refreshTokenStr)
 	if err != nil {
+		h.recordRefreshTokenReuse(r, err)
 		h.writeTokenError(w, "invalid_grant", "The refresh token is invalid or expired.")
 		return
 	}
@@ -550,7 +1024,54 @@ func (h *AuthHandler) handleRefreshTokenGrant(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	accessToken, err := h.tokenService.GenerateAccessToken(refreshToken.UserID, client.ClientID, refreshToken.Scopes)
+	// The client may ask for fewer scopes than were granted (RFC 6749 section 6). The
+	// narrowed scopes apply to the new access token only; the grant itself is unchanged.
+	scopes := refreshToken.Scopes
+	if requested := strings.Fields(r.PostForm.Get("scope")); len(requested) > 0 {
+		if !h.scopeService.CoversAll(refreshToken.Scopes, requested) {
+			h.writeTokenError(w, "invalid_scope", "The requested scope exceeds the scope originally granted.")
+			return
+		}
+		scopes = requested
+	}
+	// The token may be for any resource of the original grant.
+	resource, accessScopes, err := h.resourceService.Target(r.Context(), r.PostForm["resource"], refreshToken.Resources, scopes)
+	if err != nil {
+		h.writeTokenRequestError(w, err)
+		return
+	}
+
+	// Rotate before minting anything, so that a concurrent replay of the same token
+	// is detected before either request receives an access token.
+	var newRefreshToken string
+	grantID := refreshToken.FamilyID
+	if !client.RefreshTokenRotationDisabled {
+		var newRecord *models.Token
+		newRefreshToken, newRecord, err = h.tokenService.RotateRefreshToken(r.Context(), refreshToken)
+		if err != nil {
+			if h.recordRefreshTokenReuse(r, err) {
+				h.writeTokenError(w, "invalid_grant", "The refresh token is invalid or expired.")
+				return
+			}
+			h.logger.Error("failed to rotate refresh token", "error", err)
+			h.writeTokenError(w, "server_error", "The server encountered an error.")
+			return
+		}
+		grantID = newRecord.FamilyID
+	}
+
+	accessToken, err := h.tokenService.GenerateAccessToken(r.Context(), services.AccessTokenRequest{
+		UserID:     refreshToken.UserID,
+		ClientID:   client.ClientID,
+		Scopes:     accessScopes,
+		GrantID:    grantID,
+		SigningAlg: client.AccessTokenSigningAlg,
+		Format:     client.AccessTokenFormat,
+		Audience:   resource,
+		AuthTime:   refreshToken.AuthTime,
+		AMR:        refreshToken.AMR,
+		ACR:        refreshToken.ACR,
+	})
 	if err != nil {
 		h.logger.Error("failed to generate access token from refresh token", "error", err)
 		h.writeTokenError(w, "server_error", "The server encountered an error.")
@@ -561,11 +1082,15 @@ func (h *AuthHandler) handleRefreshTokenGrant(w http.ResponseWriter, r *http.Req
 		"access_token": accessToken,
 		"token_type":   "Bearer",
 		"expires_in":   int(h.tokenService.GetAccessTokenLifespan().Seconds()),
-		"scope":        strings.Join(refreshToken.Scopes, " "),
+		"scope":        strings.Join(accessScopes, " "),
+	}
+	if newRefreshToken != "" {
+		tokenResponse["refresh_token"] = newRefreshToken
 	}
 
-	if slices.Contains(refreshToken.Scopes, "openid") {
-		idToken, err := h.tokenService.GenerateIDToken(refreshToken.UserID, client.ClientID, "", time.Time{})
+	if slices.Contains(scopes, "openid") {
+		// The nonce is only echoed in the ID token issued with the authorization code.
+		idToken, err := h.tokenService.GenerateIDToken(refreshToken.UserID, client.ClientID, "", refreshToken.AuthTime, refreshToken.AMR, client.IDTokenSignedResponseAlg)
 		if err == nil {
 			tokenResponse["id_token"] = idToken
 		} else {
@@ -583,11 +1108,20 @@ func (h *AuthHandler) handleRefreshTokenGrant(w http.ResponseWriter, r *http.Req
 // handleDeviceCodeGrant processes the device_code grant type.
 func (h *AuthHandler) handleDeviceCodeGrant(w http.ResponseWriter, r *http.Request) {
 	deviceCode := r.PostForm.Get("device_code")
-	clientID := r.PostForm.Get("client_id")
+
+	client, err := h.authenticateClient(r, "/oauth2/token")
+	if err != nil {
+		h.writeTokenError(w, "invalid_client", "Client authentication failed.")
+		return
+	}
+	if err := h.clientPolicy.CheckGrantType(client, models.GrantTypeDeviceCode); err != nil {
+		h.writeTokenRequestError(w, err)
+		return
+	}
 
 	signature := h.tokenService.HashToken(deviceCode)
 	token, err := h.tokenService.GetTokenBySignature(r.Context(), signature)
-	if err != nil || token.ClientID != clientID {
+	if err != nil || token.ClientID != client.ClientID {
 		h.writeTokenError(w, "invalid_grant", "Device code is invalid, expired, or not for this client.")
 		return
 	}
@@ -602,30 +1136,55 @@ func (h *AuthHandler) handleDeviceCodeGrant(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	// The target is checked before the device code is consumed, so that a mistyped resource
+	// does not make the user start over.
+	resource, accessScopes, err := h.resourceService.Target(r.Context(), r.PostForm["resource"], token.Resources, token.Scopes)
+	if err != nil {
+		h.writeTokenRequestError(w, err)
+		return
+	}
+
 	_ = h.tokenService.DeleteTokenBySignature(r.Context(), signature)
 
-	accessToken, err := h.tokenService.GenerateAccessToken(token.UserID, token.ClientID, token.Scopes)
+	refreshToken, grantID, err := h.issueRefreshToken(r.Context(), client, services.RefreshTokenParams{
+		UserID:    token.UserID,
+		ClientID:  token.ClientID,
+		Scopes:    token.Scopes,
+		Resources: token.Resources,
+	})
 	if err != nil {
-		utils.HandleError(w, r, h.logger, h.templateCache, err)
+		h.logger.Error("failed to generate refresh token", "error", err)
+		h.writeTokenError(w, "server_error", "The server encountered an error.")
 		return
 	}
 
-	refreshToken, err := h.tokenService.GenerateAndStoreRefreshToken(r.Context(), token.UserID, token.ClientID, token.Scopes)
+	accessToken, err := h.tokenService.GenerateAccessToken(r.Context(), services.AccessTokenRequest{
+		UserID:     token.UserID,
+		ClientID:   token.ClientID,
+		Scopes:     accessScopes,
+		GrantID:    grantID,
+		SigningAlg: client.AccessTokenSigningAlg,
+		Format:     client.AccessTokenFormat,
+		Audience:   resource,
+	})
 	if err != nil {
-		utils.HandleError(w, r, h.logger, h.templateCache, err)
+		h.logger.Error("failed to generate access token", "error", err)
+		h.writeTokenError(w, "server_error", "The server encountered an error.")
 		return
 	}
 
 	tokenResponse := map[string]any{
-		"access_token":  accessToken,
-		"token_type":    "Bearer",
-		"expires_in":    int(h.tokenService.GetAccessTokenLifespan().Seconds()),
-		"scope":         strings.Join(token.Scopes, " "),
-		"refresh_token": refreshToken,
+		"access_token": accessToken,
+		"token_type":   "Bearer",
+		"expires_in":   int(h.tokenService.GetAccessTokenLifespan().Seconds()),
+		"scope":        strings.Join(accessScopes, " "),
+	}
+	if refreshToken != "" {
+		tokenResponse["refresh_token"] = refreshToken
 	}
 
 	if slices.Contains(token.Scopes, "openid") {
-		idToken, err := h.tokenService.GenerateIDToken(token.UserID, token.ClientID, "", time.Time{})
+		idToken, err := h.tokenService.GenerateIDToken(token.UserID, token.ClientID, "", time.Time{}, nil, client.IDTokenSignedResponseAlg)
 		if err == nil {
 			tokenResponse["id_token"] = idToken
 		} else {
@@ -640,6 +1199,83 @@ func (h *AuthHandler) handleDeviceCodeGrant(w http.ResponseWriter, r *http.Reque
 	json.NewEncoder(w).Encode(tokenResponse)
 }
 
+// issueRefreshToken creates a refresh token for a grant, if the client is registered for the
+// refresh_token grant, and returns it with its family ID, which access tokens minted with it
+// are tied to. Other clients get no refresh token and an empty family ID.
+func (h *AuthHandler) issueRefreshToken(ctx context.Context, client *models.Client, params services.RefreshTokenParams) (string, string, error) {
+	if h.clientPolicy.CheckGrantType(client, models.GrantTypeRefreshToken) != nil {
+		return "", "", nil
+	}
+	refreshToken, record, err := h.tokenService.GenerateAndStoreRefreshToken(ctx, params)
+	if err != nil {
+		return "", "", err
+	}
+	return refreshToken, record.FamilyID, nil
+}
+
+// recordRefreshTokenReuse records an audit event if err reports a replayed refresh token.
+// It returns true if the error was a reuse detection.
+func (h *AuthHandler) recordRefreshTokenReuse(r *http.Request, err error) bool {
+	var reuseErr *services.RefreshTokenReuseError
+	if !errors.As(err, &reuseErr) {
+		return false
+	}
+	h.logger.Warn("refresh token reuse detected, token family revoked", "client_id", reuseErr.Token.ClientID, "family_id", reuseErr.Token.FamilyID)
+	eventData := services.RecordEventData{
+		EventType: models.RefreshTokenReuseDetected,
+		ActorID:   reuseErr.Token.ClientID,
+		TargetID:  reuseErr.Token.UserID,
+		IPAddress: middleware.GetClientIP(r),
+		UserAgent: r.UserAgent(),
+		Details:   "Rotated refresh token presented again; token family " + reuseErr.Token.FamilyID + " revoked.",
+	}
+	_ = h.auditService.Record(r.Context(), eventData)
+	return true
+}
+
+// authenticateClient authenticates the client making a request to the token or PAR endpoint,
+// identified by its path.
+func (h *AuthHandler) authenticateClient(r *http.Request, path string) (*models.Client, error) {
+	client, err := h.clientAuthenticator.Authenticate(r.Context(), clientCredentials(r), h.clientService.GetBaseURL()+path)
+	if err != nil {
+		h.logger.Warn("client authentication failed", "error", err)
+	}
+	return client, err
+}
+
+// clientCredentials extracts client credentials from HTTP Basic auth, a client
+// assertion or the form body, and reports which secret-based method the request used.
+// A request carrying only a client_id uses the "none" method of public clients.
+func clientCredentials(r *http.Request) services.ClientCredentials {
+	if clientID, clientSecret, ok := r.BasicAuth(); ok {
+		return services.ClientCredentials{ClientID: clientID, ClientSecret: clientSecret, Method: models.ClientAuthMethodSecretBasic}
+	}
+
+	creds := services.ClientCredentials{
+		ClientID:      r.PostFormValue("client_id"),
+		ClientSecret:  r.PostFormValue("client_secret"),
+		Assertion:     r.PostFormValue("client_assertion"),
+		AssertionType: r.PostFormValue("client_assertion_type"),
+		Method:        models.ClientAuthMethodSecretPost,
+	}
+	if creds.ClientSecret == "" {
+		creds.Method = models.ClientAuthMethodNone
+	}
+	return creds
+}
+
+// writeTokenRequestError reports a rejected token or device authorization request with the
+// OAuth error code carried by err, such as those of the client policy.
+func (h *AuthHandler) writeTokenRequestError(w http.ResponseWriter, err error) {
+	var appErr *utils.AppError
+	if errors.As(err, &appErr) {
+		h.writeTokenError(w, appErr.Code, appErr.Message)
+		return
+	}
+	h.logger.Error("failed to process token request", "error", err)
+	h.writeTokenError(w, "server_error", "The server encountered an error.")
+}
+
 // writeTokenError is a helper to send a standard OAuth2 error response.
 func (h *AuthHandler) writeTokenError(w http.ResponseWriter, err, description string) {
 	w.Header().Set("Content-Type", "application/json")
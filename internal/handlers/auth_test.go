@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aminshahid573/authexa/internal/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// TestLoginRequired tests when prompt, max_age and the login hints send the user to log in
+// before an authorization request is answered.
+func TestLoginRequired(t *testing.T) {
+	user := &models.User{ID: bson.NewObjectID(), Username: "alice"}
+	requestedAt := time.Now()
+	earlierLogin := &models.Session{ID: "session-1", UserID: user.ID, AuthTime: requestedAt.Add(-time.Hour)}
+	laterLogin := &models.Session{ID: "session-2", UserID: user.ID, AuthTime: requestedAt.Add(time.Second)}
+	seconds := func(n int) *int { return &n }
+
+	tests := []struct {
+		name     string
+		user     *models.User
+		session  *models.Session
+		authReq  models.AuthorizationRequest
+		expected bool
+	}{
+		{name: "No Session", expected: true},
+		{name: "Existing Session", user: user, session: earlierLogin, expected: false},
+		{name: "Prompt Login", user: user, session: earlierLogin, authReq: models.AuthorizationRequest{Prompt: []string{models.PromptLogin}}, expected: true},
+		{name: "Prompt Login After Login", user: user, session: laterLogin, authReq: models.AuthorizationRequest{Prompt: []string{models.PromptLogin}}, expected: false},
+		{name: "Prompt Select Account", user: user, session: earlierLogin, authReq: models.AuthorizationRequest{Prompt: []string{models.PromptSelectAccount}}, expected: true},
+		{name: "Prompt Consent", user: user, session: earlierLogin, authReq: models.AuthorizationRequest{Prompt: []string{models.PromptConsent}}, expected: false},
+		{name: "Max Age Exceeded", user: user, session: earlierLogin, authReq: models.AuthorizationRequest{MaxAge: seconds(60)}, expected: true},
+		{name: "Max Age Not Exceeded", user: user, session: earlierLogin, authReq: models.AuthorizationRequest{MaxAge: seconds(7200)}, expected: false},
+		{name: "Zero Max Age After Login", user: user, session: laterLogin, authReq: models.AuthorizationRequest{MaxAge: seconds(0)}, expected: false},
+		{name: "Matching Login Hint", user: user, session: earlierLogin, authReq: models.AuthorizationRequest{LoginHint: "alice"}, expected: false},
+		{name: "Other Login Hint", user: user, session: earlierLogin, authReq: models.AuthorizationRequest{LoginHint: "bob"}, expected: true},
+		{name: "Matching ID Token Hint", user: user, session: earlierLogin, authReq: models.AuthorizationRequest{HintSubject: user.ID.Hex()}, expected: false},
+		{name: "Other ID Token Hint", user: user, session: earlierLogin, authReq: models.AuthorizationRequest{HintSubject: bson.NewObjectID().Hex()}, expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authReq := tt.authReq
+			authReq.CreatedAt = requestedAt
+			if got := loginRequired(tt.user, tt.session, &authReq); got != tt.expected {
+				t.Errorf("expected loginRequired to be %v, but got: %v", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/aminshahid573/authexa/internal/models"
+	"github.com/aminshahid573/authexa/internal/utils"
+)
+
+// sendAuthorizationResponse delivers the response parameters to the client's redirect URI in
+// the request's response mode. Plain responses carry the RFC 9207 "iss" parameter so the client
+// can detect mix-up attacks; JARM responses are instead signed as a JWT with an "iss" claim.
+// The redirect URI must already have been validated against the client.
+func (h *AuthHandler) sendAuthorizationResponse(w http.ResponseWriter, r *http.Request, client *models.Client, authReq *models.AuthorizationRequest, response url.Values) {
+	redirectURL, err := url.Parse(authReq.RedirectURI)
+	if err != nil {
+		utils.HandleError(w, r, h.logger, h.templateCache, utils.ErrBadRequest)
+		return
+	}
+
+	mode := responseMode(client, authReq.ResponseMode)
+	if base, ok := strings.CutSuffix(mode, ".jwt"); ok {
+		token, err := h.tokenService.GenerateAuthorizationResponse(client.ClientID, response, client.AuthorizationSignedResponseAlg)
+		if err != nil {
+			h.logger.Error("failed to sign authorization response", "client_id", client.ClientID, "error", err)
+			utils.HandleError(w, r, h.logger, h.templateCache, utils.ErrInternal)
+			return
+		}
+		response = url.Values{"response": {token}}
+		mode = base
+	} else {
+		response.Set("iss", h.tokenService.GetIssuer())
+	}
+
+	switch mode {
+	case models.ResponseModeFormPost:
+		// The page posts the response to the client, so it must not be cached.
+		w.Header().Set("Cache-Control", "no-store")
+		data := map[string]any{
+			"Action":     redirectURL.String(),
+			"Parameters": response,
+		}
+		h.templateCache.Render(w, r, "base.html", "form_post.html", data)
+	case models.ResponseModeFragment:
+		redirectURL.Fragment = ""
+		http.Redirect(w, r, redirectURL.String()+"#"+response.Encode(), http.StatusSeeOther)
+	default:
+		query := redirectURL.Query()
+		for key, values := range response {
+			query[key] = values
+		}
+		redirectURL.RawQuery = query.Encode()
+		http.Redirect(w, r, redirectURL.String(), http.StatusSeeOther)
+	}
+}
+
+// sendAuthorizationError reports an authorization error to the client as described in
+// RFC 6749 section 4.1.2.1. Errors without an OAuth error code become server_error.
+func (h *AuthHandler) sendAuthorizationError(w http.ResponseWriter, r *http.Request, client *models.Client, authReq *models.AuthorizationRequest, err error) {
+	code, description := "server_error", "The authorization server encountered an unexpected error."
+	var appErr *utils.AppError
+	if errors.As(err, &appErr) && appErr.HTTPStatus < http.StatusInternalServerError {
+		code, description = appErr.Code, appErr.Message
+	} else {
+		h.logger.Error("authorization request failed", "error", err)
+	}
+
+	response := url.Values{"error": {code}, "error_description": {description}}
+	if authReq.State != "" {
+		response.Set("state", authReq.State)
+	}
+	h.sendAuthorizationResponse(w, r, client, authReq, response)
+}
+
+// responseMode returns how an authorization response is delivered: the requested response_mode,
+// else the client's default, else query. An unsupported request falls back the same way, so the
+// error rejecting it can still be delivered. The bare "jwt" mode means query.jwt for the code flow.
+func responseMode(client *models.Client, requested string) string {
+	mode := requested
+	if !slices.Contains(models.SupportedResponseModes, mode) {
+		mode = client.DefaultResponseMode
+	}
+	switch mode {
+	case "":
+		return models.ResponseModeQuery
+	case models.ResponseModeJWT:
+		return models.ResponseModeQueryJWT
+	default:
+		return mode
+	}
+}
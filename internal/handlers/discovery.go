@@ -7,81 +7,107 @@ import (
 
 	"github.com/aminshahid573/authexa/internal/models"
 	"github.com/aminshahid573/authexa/internal/services"
+	"github.com/aminshahid573/authexa/internal/utils"
 )
 
-// DiscoveryHandler serves the OAuth2 discovery document.
+// DiscoveryHandler serves the OAuth2 and OpenID Connect discovery documents.
 type DiscoveryHandler struct {
 	logger        *slog.Logger
 	clientService *services.ClientService
+	scopeService  *services.ScopeService
+	jwtManager    *utils.JWTManager
+	parService    *services.PARService
+	// registrationEnabled advertises the dynamic client registration endpoint.
+	registrationEnabled bool
 }
 
 // NewDiscoveryHandler creates a new DiscoveryHandler.
-func NewDiscoveryHandler(logger *slog.Logger, clientService *services.ClientService) *DiscoveryHandler {
+func NewDiscoveryHandler(logger *slog.Logger, clientService *services.ClientService, scopeService *services.ScopeService, jwtManager *utils.JWTManager, parService *services.PARService, registrationEnabled bool) *DiscoveryHandler {
 	return &DiscoveryHandler{
-		logger:        logger,
-		clientService: clientService,
+		logger:              logger,
+		clientService:       clientService,
+		scopeService:        scopeService,
+		jwtManager:          jwtManager,
+		parService:          parService,
+		registrationEnabled: registrationEnabled,
 	}
 }
 
-// ServeDiscoveryDocument is the HTTP handler for the discovery endpoint.
+// ServeDiscoveryDocument is the HTTP handler for the RFC 8414 authorization server metadata endpoint.
 func (h *DiscoveryHandler) ServeDiscoveryDocument(w http.ResponseWriter, r *http.Request) {
+	h.writeDocument(w, h.buildMetadata())
+}
+
+// ServeOpenIDConfiguration is the HTTP handler for the OpenID Connect discovery endpoint.
+func (h *DiscoveryHandler) ServeOpenIDConfiguration(w http.ResponseWriter, r *http.Request) {
+	h.writeDocument(w, h.buildMetadata())
+}
+
+// buildMetadata assembles the provider metadata from the live configuration, so that the
+// document never advertises a grant, scope or algorithm the server does not actually accept.
+func (h *DiscoveryHandler) buildMetadata() map[string]any {
 	baseURL := h.clientService.GetBaseURL()
 
-	// Construct the discovery document.
-	discoveryDoc := map[string]any{
+	metadata := map[string]any{
 		// --- Endpoint URLs ---
-		"issuer":                        baseURL,
+		"issuer":                        h.jwtManager.GetIssuer(),
 		"authorization_endpoint":        baseURL + "/oauth2/authorize",
 		"token_endpoint":                baseURL + "/oauth2/token",
+		"userinfo_endpoint":             baseURL + "/oauth2/userinfo",
 		"jwks_uri":                      baseURL + "/.well-known/jwks.json",
 		"revocation_endpoint":           baseURL + "/oauth2/revoke",
 		"introspection_endpoint":        baseURL + "/oauth2/introspect",
 		"device_authorization_endpoint": baseURL + "/oauth2/device_authorization",
+		"end_session_endpoint":          baseURL + "/oauth2/logout",
+
+		"pushed_authorization_request_endpoint": baseURL + "/oauth2/par",
+		"require_pushed_authorization_requests": h.parService.RequiredGlobally(),
+
+		"authorization_response_iss_parameter_supported": true,
 
 		// --- Supported Features ---
-		"grant_types_supported": []string{
-			models.GrantTypeAuthorizationCode,
-			models.GrantTypeClientCredentials,
-			models.GrantTypeRefreshToken,
-			models.GrantTypeDeviceCode,
-			models.GrantTypeJWTBearer,
-		},
+		"grant_types_supported": supportedGrantTypes,
 		"response_types_supported": []string{
 			"code",
 		},
-		"scopes_supported": []string{
-			"openid",
-			"profile",
-			"email",
-			"offline",
-			"api:read",
-			"api:write",
-		},
-		"token_endpoint_auth_methods_supported": []string{
-			"client_secret_basic",
-			"client_secret_post",
-		},
-		"revocation_endpoint_auth_methods_supported": []string{
-			"client_secret_basic",
-			"client_secret_post",
-		},
-		"introspection_endpoint_auth_methods_supported": []string{
-			"client_secret_basic",
-		},
+		"response_modes_supported":                      models.SupportedResponseModes,
+		"prompt_values_supported":                       models.SupportedPrompts,
+		"scopes_supported":                              h.scopeService.SupportedScopes(),
+		"claims_supported":                              append([]string{"iss", "aud", "exp", "iat", "auth_time", "amr", "nonce"}, h.scopeService.SupportedClaims()...),
+		"token_endpoint_auth_methods_supported":         tokenEndpointAuthMethods,
+		"revocation_endpoint_auth_methods_supported":    revocationEndpointAuthMethods,
+		"introspection_endpoint_auth_methods_supported": introspectionEndpointAuthMethods,
 		"code_challenge_methods_supported": []string{
-			"S256", // We will implement PKCE next
-		},
-		"id_token_signing_alg_values_supported": []string{
-			"RS256",
+			"S256",
 		},
+		"id_token_signing_alg_values_supported":                    h.jwtManager.SigningAlgorithms(),
+		"authorization_signing_alg_values_supported":               h.jwtManager.SigningAlgorithms(),
+		"token_endpoint_auth_signing_alg_values_supported":         services.ClientAssertionSigningAlgorithms,
+		"revocation_endpoint_auth_signing_alg_values_supported":    services.ClientAssertionSigningAlgorithms,
+		"introspection_endpoint_auth_signing_alg_values_supported": services.ClientAssertionSigningAlgorithms,
 		"subject_types_supported": []string{
 			"public",
 		},
+		"claims_parameter_supported":  false,
+		"request_parameter_supported": true,
+
+		"request_uri_parameter_supported":                true,
+		"require_request_uri_registration":               true,
+		"request_object_signing_alg_values_supported":    utils.SupportedSigningAlgorithms,
+		"request_object_encryption_alg_values_supported": utils.SupportedEncryptionAlgorithms,
+		"request_object_encryption_enc_values_supported": services.RequestObjectEncryptionMethods,
+	}
+	if h.registrationEnabled {
+		metadata["registration_endpoint"] = baseURL + "/oauth2/register"
 	}
+	return metadata
+}
 
+// writeDocument encodes a metadata document as a JSON response.
+func (h *DiscoveryHandler) writeDocument(w http.ResponseWriter, doc map[string]any) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	if err := json.NewEncoder(w).Encode(discoveryDoc); err != nil {
+	if err := json.NewEncoder(w).Encode(doc); err != nil {
 		h.logger.Error("failed to write discovery document", "error", err)
 	}
 }
@@ -5,6 +5,7 @@ import (
 	"log/slog"
 	"net/http"
 	"net/url"
+	"slices"
 	"strings"
 	"time"
 
@@ -49,11 +50,13 @@ func NewFrontendHandler(
 	}
 }
 
-// LoginPage serves the user login page.
+// LoginPage serves the user login page. A login_hint passed on by the authorization
+// endpoint prefills the username.
 func (h *FrontendHandler) LoginPage(w http.ResponseWriter, r *http.Request) {
 	returnTo := r.URL.Query().Get("return_to")
 	data := map[string]any{
 		"ReturnTo": returnTo,
+		"Username": r.URL.Query().Get("login_hint"),
 	}
 
 	// Use the "base.html" layout for the public login page.
@@ -92,7 +95,7 @@ func (h *FrontendHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	session, err := h.sessionService.CreateSession(r.Context(), user.ID)
+	session, err := h.sessionService.CreateSession(r.Context(), user.ID, []string{models.AMRPassword}, models.ACRPassword)
 	if err != nil {
 		utils.HandleError(w, r, h.logger, h.templateCache, err)
 		return
@@ -196,6 +199,91 @@ func (h *FrontendHandler) Logout(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, "/login", http.StatusSeeOther)
 }
 
+// EndSession handles OpenID Connect RP-initiated logout requests.
+// It ends the user's session and, when a registered post_logout_redirect_uri is provided,
+// sends the user back to the client application. Without a valid id_token_hint, any site
+// could send the user here, so the user is asked to confirm the logout first.
+func (h *FrontendHandler) EndSession(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		utils.HandleError(w, r, h.logger, h.templateCache, utils.ErrBadRequest)
+		return
+	}
+	idTokenHint := r.Form.Get("id_token_hint")
+	clientID := r.Form.Get("client_id")
+	postLogoutRedirectURI := r.Form.Get("post_logout_redirect_uri")
+	state := r.Form.Get("state")
+
+	hinted := false
+	if idTokenHint != "" {
+		claims, err := h.tokenService.ParseIDTokenHint(idTokenHint)
+		if err != nil {
+			utils.HandleError(w, r, h.logger, h.templateCache, &utils.AppError{Code: "invalid_request", Title: "Bad Request", Message: "The id_token_hint is invalid.", HTTPStatus: http.StatusBadRequest})
+			return
+		}
+		if clientID == "" && len(claims.Audience) > 0 {
+			clientID = claims.Audience[0]
+		}
+		if clientID != "" && !slices.Contains(claims.Audience, clientID) {
+			utils.HandleError(w, r, h.logger, h.templateCache, &utils.AppError{Code: "invalid_request", Title: "Bad Request", Message: "The id_token_hint was not issued to this client.", HTTPStatus: http.StatusBadRequest})
+			return
+		}
+		hinted = true
+	}
+
+	// Resolve the redirect target before ending the session so a bad request leaves the user logged in.
+	redirectTo := "/login"
+	if postLogoutRedirectURI != "" {
+		if clientID == "" {
+			utils.HandleError(w, r, h.logger, h.templateCache, &utils.AppError{Code: "invalid_request", Title: "Bad Request", Message: "A client_id or id_token_hint is required with post_logout_redirect_uri.", HTTPStatus: http.StatusBadRequest})
+			return
+		}
+		client, err := h.clientService.GetClient(r.Context(), clientID)
+		if err != nil {
+			utils.HandleError(w, r, h.logger, h.templateCache, utils.ErrInvalidClient)
+			return
+		}
+		if !slices.Contains(client.PostLogoutRedirectURIs, postLogoutRedirectURI) {
+			utils.HandleError(w, r, h.logger, h.templateCache, &utils.AppError{Code: "invalid_request", Title: "Bad Request", Message: "The post_logout_redirect_uri is not registered for this client.", HTTPStatus: http.StatusBadRequest})
+			return
+		}
+		redirectURL, err := url.Parse(postLogoutRedirectURI)
+		if err != nil {
+			utils.HandleError(w, r, h.logger, h.templateCache, utils.ErrBadRequest)
+			return
+		}
+		if state != "" {
+			query := redirectURL.Query()
+			query.Set("state", state)
+			redirectURL.RawQuery = query.Encode()
+		}
+		redirectTo = redirectURL.String()
+	}
+
+	sessionCookie, err := r.Cookie("session_id")
+	if err == nil {
+		// The confirmation form is a POST carrying the CSRF token, which a foreign page cannot forge.
+		confirmed := r.Method == http.MethodPost && r.PostForm.Get("logout") == "confirm"
+		if !hinted && !confirmed {
+			data := map[string]any{
+				"ClientID":              clientID,
+				"PostLogoutRedirectURI": postLogoutRedirectURI,
+				"State":                 state,
+			}
+			h.templateCache.Render(w, r, "base.html", "logout.html", data)
+			return
+		}
+		h.sessionService.DeleteSession(r.Context(), sessionCookie.Value)
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:   "session_id",
+		Value:  "",
+		Path:   "/",
+		MaxAge: -1,
+	})
+
+	http.Redirect(w, r, redirectTo, http.StatusSeeOther)
+}
+
 // AdminClientsPage serves the client management page.
 func (h *FrontendHandler) AdminClientsPage(w http.ResponseWriter, r *http.Request) {
 	user, _ := middleware.GetUserFromContext(r)
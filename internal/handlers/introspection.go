@@ -9,40 +9,44 @@ import (
 
 	"github.com/aminshahid573/authexa/internal/models"
 	"github.com/aminshahid573/authexa/internal/services"
-	"github.com/aminshahid573/authexa/internal/utils"
 )
 
 // IntrospectionHandler handles token introspection requests.
 type IntrospectionHandler struct {
-	logger        *slog.Logger
-	clientService *services.ClientService
-	tokenService  *services.TokenService
-	jwtManager    *utils.JWTManager
+	logger              *slog.Logger
+	clientService       *services.ClientService
+	clientAuthenticator *services.ClientAuthenticator
+	tokenService        *services.TokenService
 }
 
 // NewIntrospectionHandler creates a new IntrospectionHandler.
-func NewIntrospectionHandler(logger *slog.Logger, clientService *services.ClientService, tokenService *services.TokenService, jwtManager *utils.JWTManager) *IntrospectionHandler {
+func NewIntrospectionHandler(logger *slog.Logger, clientService *services.ClientService, clientAuthenticator *services.ClientAuthenticator, tokenService *services.TokenService) *IntrospectionHandler {
 	return &IntrospectionHandler{
-		logger:        logger,
-		clientService: clientService,
-		tokenService:  tokenService,
-		jwtManager:    jwtManager,
+		logger:              logger,
+		clientService:       clientService,
+		clientAuthenticator: clientAuthenticator,
+		tokenService:        tokenService,
 	}
 }
 
+// introspectionEndpointAuthMethods lists the client authentication methods accepted by Introspect.
+var introspectionEndpointAuthMethods = []string{
+	models.ClientAuthMethodSecretBasic,
+	models.ClientAuthMethodSecretPost,
+	models.ClientAuthMethodPrivateKeyJWT,
+	models.ClientAuthMethodSecretJWT,
+}
+
 // Introspect is the main handler for the introspection endpoint.
 func (h *IntrospectionHandler) Introspect(w http.ResponseWriter, r *http.Request) {
 	// The introspection endpoint itself must be protected.
-	// A resource server authenticates with its own client credentials.
-	// We use HTTP Basic Authentication for this.
-	clientID, clientSecret, ok := r.BasicAuth()
-	if !ok {
-		h.writeInactiveResponse(w)
-		return
-	}
-
-	_, err := h.clientService.ValidateClientCredentials(r.Context(), clientID, clientSecret)
-	if err != nil {
+	// A resource server authenticates with its own client credentials or a client assertion.
+	// Public clients cannot authenticate, so they cannot introspect tokens.
+	client, err := h.clientAuthenticator.Authenticate(r.Context(), clientCredentials(r), h.clientService.GetBaseURL()+"/oauth2/introspect")
+	if err != nil || client.IsPublic() {
+		if err != nil {
+			h.logger.Warn("client authentication failed at introspection endpoint", "error", err)
+		}
 		h.writeInactiveResponse(w)
 		return
 	}
@@ -58,8 +62,8 @@ func (h *IntrospectionHandler) Introspect(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	// First, try verifying it as a JWT Access Token.
-	claims, err := h.jwtManager.VerifyToken(tokenToInspect)
+	// First, try verifying it as a JWT Access Token. Revoked tokens fail verification.
+	claims, err := h.tokenService.VerifyAccessToken(r.Context(), tokenToInspect)
 	if err == nil {
 		// Valid JWT Access Token
 		response := map[string]any{
@@ -75,6 +79,12 @@ func (h *IntrospectionHandler) Introspect(w http.ResponseWriter, r *http.Request
 			"jti":        claims.ID,
 			"token_type": "Bearer",
 		}
+		if claims.ACR != "" {
+			response["acr"] = claims.ACR
+		}
+		if claims.Act != nil {
+			response["act"] = claims.Act
+		}
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
 		json.NewEncoder(w).Encode(response)
@@ -84,7 +94,7 @@ func (h *IntrospectionHandler) Introspect(w http.ResponseWriter, r *http.Request
 	// If not a valid JWT, check if it's a Refresh Token in the database.
 	signature := h.tokenService.HashToken(tokenToInspect)
 	token, err := h.tokenService.GetTokenBySignature(r.Context(), signature)
-	if err == nil && token.Type == models.TokenTypeRefreshToken {
+	if err == nil && token.Type == models.TokenTypeRefreshToken && token.RotatedAt.IsZero() {
 		// Check if it's expired
 		if time.Now().Before(token.ExpiresAt) {
 			response := map[string]any{
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/aminshahid573/authexa/internal/utils"
+)
+
+// clientAuthParams are the form parameters that authenticate the client rather than
+// describe the authorization request, and so are not stored with a pushed request.
+var clientAuthParams = []string{"client_secret", "client_assertion", "client_assertion_type"}
+
+// PushAuthorizationRequest handles POST requests to the PAR endpoint (RFC 9126). The client
+// authenticates as it would at the token endpoint, and receives a request_uri to send to
+// the authorization endpoint in place of the request parameters.
+func (h *AuthHandler) PushAuthorizationRequest(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		h.writeTokenError(w, "invalid_request", "The request is malformed.")
+		return
+	}
+
+	client, err := h.authenticateClient(r, "/oauth2/par")
+	if err != nil {
+		h.writeTokenError(w, "invalid_client", "Client authentication failed.")
+		return
+	}
+
+	params := url.Values{}
+	for key, values := range r.PostForm {
+		params[key] = values
+	}
+	for _, key := range clientAuthParams {
+		params.Del(key)
+	}
+
+	if params.Has("request_uri") {
+		h.writeTokenError(w, "invalid_request", "The request_uri parameter cannot be pushed.")
+		return
+	}
+	if clientID := params.Get("client_id"); clientID != "" && clientID != client.ClientID {
+		h.writeTokenError(w, "invalid_request", "The client_id does not match the authenticated client.")
+		return
+	}
+	params.Set("client_id", client.ClientID)
+
+	// A request object is verified now, so the stored request holds its resolved parameters.
+	if params.Has("request") {
+		if params, err = h.requestObjectService.Resolve(r.Context(), client, params); err != nil {
+			h.writeAuthorizationRequestError(w, err)
+			return
+		}
+	} else if client.RequireSignedRequestObject {
+		h.writeAuthorizationRequestError(w, errSignedRequestObjectRequired)
+		return
+	}
+
+	if err := h.validateAuthorizationRequest(r.Context(), client, params); err != nil {
+		h.writeAuthorizationRequestError(w, err)
+		return
+	}
+
+	requestURI, lifetime, err := h.parService.Push(r.Context(), client.ClientID, params)
+	if err != nil {
+		h.logger.Error("failed to store pushed authorization request", "error", err)
+		h.writeTokenError(w, "server_error", "The server encountered an error.")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]any{
+		"request_uri": requestURI,
+		"expires_in":  int(lifetime.Seconds()),
+	})
+}
+
+// writeAuthorizationRequestError reports why a pushed authorization request was rejected.
+func (h *AuthHandler) writeAuthorizationRequestError(w http.ResponseWriter, err error) {
+	var appErr *utils.AppError
+	if errors.As(err, &appErr) {
+		h.writeTokenError(w, appErr.Code, appErr.Message)
+		return
+	}
+	h.logger.Error("failed to process pushed authorization request", "error", err)
+	h.writeTokenError(w, "invalid_request", "The authorization request is invalid.")
+}
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/aminshahid573/authexa/internal/middleware"
+	"github.com/aminshahid573/authexa/internal/models"
+	"github.com/aminshahid573/authexa/internal/services"
+	"github.com/aminshahid573/authexa/internal/utils"
+)
+
+// RegistrationHandler serves the dynamic client registration endpoint (RFC 7591)
+// and the client configuration endpoint (RFC 7592).
+type RegistrationHandler struct {
+	logger              *slog.Logger
+	registrationService *services.RegistrationService
+	auditService        *services.AuditService
+	baseURL             string
+}
+
+// NewRegistrationHandler creates a new RegistrationHandler.
+func NewRegistrationHandler(logger *slog.Logger, registrationService *services.RegistrationService, auditService *services.AuditService, baseURL string) *RegistrationHandler {
+	return &RegistrationHandler{
+		logger:              logger,
+		registrationService: registrationService,
+		auditService:        auditService,
+		baseURL:             baseURL,
+	}
+}
+
+// Enabled reports whether the registration endpoints should be served.
+func (h *RegistrationHandler) Enabled() bool {
+	return h.registrationService.Enabled()
+}
+
+// Register handles POST /oauth2/register.
+func (h *RegistrationHandler) Register(w http.ResponseWriter, r *http.Request) {
+	if err := h.registrationService.CheckInitialAccessToken(bearerToken(r)); err != nil {
+		utils.HandleAPIError(w, r, h.logger, err)
+		return
+	}
+
+	var metadata services.ClientMetadata
+	if err := json.NewDecoder(r.Body).Decode(&metadata); err != nil {
+		utils.HandleAPIError(w, r, h.logger, &utils.AppError{Code: "invalid_client_metadata", Message: "The request body is not valid client metadata.", HTTPStatus: http.StatusBadRequest})
+		return
+	}
+
+	client, secret, registrationToken, err := h.registrationService.Register(r.Context(), metadata)
+	if err != nil {
+		utils.HandleAPIError(w, r, h.logger, err)
+		return
+	}
+
+	response := h.registrationResponse(client)
+	response["client_id_issued_at"] = client.CreatedAt.Unix()
+	response["registration_access_token"] = registrationToken
+	if secret != "" {
+		response["client_secret"] = secret
+		response["client_secret_expires_at"] = 0 // The secret does not expire.
+	}
+
+	h.recordEvent(r, models.ClientCreated, client.ClientID, "Client registered via dynamic client registration.")
+	h.writeJSON(w, http.StatusCreated, response)
+}
+
+// GetRegistration handles GET /oauth2/register/{clientID}.
+func (h *RegistrationHandler) GetRegistration(w http.ResponseWriter, r *http.Request) {
+	client, err := h.registrationService.Authorize(r.Context(), r.PathValue("clientID"), bearerToken(r))
+	if err != nil {
+		utils.HandleAPIError(w, r, h.logger, err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, h.registrationResponse(client))
+}
+
+// UpdateRegistration handles PUT /oauth2/register/{clientID}.
+func (h *RegistrationHandler) UpdateRegistration(w http.ResponseWriter, r *http.Request) {
+	client, err := h.registrationService.Authorize(r.Context(), r.PathValue("clientID"), bearerToken(r))
+	if err != nil {
+		utils.HandleAPIError(w, r, h.logger, err)
+		return
+	}
+
+	var metadata services.ClientMetadata
+	if err := json.NewDecoder(r.Body).Decode(&metadata); err != nil {
+		utils.HandleAPIError(w, r, h.logger, &utils.AppError{Code: "invalid_client_metadata", Message: "The request body is not valid client metadata.", HTTPStatus: http.StatusBadRequest})
+		return
+	}
+
+	updated, err := h.registrationService.Update(r.Context(), client, metadata)
+	if err != nil {
+		utils.HandleAPIError(w, r, h.logger, err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, h.registrationResponse(updated))
+}
+
+// DeleteRegistration handles DELETE /oauth2/register/{clientID}.
+func (h *RegistrationHandler) DeleteRegistration(w http.ResponseWriter, r *http.Request) {
+	client, err := h.registrationService.Authorize(r.Context(), r.PathValue("clientID"), bearerToken(r))
+	if err != nil {
+		utils.HandleAPIError(w, r, h.logger, err)
+		return
+	}
+
+	if err := h.registrationService.Delete(r.Context(), client); err != nil {
+		utils.HandleAPIError(w, r, h.logger, err)
+		return
+	}
+
+	h.recordEvent(r, models.ClientDeleted, client.ClientID, "Client deregistered via the client configuration endpoint.")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// registrationResponse builds the client information response of RFC 7591 section 3.2.1.
+func (h *RegistrationHandler) registrationResponse(client *models.Client) map[string]any {
+	// Round-trip through JSON so the metadata keeps its RFC field names and omissions.
+	var response map[string]any
+	data, _ := json.Marshal(h.registrationService.Metadata(client))
+	_ = json.Unmarshal(data, &response)
+
+	response["registration_client_uri"] = h.baseURL + "/oauth2/register/" + client.ClientID
+	return response
+}
+
+// recordEvent writes an audit event for a change made through the registration endpoints.
+// The client itself is the actor, as no user is involved.
+func (h *RegistrationHandler) recordEvent(r *http.Request, eventType models.EventType, clientID, details string) {
+	eventData := services.RecordEventData{
+		EventType: eventType,
+		ActorID:   clientID,
+		TargetID:  clientID,
+		IPAddress: middleware.GetClientIP(r),
+		UserAgent: r.UserAgent(),
+		Details:   details,
+	}
+	_ = h.auditService.Record(r.Context(), eventData)
+}
+
+// writeJSON writes a registration response. Responses carry credentials, so they must not be cached.
+func (h *RegistrationHandler) writeJSON(w http.ResponseWriter, status int, body map[string]any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		h.logger.Error("failed to write registration response", "error", err)
+	}
+}
+
+// bearerToken returns the token of a "Bearer" Authorization header, or an empty string.
+func bearerToken(r *http.Request) string {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
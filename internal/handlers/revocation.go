@@ -4,39 +4,49 @@ import (
 	"log/slog"
 	"net/http"
 
+	"github.com/aminshahid573/authexa/internal/models"
 	"github.com/aminshahid573/authexa/internal/services"
 )
 
 // RevocationHandler handles token revocation requests.
 type RevocationHandler struct {
-	logger        *slog.Logger
-	clientService *services.ClientService
-	tokenService  *services.TokenService
+	logger              *slog.Logger
+	clientService       *services.ClientService
+	clientAuthenticator *services.ClientAuthenticator
+	tokenService        *services.TokenService
+	// revokeAccessTokens controls whether revoking a refresh token also revokes
+	// the access tokens minted from its grant.
+	revokeAccessTokens bool
 }
 
 // NewRevocationHandler creates a new RevocationHandler.
-func NewRevocationHandler(logger *slog.Logger, clientService *services.ClientService, tokenService *services.TokenService) *RevocationHandler {
+func NewRevocationHandler(logger *slog.Logger, clientService *services.ClientService, clientAuthenticator *services.ClientAuthenticator, tokenService *services.TokenService, revokeAccessTokens bool) *RevocationHandler {
 	return &RevocationHandler{
-		logger:        logger,
-		clientService: clientService,
-		tokenService:  tokenService,
+		logger:              logger,
+		clientService:       clientService,
+		clientAuthenticator: clientAuthenticator,
+		tokenService:        tokenService,
+		revokeAccessTokens:  revokeAccessTokens,
 	}
 }
 
+// revocationEndpointAuthMethods lists the client authentication methods accepted by Revoke.
+var revocationEndpointAuthMethods = []string{
+	models.ClientAuthMethodSecretBasic,
+	models.ClientAuthMethodSecretPost,
+	models.ClientAuthMethodNone,
+	models.ClientAuthMethodPrivateKeyJWT,
+	models.ClientAuthMethodSecretJWT,
+}
+
 // Revoke is the main handler for the revocation endpoint.
 func (h *RevocationHandler) Revoke(w http.ResponseWriter, r *http.Request) {
 	// 1. Authenticate the client.
-	// The client can authenticate using basic auth or by including credentials in the body.
-	// We'll support basic auth for simplicity.
-	clientID, clientSecret, ok := r.BasicAuth()
-	if !ok {
-		// Fallback to checking form parameters
-		clientID = r.PostFormValue("client_id")
-		clientSecret = r.PostFormValue("client_secret")
-	}
-
-	client, err := h.clientService.ValidateClientCredentials(r.Context(), clientID, clientSecret)
+	// The client can authenticate using basic auth, a client assertion or by including
+	// credentials in the body. Public clients identify themselves with their client_id alone.
+	client, err := h.clientAuthenticator.Authenticate(r.Context(), clientCredentials(r), h.clientService.GetBaseURL()+"/oauth2/revoke")
 	if err != nil {
+		h.logger.Warn("client authentication failed at revocation endpoint", "error", err)
 		// RFC 7009 says to return 200 OK even for invalid clients to prevent snooping.
 		w.WriteHeader(http.StatusOK)
 		return
@@ -53,14 +63,12 @@ func (h *RevocationHandler) Revoke(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// 3. For now, we only support revoking refresh tokens.
-	// Access tokens are stateless JWTs and will expire on their own.
-	// Revoking the refresh token prevents new access tokens from being issued.
+	// 3. Refresh tokens and reference access tokens are looked up in the database first.
+	// Any other token is treated as a JWT access token and added to the jti denylist.
 	signature := h.tokenService.HashToken(tokenToRevoke)
 	token, err := h.tokenService.GetTokenBySignature(r.Context(), signature)
 	if err != nil {
-		// Token not found, but we still return 200 OK as per RFC.
-		w.WriteHeader(http.StatusOK)
+		h.revokeAccessToken(w, r, client.ClientID, tokenToRevoke)
 		return
 	}
 
@@ -71,8 +79,13 @@ func (h *RevocationHandler) Revoke(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// 5. Delete the token from the database.
-	err = h.tokenService.DeleteTokenBySignature(r.Context(), signature)
+	// 5. Delete the token, and the rest of its grant, from the database. Deleting a
+	// reference access token revokes it immediately.
+	if token.Type == models.TokenTypeRefreshToken {
+		err = h.tokenService.RevokeRefreshToken(r.Context(), token, h.revokeAccessTokens)
+	} else {
+		err = h.tokenService.DeleteTokenBySignature(r.Context(), signature)
+	}
 	if err != nil {
 		h.logger.Error("failed to delete token during revocation", "error", err)
 		// Even if deletion fails, we should probably not signal an error to the client.
@@ -81,3 +94,26 @@ func (h *RevocationHandler) Revoke(w http.ResponseWriter, r *http.Request) {
 	h.logger.Info("token revoked successfully", "client_id", client.ClientID)
 	w.WriteHeader(http.StatusOK)
 }
+
+// revokeAccessToken denylists a JWT access token for the rest of its lifetime.
+// Unknown, expired or foreign tokens are silently ignored, as RFC 7009 requires.
+func (h *RevocationHandler) revokeAccessToken(w http.ResponseWriter, r *http.Request, clientID, tokenStr string) {
+	claims, err := h.tokenService.VerifyAccessToken(r.Context(), tokenStr)
+	if err != nil {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if claims.ClientID != clientID {
+		h.logger.Warn("client attempted to revoke a token not belonging to it", "requesting_client", clientID, "token_client", claims.ClientID)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if err := h.tokenService.RevokeAccessToken(r.Context(), claims); err != nil {
+		h.logger.Error("failed to denylist access token during revocation", "error", err)
+	} else {
+		h.logger.Info("access token revoked successfully", "client_id", clientID, "jti", claims.ID)
+	}
+	w.WriteHeader(http.StatusOK)
+}
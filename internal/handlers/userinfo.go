@@ -6,24 +6,26 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/aminshahid573/authexa/internal/services"
 	"github.com/aminshahid573/authexa/internal/storage"
-	"github.com/aminshahid573/authexa/internal/utils"
 	"go.mongodb.org/mongo-driver/v2/bson"
 )
 
 // UserInfoHandler handles requests for user information.
 type UserInfoHandler struct {
-	logger     *slog.Logger
-	jwtManager *utils.JWTManager
-	userStore  storage.UserStore
+	logger       *slog.Logger
+	tokenService *services.TokenService
+	scopeService *services.ScopeService
+	userStore    storage.UserStore
 }
 
 // NewUserInfoHandler creates a new UserInfoHandler.
-func NewUserInfoHandler(logger *slog.Logger, jwtManager *utils.JWTManager, userStore storage.UserStore) *UserInfoHandler {
+func NewUserInfoHandler(logger *slog.Logger, tokenService *services.TokenService, scopeService *services.ScopeService, userStore storage.UserStore) *UserInfoHandler {
 	return &UserInfoHandler{
-		logger:     logger,
-		jwtManager: jwtManager,
-		userStore:  userStore,
+		logger:       logger,
+		tokenService: tokenService,
+		scopeService: scopeService,
+		userStore:    userStore,
 	}
 }
 
@@ -42,8 +44,8 @@ func (h *UserInfoHandler) GetUserInfo(w http.ResponseWriter, r *http.Request) {
 	}
 	tokenStr := parts[1]
 
-	// 2. Validate the access token.
-	claims, err := h.jwtManager.VerifyToken(tokenStr)
+	// 2. Validate the access token, including its revocation status.
+	claims, err := h.tokenService.VerifyAccessToken(r.Context(), tokenStr)
 	if err != nil {
 		h.writeError(w, http.StatusUnauthorized, "invalid_token", "Access token is invalid or expired")
 		return
@@ -63,24 +65,22 @@ func (h *UserInfoHandler) GetUserInfo(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// 4. Construct the response based on the scopes granted to the token.
+	// 4. Construct the response from the claims the scope registry releases for the token's scopes.
 	userInfo := make(map[string]any)
 	// The 'sub' claim is always required.
 	userInfo["sub"] = user.ID.Hex()
 
-	scopeMap := make(map[string]bool)
-	for _, s := range claims.Scope {
-		scopeMap[s] = true
-	}
-
-	if scopeMap["profile"] {
-		userInfo["name"] = user.Username // In a real app, you'd have more profile fields.
-		userInfo["preferred_username"] = user.Username
-	}
-	if scopeMap["email"] {
+	userClaims := map[string]any{
+		"name":               user.Username, // In a real app, you'd have more profile fields.
+		"preferred_username": user.Username,
 		// Our user model doesn't have an email field, so we'll use the username as a placeholder.
-		userInfo["email"] = user.Username + "@example.com"
-		userInfo["email_verified"] = false // In a real app, this would be a real field.
+		"email":          user.Username + "@example.com",
+		"email_verified": false, // In a real app, this would be a real field.
+	}
+	for _, claim := range h.scopeService.ReleasedClaims(claims.Scope) {
+		if value, ok := userClaims[claim]; ok {
+			userInfo[claim] = value
+		}
 	}
 
 	w.Header().Set("Content-Type", "application/json")
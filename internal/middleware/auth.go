@@ -14,7 +14,10 @@ import (
 // CtxUserKey is the key for storing the user object in the request context.
 type CtxUserKey string
 
-const UserKey CtxUserKey = "user"
+const (
+	UserKey    CtxUserKey = "user"
+	SessionKey CtxUserKey = "session"
+)
 
 // AuthMiddleware provides middleware for authentication.
 type AuthMiddleware struct {
@@ -38,31 +41,52 @@ func NewAuthMiddleware(logger *slog.Logger, sessionService *services.SessionServ
 // If not, it redirects them to the login page.
 func (m *AuthMiddleware) RequireAuth(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		sessionCookie, err := r.Cookie("session_id")
-		if err != nil { // No cookie found
+		ctx, ok := m.authenticate(w, r)
+		if !ok {
 			m.redirectToLogin(w, r)
 			return
 		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
 
-		session, err := m.sessionService.GetSession(r.Context(), sessionCookie.Value)
-		if err != nil { // Invalid session
-			m.clearSessionCookie(w)
-			m.redirectToLogin(w, r)
-			return
+// LoadSession is a middleware that adds the user and session to the request context when
+// the user is logged in, but lets anonymous requests through. It is used where the handler
+// decides itself whether, and how, to ask the user to log in.
+func (m *AuthMiddleware) LoadSession(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ctx, ok := m.authenticate(w, r); ok {
+			r = r.WithContext(ctx)
 		}
+		next.ServeHTTP(w, r)
+	})
+}
 
-		// Fetch the full user object from the database using the storage interface
-		user, err := m.userService.GetByID(r.Context(), session.UserID)
-		if err != nil {
-			m.clearSessionCookie(w)
-			m.redirectToLogin(w, r)
-			return
-		}
+// authenticate loads the session named by the session cookie and its user, and returns a
+// context carrying both. A cookie for an invalid session is cleared.
+func (m *AuthMiddleware) authenticate(w http.ResponseWriter, r *http.Request) (context.Context, bool) {
+	sessionCookie, err := r.Cookie("session_id")
+	if err != nil { // No cookie found
+		return nil, false
+	}
 
-		// Add the user to the request context for later handlers to use.
-		ctx := context.WithValue(r.Context(), UserKey, user)
-		next.ServeHTTP(w, r.WithContext(ctx))
-	})
+	session, err := m.sessionService.GetSession(r.Context(), sessionCookie.Value)
+	if err != nil { // Invalid session
+		m.clearSessionCookie(w)
+		return nil, false
+	}
+
+	// Fetch the full user object from the database using the storage interface
+	user, err := m.userService.GetByID(r.Context(), session.UserID)
+	if err != nil {
+		m.clearSessionCookie(w)
+		return nil, false
+	}
+
+	// Add the user and session to the request context for later handlers to use.
+	ctx := context.WithValue(r.Context(), UserKey, user)
+	ctx = context.WithValue(ctx, SessionKey, session)
+	return ctx, true
 }
 
 // RequireAdmin is a middleware that ensures a user is authenticated AND is an admin.
@@ -109,3 +133,9 @@ func GetUserFromContext(r *http.Request) (*models.User, bool) {
 	user, ok := r.Context().Value(UserKey).(*models.User)
 	return user, ok
 }
+
+// GetSessionFromContext retrieves the authenticated user's session from the request context.
+func GetSessionFromContext(r *http.Request) (*models.Session, bool) {
+	session, ok := r.Context().Value(SessionKey).(*models.Session)
+	return session, ok
+}
@@ -16,6 +16,19 @@ const (
 	ClientCreated    EventType = "CLIENT_CREATED"
 	ClientDeleted    EventType = "CLIENT_DELETED"
 	UserCreated      EventType = "USER_CREATED"
+
+	RefreshTokenReuseDetected EventType = "REFRESH_TOKEN_REUSE_DETECTED"
+	SigningKeyRotated         EventType = "SIGNING_KEY_ROTATED"
+	SigningKeyRevoked         EventType = "SIGNING_KEY_REVOKED"
+	ScopeCreated              EventType = "SCOPE_CREATED"
+	ScopeUpdated              EventType = "SCOPE_UPDATED"
+	ScopeDeleted              EventType = "SCOPE_DELETED"
+	ResourceServerCreated     EventType = "RESOURCE_SERVER_CREATED"
+	ResourceServerUpdated     EventType = "RESOURCE_SERVER_UPDATED"
+	ResourceServerDeleted     EventType = "RESOURCE_SERVER_DELETED"
+	TrustedIssuerCreated      EventType = "TRUSTED_ISSUER_CREATED"
+	TrustedIssuerUpdated      EventType = "TRUSTED_ISSUER_UPDATED"
+	TrustedIssuerDeleted      EventType = "TRUSTED_ISSUER_DELETED"
 )
 
 // AuditEvent represents a single logged action in the system.
@@ -0,0 +1,79 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// Response modes an authorization response can be delivered in: OAuth 2.0 Multiple Response
+// Types, Form Post Response Mode and the JWT-secured modes of JARM.
+const (
+	ResponseModeQuery       = "query"
+	ResponseModeFragment    = "fragment"
+	ResponseModeFormPost    = "form_post"
+	ResponseModeJWT         = "jwt"
+	ResponseModeQueryJWT    = "query.jwt"
+	ResponseModeFragmentJWT = "fragment.jwt"
+	ResponseModeFormPostJWT = "form_post.jwt"
+)
+
+// SupportedResponseModes lists every response_mode the authorization endpoint accepts.
+var SupportedResponseModes = []string{
+	ResponseModeQuery,
+	ResponseModeFragment,
+	ResponseModeFormPost,
+	ResponseModeJWT,
+	ResponseModeQueryJWT,
+	ResponseModeFragmentJWT,
+	ResponseModeFormPostJWT,
+}
+
+// Values of the OIDC prompt parameter.
+const (
+	PromptNone          = "none"
+	PromptLogin         = "login"
+	PromptConsent       = "consent"
+	PromptSelectAccount = "select_account"
+)
+
+// SupportedPrompts lists every prompt value the authorization endpoint accepts.
+var SupportedPrompts = []string{PromptNone, PromptLogin, PromptConsent, PromptSelectAccount}
+
+// AuthorizationRequest is a validated authorization request awaiting the user's decision
+// on the consent page. It is kept server-side and bound to the user's session, so the
+// consent form carries only its ID and the request cannot be altered in the browser.
+// A request waiting for the user to log in is not yet bound to a session.
+type AuthorizationRequest struct {
+	ID                  string    `json:"id"`
+	SessionID           string    `json:"session_id"`
+	ClientID            string    `json:"client_id"`
+	RedirectURI         string    `json:"redirect_uri"`
+	Scopes              []string  `json:"scopes"`
+	State               string    `json:"state,omitempty"`
+	Nonce               string    `json:"nonce,omitempty"`
+	CodeChallenge       string    `json:"code_challenge,omitempty"`
+	CodeChallengeMethod string    `json:"code_challenge_method,omitempty"`
+	ResponseMode        string    `json:"response_mode,omitempty"`
+	CreatedAt           time.Time `json:"created_at"`
+	ExpiresAt           time.Time `json:"expires_at"`
+
+	// OIDC authentication parameters, checked against the user's session.
+	Prompt      []string `json:"prompt,omitempty"`
+	MaxAge      *int     `json:"max_age,omitempty"`
+	LoginHint   string   `json:"login_hint,omitempty"`
+	HintSubject string   `json:"hint_subject,omitempty"` // Subject of a verified id_token_hint
+
+	// IncludeGrantedScopes adds the scopes the user approved for the client before to the
+	// scopes of the new authorization (incremental authorization).
+	IncludeGrantedScopes bool `json:"include_granted_scopes,omitempty"`
+	// PendingScopes are the requested scopes the user has not approved before. They are
+	// listed on the consent page, where the user may deselect those that are optional.
+	PendingScopes []string `json:"pending_scopes,omitempty"`
+	// Resources are the resource servers the client asked to access (RFC 8707).
+	Resources []string `json:"resources,omitempty"`
+}
+
+// HasPrompt reports whether the request was sent with the given prompt value.
+func (r *AuthorizationRequest) HasPrompt(prompt string) bool {
+	return slices.Contains(r.Prompt, prompt)
+}
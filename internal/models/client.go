@@ -1,6 +1,7 @@
 package models
 
 import (
+	"slices"
 	"time"
 
 	"go.mongodb.org/mongo-driver/v2/bson"
@@ -13,8 +14,27 @@ const (
 	GrantTypeRefreshToken      = "refresh_token"
 	GrantTypeDeviceCode        = "urn:ietf:params:oauth:grant-type:device_code"
 	GrantTypeJWTBearer         = "urn:ietf:params:oauth:grant-type:jwt-bearer"
+	GrantTypeTokenExchange     = "urn:ietf:params:oauth:grant-type:token-exchange"
 )
 
+// Constants for supported client authentication methods at the token endpoint.
+const (
+	ClientAuthMethodSecretBasic   = "client_secret_basic"
+	ClientAuthMethodSecretPost    = "client_secret_post"
+	ClientAuthMethodNone          = "none"
+	ClientAuthMethodPrivateKeyJWT = "private_key_jwt"
+	ClientAuthMethodSecretJWT     = "client_secret_jwt"
+)
+
+// Constants for the formats a client's access tokens can be issued in.
+const (
+	AccessTokenFormatJWT       = "jwt"
+	AccessTokenFormatReference = "reference"
+)
+
+// ClientAssertionTypeJWTBearer is the client_assertion_type of RFC 7523 client assertions.
+const ClientAssertionTypeJWTBearer = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+
 // Client represents an OAuth2 client application.
 type Client struct {
 	ID            bson.ObjectID `bson:"_id,omitempty"`
@@ -28,4 +48,74 @@ type Client struct {
 	JWKSURL       string        `bson:"jwks_url,omitempty"`
 	CreatedAt     time.Time     `bson:"created_at"`
 	UpdatedAt     time.Time     `bson:"updated_at"`
+
+	// PostLogoutRedirectURIs lists where RP-initiated logout may send the user afterwards.
+	PostLogoutRedirectURIs []string `bson:"post_logout_redirect_uris,omitempty"`
+
+	// TokenEndpointAuthMethod is how the client authenticates at the token endpoint.
+	// Empty accepts both client_secret_basic and client_secret_post.
+	TokenEndpointAuthMethod string `bson:"token_endpoint_auth_method,omitempty"`
+	// JWKS is an inline JSON Web Key Set, used instead of JWKSURL to verify private_key_jwt assertions.
+	JWKS string `bson:"jwks,omitempty"`
+	// ClientSecretEncrypted holds the client secret encrypted at rest for client_secret_jwt,
+	// which needs the plaintext secret as the HMAC key. ClientSecret still holds the hash.
+	ClientSecretEncrypted string `bson:"client_secret_encrypted,omitempty"`
+	// RegistrationAccessToken is the hash of the token that authorizes RFC 7592
+	// management of a dynamically registered client. It is empty for other clients.
+	RegistrationAccessToken string `bson:"registration_access_token,omitempty"`
+	// RequestURIs lists the URLs the client may pass as a request_uri for the
+	// authorization server to fetch a request object from.
+	RequestURIs []string `bson:"request_uris,omitempty"`
+	// RequiredScopes lists the scopes the user cannot deselect on the consent page.
+	RequiredScopes []string `bson:"required_scopes,omitempty"`
+
+	// RefreshTokenRotationDisabled opts the client out of refresh token rotation,
+	// leaving its refresh tokens reusable until they expire.
+	RefreshTokenRotationDisabled bool `bson:"refresh_token_rotation_disabled,omitempty"`
+	// RequirePushedAuthorizationRequests makes the authorization endpoint accept the
+	// client's requests only through a request_uri from the PAR endpoint.
+	RequirePushedAuthorizationRequests bool `bson:"require_pushed_authorization_requests,omitempty"`
+	// RequireSignedRequestObject makes the authorization endpoint accept the client's
+	// requests only as a signed request object (RFC 9101).
+	RequireSignedRequestObject bool `bson:"require_signed_request_object,omitempty"`
+
+	// IDTokenSignedResponseAlg and AccessTokenSigningAlg select the JWS algorithm used
+	// for the client's tokens. Empty values fall back to RS256.
+	IDTokenSignedResponseAlg string `bson:"id_token_signed_response_alg,omitempty"`
+	AccessTokenSigningAlg    string `bson:"access_token_signing_alg,omitempty"`
+	// AccessTokenFormat selects between JWT access tokens and opaque reference tokens,
+	// which only the server can resolve. Empty means jwt.
+	AccessTokenFormat string `bson:"access_token_format,omitempty"`
+	// RequestObjectSigningAlg restricts the JWS algorithm of the client's request objects.
+	// Empty accepts any supported algorithm.
+	RequestObjectSigningAlg string `bson:"request_object_signing_alg,omitempty"`
+	// AuthorizationSignedResponseAlg selects the JWS algorithm of JARM authorization
+	// responses. Empty falls back to RS256.
+	AuthorizationSignedResponseAlg string `bson:"authorization_signed_response_alg,omitempty"`
+
+	// DefaultResponseMode is used when an authorization request has no response_mode.
+	// Empty means query.
+	DefaultResponseMode string `bson:"default_response_mode,omitempty"`
+
+	// Trusted marks a first-party client whose authorization requests need no consent
+	// from the user. Only administrators can set it.
+	Trusted bool `bson:"trusted,omitempty"`
+
+	// TokenExchangeAudiences and TokenExchangeScopes are the client's token exchange policy
+	// (RFC 8693): the audiences, besides itself, and the scopes it may obtain by exchanging
+	// tokens. Only administrators can set them.
+	TokenExchangeAudiences []string `bson:"token_exchange_audiences,omitempty"`
+	TokenExchangeScopes    []string `bson:"token_exchange_scopes,omitempty"`
+}
+
+// IsScopeRequired reports whether the user must grant scope for the client's request to
+// proceed. openid is always required, as it decides whether the request is an OIDC one.
+func (c *Client) IsScopeRequired(scope string) bool {
+	return scope == "openid" || slices.Contains(c.RequiredScopes, scope)
+}
+
+// IsPublic reports whether the client cannot keep a secret, such as a SPA or a mobile app.
+// Public clients authenticate with their client_id alone and must use PKCE.
+func (c *Client) IsPublic() bool {
+	return c.TokenEndpointAuthMethod == ClientAuthMethodNone
 }
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// ConsentGrant records the scopes a user has approved for a client, so the consent
+// page is only shown again for scopes the user has not approved yet.
+type ConsentGrant struct {
+	ID        bson.ObjectID `bson:"_id,omitempty"`
+	UserID    string        `bson:"user_id"`
+	ClientID  string        `bson:"client_id"`
+	Scopes    []string      `bson:"scopes"`
+	CreatedAt time.Time     `bson:"created_at"`
+	UpdatedAt time.Time     `bson:"updated_at"`
+	ExpiresAt time.Time     `bson:"expires_at,omitempty"` // Zero when the grant does not expire
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"net/url"
+	"time"
+)
+
+// RequestURIPrefix prefixes the request_uri values issued by the PAR endpoint (RFC 9126).
+const RequestURIPrefix = "urn:ietf:params:oauth:request_uri:"
+
+// PushedAuthorizationRequest is an authorization request a client pushed to the
+// PAR endpoint, stored until the user agent presents its request_uri.
+type PushedAuthorizationRequest struct {
+	RequestURI string     `json:"request_uri"`
+	ClientID   string     `json:"client_id"`
+	Parameters url.Values `json:"parameters"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// ResourceServer is an API that accepts access tokens, identified by an absolute URI that
+// clients send as the RFC 8707 resource parameter. It owns the registry scopes whose API
+// is its identifier, and access tokens minted for it carry only those scopes.
+type ResourceServer struct {
+	ID         bson.ObjectID `bson:"_id,omitempty"`
+	Identifier string        `bson:"identifier"`
+	Name       string        `bson:"name"`
+	CreatedAt  time.Time     `bson:"created_at"`
+	UpdatedAt  time.Time     `bson:"updated_at"`
+}
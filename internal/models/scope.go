@@ -1,7 +1,46 @@
 package models
 
-// Scope defines a permission that a client can request.
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Types of scope template parameters.
+const (
+	ScopeParameterString  = "string"
+	ScopeParameterInteger = "integer"
+	ScopeParameterUUID    = "uuid"
+)
+
+// ScopeParameter declares a typed parameter of a scope template, such as folderId in
+// "documents:read:{folderId}".
+type ScopeParameter struct {
+	Name string `bson:"name"`
+	Type string `bson:"type"`
+}
+
+// Scope defines a permission that a client can request. Scopes are kept in a registry
+// that administrators manage through the admin API.
 type Scope struct {
-	Name        string
-	Description string
+	ID          bson.ObjectID `bson:"_id,omitempty"`
+	Name        string        `bson:"name"`
+	Description string        `bson:"description"`
+	API         string        `bson:"api,omitempty"`    // The API that owns the scope; empty for OpenID Connect scopes
+	Claims      []string      `bson:"claims,omitempty"` // User claims the scope releases at the userinfo endpoint
+	CreatedAt   time.Time     `bson:"created_at"`
+	UpdatedAt   time.Time     `bson:"updated_at"`
+
+	// ConsentRequired lists the scope on the consent page. Scopes that do not need consent
+	// are granted without asking the user.
+	ConsentRequired bool `bson:"consent_required"`
+	// Default includes the scope in authorization requests that name no scope.
+	Default bool `bson:"default,omitempty"`
+
+	// Parameters makes the scope a template: each parameter appears in the name and the
+	// description as {name}, and requests substitute a value of the parameter's type.
+	Parameters []ScopeParameter `bson:"parameters,omitempty"`
+	// Implies lists the scopes granted along with this one. They may use the parameters of
+	// this scope, such as "documents:read:{folderId}" implied by "documents:write:{folderId}".
+	Implies []string `bson:"implies,omitempty"`
 }
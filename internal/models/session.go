@@ -6,9 +6,23 @@ import (
 	"go.mongodb.org/mongo-driver/v2/bson"
 )
 
+// Authentication method references (RFC 8176) recorded on a session.
+const (
+	AMRPassword = "pwd"
+)
+
+// Authentication context class references (OIDC Core section 2, "acr") recorded on a session.
+const (
+	// ACRPassword is the context class of a login with a password alone.
+	ACRPassword = "urn:authexa:acr:password"
+)
+
 // Session represents a user's login session.
 type Session struct {
 	ID        string        `json:"id"`
 	UserID    bson.ObjectID `json:"user_id"`
+	AuthTime  time.Time     `json:"auth_time"`     // When the user authenticated
+	AMR       []string      `json:"amr,omitempty"` // How the user authenticated
+	ACR       string        `json:"acr,omitempty"` // The authentication context class
 	ExpiresAt time.Time     `json:"expires_at"`
 }
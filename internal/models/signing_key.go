@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// SigningKeyState describes where a signing key is in its lifecycle.
+type SigningKeyState string
+
+// Constants for the signing key lifecycle. A key is published as "next" ahead of use,
+// signs tokens while "active", and is still published for verification while "retired".
+// A "revoked" key is withdrawn immediately.
+const (
+	SigningKeyStateNext    SigningKeyState = "next"
+	SigningKeyStateActive  SigningKeyState = "active"
+	SigningKeyStateRetired SigningKeyState = "retired"
+	SigningKeyStateRevoked SigningKeyState = "revoked"
+)
+
+// SigningKey represents a persisted private key used to sign JWTs.
+type SigningKey struct {
+	ID        bson.ObjectID   `bson:"_id,omitempty"`
+	KeyID     string          `bson:"kid"` // RFC 7638 thumbprint of the public key
+	Algorithm string          `bson:"alg"`
+	State     SigningKeyState `bson:"state"`
+	// PrivateKey is the PEM-encoded private key, encrypted at rest.
+	PrivateKey  string    `bson:"private_key"`
+	CreatedAt   time.Time `bson:"created_at"`
+	ActivatedAt time.Time `bson:"activated_at,omitempty"`
+	RetiredAt   time.Time `bson:"retired_at,omitempty"`
+	RevokedAt   time.Time `bson:"revoked_at,omitempty"`
+}
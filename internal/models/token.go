@@ -13,9 +13,10 @@ const (
 	TokenTypeAuthorizationCode TokenType = "auth_code"
 	TokenTypeRefreshToken      TokenType = "refresh_token"
 	TokenTypeDeviceCode        TokenType = "device_code"
+	TokenTypeAccessToken       TokenType = "access_token"
 )
 
-// Token represents a stored authorization code or refresh token.
+// Token represents a stored authorization code, refresh token, device code or reference access token.
 // We store a signature/hash of the token/code for security, not the raw value.
 type Token struct {
 	ID        bson.ObjectID `bson:"_id,omitempty"`
@@ -28,4 +29,40 @@ type Token struct {
 	Type      TokenType     `bson:"type"`
 	CreatedAt time.Time     `bson:"created_at"`
 	Approved  bool          `bson:"approved,omitempty"`
+
+	// Authorization request context, bound to authorization codes at issuance
+	// and verified when the code is exchanged.
+	RedirectURI         string    `bson:"redirect_uri,omitempty"`
+	Nonce               string    `bson:"nonce,omitempty"`
+	AuthTime            time.Time `bson:"auth_time,omitempty"` // When the user authenticated
+	AMR                 []string  `bson:"amr,omitempty"`       // How the user authenticated
+	ACR                 string    `bson:"acr,omitempty"`       // The authentication context class
+	SessionID           string    `bson:"session_id,omitempty"`
+	CodeChallenge       string    `bson:"code_challenge,omitempty"`
+	CodeChallengeMethod string    `bson:"code_challenge_method,omitempty"`
+
+	// Resources are the resource servers (RFC 8707) the grant was authorized for. Access
+	// tokens minted from it may only be for one of them.
+	Resources []string `bson:"resources,omitempty"`
+
+	// Reference access tokens record the audience they were minted for and the refresh
+	// token family they were minted from, if any, so they can be revoked with it.
+	Audience string `bson:"audience,omitempty"`
+	GrantID  string `bson:"grant_id,omitempty"`
+	// Act records the delegation chain of a token obtained by token exchange.
+	Act *Actor `bson:"act,omitempty"`
+
+	// Refresh token family tracking. Every token obtained by rotation shares the
+	// FamilyID of the token issued with the original grant and points at its parent.
+	FamilyID  string        `bson:"family_id,omitempty"`
+	ParentID  bson.ObjectID `bson:"parent_id,omitempty"`
+	RotatedAt time.Time     `bson:"rotated_at,omitempty"` // Set once the token has been exchanged for a successor
+}
+
+// Actor identifies the party acting on behalf of a token's subject (RFC 8693 section 4.1).
+// A nested Act records the actor before it in a delegation chain.
+type Actor struct {
+	Subject  string `bson:"sub" json:"sub"`
+	ClientID string `bson:"client_id,omitempty" json:"client_id,omitempty"`
+	Act      *Actor `bson:"act,omitempty" json:"act,omitempty"`
 }
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// TrustedIssuer is an external party, such as a CI system or another identity provider,
+// whose signed assertions about its subjects clients can exchange for access tokens with
+// the JWT bearer grant (RFC 7523 section 2.1).
+type TrustedIssuer struct {
+	ID bson.ObjectID `bson:"_id,omitempty"`
+	// Issuer is the "iss" claim of the issuer's assertions.
+	Issuer string `bson:"issuer"`
+	Name   string `bson:"name"`
+	// JWKSURL or JWKS, an inline JSON Web Key Set, holds the keys that sign the issuer's assertions.
+	JWKSURL string `bson:"jwks_url,omitempty"`
+	JWKS    string `bson:"jwks,omitempty"`
+
+	// AllowedSubjects lists the "sub" values accepted from the issuer. An entry ending in
+	// "*" matches every subject with that prefix.
+	AllowedSubjects []string `bson:"allowed_subjects"`
+	// SubjectMappings maps accepted subjects to the local users access tokens are issued for.
+	// The first matching mapping applies, and unmapped subjects are rejected.
+	SubjectMappings []SubjectMapping `bson:"subject_mappings"`
+	// Scopes are the scopes that may be obtained with the issuer's assertions.
+	Scopes []string `bson:"scopes"`
+	// AllowedClients lists the client IDs that may present the issuer's assertions.
+	AllowedClients []string `bson:"allowed_clients"`
+
+	CreatedAt time.Time `bson:"created_at"`
+	UpdatedAt time.Time `bson:"updated_at"`
+}
+
+// SubjectMapping maps the subjects of a trusted issuer matching Subject, which is matched
+// like TrustedIssuer.AllowedSubjects, to a local user.
+type SubjectMapping struct {
+	Subject string `bson:"subject" json:"subject"`
+	UserID  string `bson:"user_id" json:"user_id"`
+}
@@ -23,13 +23,22 @@ type AppDependencies struct {
 	AuthService      *services.AuthService
 	SessionService   *services.SessionService
 	ClientService    *services.ClientService
+	ClientPolicy     *services.ClientPolicy
+	ClientAuth       *services.ClientAuthenticator
+	PARService       *services.PARService
+	RequestObjects   *services.RequestObjectService
+	AuthRequests     *services.AuthorizationRequestService
+	ConsentService   *services.ConsentService
 	ScopeService     *services.ScopeService
+	ResourceService  *services.ResourceService
 	TokenService     *services.TokenService
 	UserStore        storage.UserStore
 	UserService      *services.UserService
 	DashboardService *services.DashboardService
 	AuditService     *services.AuditService
 
+	TrustedIssuerService *services.TrustedIssuerService
+
 	BaseURL string
 	AppEnv  string
 
@@ -38,6 +47,7 @@ type AppDependencies struct {
 	JWKSHandler          *handlers.JWKSHandler
 	DiscoveryHandler     *handlers.DiscoveryHandler
 	UserInfoHandler      *handlers.UserInfoHandler
+	RegistrationHandler  *handlers.RegistrationHandler
 	AdminHandler         *handlers.AdminHandler
 	HealthHandler        *handlers.HealthHandler
 
@@ -71,7 +81,7 @@ func NewRouter(deps AppDependencies) http.Handler {
 	// --- Initialize Handlers and Middleware from Dependencies ---
 	authMiddleware := middleware.NewAuthMiddleware(deps.Logger, deps.SessionService, deps.UserStore)
 	frontendHandler := handlers.NewFrontendHandler(deps.Logger, deps.TemplateCache, deps.AuthService, deps.SessionService, deps.TokenService, deps.ClientService, deps.ScopeService, deps.AuditService)
-	authHandler := handlers.NewAuthHandler(deps.Logger, deps.TemplateCache, deps.ClientService, deps.ScopeService, deps.TokenService)
+	authHandler := handlers.NewAuthHandler(deps.Logger, deps.TemplateCache, deps.ClientService, deps.ClientPolicy, deps.ClientAuth, deps.PARService, deps.RequestObjects, deps.AuthRequests, deps.ConsentService, deps.ScopeService, deps.ResourceService, deps.TrustedIssuerService, deps.TokenService, deps.AuditService)
 
 	// == Route Definitions ==
 
@@ -83,10 +93,14 @@ func NewRouter(deps AppDependencies) http.Handler {
 	mux.HandleFunc("GET /login", frontendHandler.LoginPage)
 	mux.HandleFunc("POST /login", frontendHandler.Login)
 	mux.HandleFunc("POST /logout", frontendHandler.Logout)
+	mux.HandleFunc("GET /oauth2/logout", frontendHandler.EndSession)
+	mux.HandleFunc("POST /oauth2/logout", frontendHandler.EndSession)
 
 	// --- Protected User-Facing Routes (Login Required) ---
 	mux.Handle("/device", authMiddleware.RequireAuth(http.HandlerFunc(frontendHandler.DeviceFlow)))
-	mux.Handle("/oauth2/authorize", authMiddleware.RequireAuth(http.HandlerFunc(authHandler.AuthorizeFlow)))
+	// The authorization endpoint decides itself when to send the user to the login page,
+	// so that prompt, max_age and the login hints can be honoured.
+	mux.Handle("/oauth2/authorize", authMiddleware.LoadSession(http.HandlerFunc(authHandler.AuthorizeFlow)))
 
 	deviceConsentHandler := http.HandlerFunc(authHandler.DeviceConsentFlow)
 	mux.Handle("GET /oauth2/authorize/device", authMiddleware.RequireAuth(deviceConsentHandler))
@@ -120,6 +134,26 @@ func NewRouter(deps AppDependencies) http.Handler {
 	adminAPI.HandleFunc("PUT /users/{userID}", deps.AdminHandler.UpdateUser)
 	adminAPI.HandleFunc("DELETE /users/{userID}", deps.AdminHandler.DeleteUser)
 
+	adminAPI.HandleFunc("GET /keys", deps.AdminHandler.ListKeys)
+	adminAPI.HandleFunc("POST /keys/rotate", deps.AdminHandler.RotateKeys)
+	adminAPI.HandleFunc("POST /keys/{kid}/revoke", deps.AdminHandler.RevokeKey)
+
+	adminAPI.HandleFunc("GET /scopes", deps.AdminHandler.ListScopes)
+	adminAPI.HandleFunc("POST /scopes", deps.AdminHandler.CreateScope)
+	adminAPI.HandleFunc("GET /scopes/{name}", deps.AdminHandler.GetScope)
+	adminAPI.HandleFunc("PUT /scopes/{name}", deps.AdminHandler.UpdateScope)
+	adminAPI.HandleFunc("DELETE /scopes/{name}", deps.AdminHandler.DeleteScope)
+	adminAPI.HandleFunc("GET /resources", deps.AdminHandler.ListResourceServers)
+	adminAPI.HandleFunc("POST /resources", deps.AdminHandler.CreateResourceServer)
+	adminAPI.HandleFunc("GET /resources/{id}", deps.AdminHandler.GetResourceServer)
+	adminAPI.HandleFunc("PUT /resources/{id}", deps.AdminHandler.UpdateResourceServer)
+	adminAPI.HandleFunc("DELETE /resources/{id}", deps.AdminHandler.DeleteResourceServer)
+	adminAPI.HandleFunc("GET /trusted-issuers", deps.AdminHandler.ListTrustedIssuers)
+	adminAPI.HandleFunc("POST /trusted-issuers", deps.AdminHandler.CreateTrustedIssuer)
+	adminAPI.HandleFunc("GET /trusted-issuers/{id}", deps.AdminHandler.GetTrustedIssuer)
+	adminAPI.HandleFunc("PUT /trusted-issuers/{id}", deps.AdminHandler.UpdateTrustedIssuer)
+	adminAPI.HandleFunc("DELETE /trusted-issuers/{id}", deps.AdminHandler.DeleteTrustedIssuer)
+
 	protectedAdminAPI := authMiddleware.RequireAuth(authMiddleware.RequireAdmin(adminAPI))
 	mux.Handle("/api/admin/", http.StripPrefix("/api/admin", protectedAdminAPI))
 
@@ -129,12 +163,25 @@ func NewRouter(deps AppDependencies) http.Handler {
 	mux.HandleFunc("POST /oauth2/revoke", deps.RevocationHandler.Revoke)
 	mux.HandleFunc("GET /.well-known/jwks.json", deps.JWKSHandler.ServeJWKS)
 	mux.HandleFunc("GET /.well-known/oauth-authorization-server", deps.DiscoveryHandler.ServeDiscoveryDocument)
+	mux.HandleFunc("GET /.well-known/openid-configuration", deps.DiscoveryHandler.ServeOpenIDConfiguration)
 	mux.HandleFunc("/oauth2/userinfo", deps.UserInfoHandler.GetUserInfo)
 
+	// --- Dynamic Client Registration (RFC 7591/7592) ---
+	if deps.RegistrationHandler.Enabled() {
+		mux.HandleFunc("POST /oauth2/register", deps.RegistrationHandler.Register)
+		mux.HandleFunc("GET /oauth2/register/{clientID}", deps.RegistrationHandler.GetRegistration)
+		mux.HandleFunc("PUT /oauth2/register/{clientID}", deps.RegistrationHandler.UpdateRegistration)
+		mux.HandleFunc("DELETE /oauth2/register/{clientID}", deps.RegistrationHandler.DeleteRegistration)
+	}
+
 	// The /token endpoint has its own specific rate limiter.
 	tokenHandler := deps.RateLimiter.PerClient(http.HandlerFunc(authHandler.Token))
 	mux.Handle("POST /oauth2/token", tokenHandler)
 
+	// The PAR endpoint authenticates clients like /token and shares its rate limit.
+	parHandler := deps.RateLimiter.PerClient(http.HandlerFunc(authHandler.PushAuthorizationRequest))
+	mux.Handle("POST /oauth2/par", parHandler)
+
 	// --- Public Metrics Endpoint ---
 	mux.Handle("GET /metrics", promhttp.Handler())
 
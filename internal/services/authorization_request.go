@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aminshahid573/authexa/internal/models"
+	"github.com/aminshahid573/authexa/internal/storage"
+	"github.com/aminshahid573/authexa/internal/utils"
+)
+
+// authorizationRequestLifetime is how long the user has to log in or answer the consent page.
+const authorizationRequestLifetime = 10 * time.Minute
+
+// ErrInvalidAuthorizationRequest is returned when a consent decision references an authorization
+// request that is unknown, expired, already answered or was started in another session.
+var ErrInvalidAuthorizationRequest = &utils.AppError{Code: "invalid_request", Message: "The authorization request is invalid or has expired. Please start again from the application.", HTTPStatus: http.StatusBadRequest}
+
+// AuthorizationRequestService keeps validated authorization requests server-side between
+// the consent page and the user's decision.
+type AuthorizationRequestService struct {
+	store storage.AuthorizationRequestStore
+}
+
+// NewAuthorizationRequestService creates a new AuthorizationRequestService.
+func NewAuthorizationRequestService(store storage.AuthorizationRequestStore) *AuthorizationRequestService {
+	return &AuthorizationRequestService{store: store}
+}
+
+// New builds an authorization request from validated request parameters. It is not
+// stored until Save is called.
+func (s *AuthorizationRequestService) New(clientID string, params url.Values) *models.AuthorizationRequest {
+	req := &models.AuthorizationRequest{
+		ClientID:            clientID,
+		RedirectURI:         params.Get("redirect_uri"),
+		Scopes:              strings.Fields(params.Get("scope")),
+		State:               params.Get("state"),
+		Nonce:               params.Get("nonce"),
+		CodeChallenge:       params.Get("code_challenge"),
+		CodeChallengeMethod: params.Get("code_challenge_method"),
+		ResponseMode:        params.Get("response_mode"),
+		Prompt:              strings.Fields(params.Get("prompt")),
+		LoginHint:           params.Get("login_hint"),
+		CreatedAt:           time.Now(),
+
+		IncludeGrantedScopes: params.Get("include_granted_scopes") == "true",
+		Resources:            params["resource"],
+	}
+	if maxAge, err := strconv.Atoi(params.Get("max_age")); err == nil {
+		req.MaxAge = &maxAge
+	}
+	return req
+}
+
+// Save stores an authorization request under a new ID, bound to the session that will
+// answer it, or to no session while the user is sent to log in. A new ID is issued on every
+// save, so the ID handed out before login cannot be used once the request is bound.
+func (s *AuthorizationRequestService) Save(ctx context.Context, req *models.AuthorizationRequest, sessionID string) error {
+	id, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return fmt.Errorf("failed to generate authorization request id: %w", err)
+	}
+
+	req.ID = id
+	req.SessionID = sessionID
+	req.ExpiresAt = time.Now().Add(authorizationRequestLifetime)
+	return s.store.Save(ctx, req)
+}
+
+// Consume returns a stored authorization request and invalidates it. The request must
+// belong to the given session.
+func (s *AuthorizationRequestService) Consume(ctx context.Context, id, sessionID string) (*models.AuthorizationRequest, error) {
+	if id == "" {
+		return nil, ErrInvalidAuthorizationRequest
+	}
+
+	req, err := s.store.Consume(ctx, id)
+	if err != nil {
+		if errors.Is(err, utils.ErrNotFound) {
+			return nil, ErrInvalidAuthorizationRequest
+		}
+		return nil, err
+	}
+	if req.SessionID != sessionID || time.Now().After(req.ExpiresAt) {
+		return nil, ErrInvalidAuthorizationRequest
+	}
+	return req, nil
+}
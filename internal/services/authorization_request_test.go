@@ -0,0 +1,151 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"slices"
+	"testing"
+
+	"github.com/aminshahid573/authexa/internal/models"
+	"github.com/aminshahid573/authexa/internal/utils"
+)
+
+// MockAuthorizationRequestStore is an in-memory implementation of the storage.AuthorizationRequestStore interface.
+type MockAuthorizationRequestStore struct {
+	Requests map[string]models.AuthorizationRequest
+}
+
+func NewMockAuthorizationRequestStore() *MockAuthorizationRequestStore {
+	return &MockAuthorizationRequestStore{Requests: make(map[string]models.AuthorizationRequest)}
+}
+
+func (m *MockAuthorizationRequestStore) Save(ctx context.Context, req *models.AuthorizationRequest) error {
+	m.Requests[req.ID] = *req
+	return nil
+}
+
+func (m *MockAuthorizationRequestStore) Consume(ctx context.Context, id string) (*models.AuthorizationRequest, error) {
+	req, ok := m.Requests[id]
+	if !ok {
+		return nil, utils.ErrNotFound
+	}
+	delete(m.Requests, id)
+	return &req, nil
+}
+
+// TestAuthorizationRequestService_Consume tests that a stored authorization request can
+// only be answered once, from the session it is bound to.
+func TestAuthorizationRequestService_Consume(t *testing.T) {
+	ctx := context.Background()
+	params := url.Values{
+		"redirect_uri": {"https://client.example.com/callback"},
+		"scope":        {"openid profile"},
+		"state":        {"xyz"},
+	}
+
+	tests := []struct {
+		name      string
+		sessionID string
+		// id overrides the ID of the stored request. reused consumes the request twice.
+		id      func(saved string) string
+		reused  bool
+		wantErr bool
+	}{
+		{name: "Same Session", sessionID: "session-1"},
+		{name: "Other Session", sessionID: "session-2", wantErr: true},
+		{name: "Answered Twice", sessionID: "session-1", reused: true, wantErr: true},
+		{name: "Missing ID", sessionID: "session-1", id: func(string) string { return "" }, wantErr: true},
+		{name: "Unknown ID", sessionID: "session-1", id: func(string) string { return "unknown" }, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewAuthorizationRequestService(NewMockAuthorizationRequestStore())
+			req := service.New("client-1", params)
+			if err := service.Save(ctx, req, "session-1"); err != nil {
+				t.Fatalf("expected the request to be saved, but got: %v", err)
+			}
+			id := req.ID
+			if tt.id != nil {
+				id = tt.id(id)
+			}
+			if tt.reused {
+				if _, err := service.Consume(ctx, id, tt.sessionID); err != nil {
+					t.Fatalf("expected the first answer to succeed, but got: %v", err)
+				}
+			}
+
+			got, err := service.Consume(ctx, id, tt.sessionID)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidAuthorizationRequest) {
+					t.Errorf("expected ErrInvalidAuthorizationRequest, but got: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, but got: %v", err)
+			}
+			if got.ClientID != "client-1" || got.RedirectURI != "https://client.example.com/callback" || got.State != "xyz" || !slices.Equal(got.Scopes, []string{"openid", "profile"}) {
+				t.Errorf("expected the stored request, but got: %+v", got)
+			}
+		})
+	}
+}
+
+// TestAuthorizationRequestService_SaveAfterLogin tests that the ID handed out while the
+// user logs in stops working once the request is bound to the new session.
+func TestAuthorizationRequestService_SaveAfterLogin(t *testing.T) {
+	ctx := context.Background()
+	service := NewAuthorizationRequestService(NewMockAuthorizationRequestStore())
+	req := service.New("client-1", url.Values{"scope": {"openid"}})
+
+	if err := service.Save(ctx, req, ""); err != nil {
+		t.Fatalf("expected the request to be saved, but got: %v", err)
+	}
+	loginID := req.ID
+	if err := service.Save(ctx, req, "session-1"); err != nil {
+		t.Fatalf("expected the request to be saved, but got: %v", err)
+	}
+	if req.ID == loginID {
+		t.Fatal("expected a new ID once the request is bound to a session")
+	}
+
+	if _, err := service.Consume(ctx, loginID, "session-1"); !errors.Is(err, ErrInvalidAuthorizationRequest) {
+		t.Errorf("expected the ID from before login to be rejected, but got: %v", err)
+	}
+	if _, err := service.Consume(ctx, req.ID, "session-1"); err != nil {
+		t.Errorf("expected the bound request to be answered, but got: %v", err)
+	}
+}
+
+// TestAuthorizationRequestService_New tests parsing the OIDC authentication parameters
+// of an authorization request.
+func TestAuthorizationRequestService_New(t *testing.T) {
+	service := NewAuthorizationRequestService(NewMockAuthorizationRequestStore())
+	seconds := func(n int) *int { return &n }
+
+	tests := []struct {
+		name       string
+		params     url.Values
+		wantPrompt []string
+		wantMaxAge *int
+	}{
+		{name: "No Parameters", params: url.Values{}},
+		{name: "Prompt And Max Age", params: url.Values{"prompt": {"login consent"}, "max_age": {"300"}}, wantPrompt: []string{models.PromptLogin, models.PromptConsent}, wantMaxAge: seconds(300)},
+		{name: "Zero Max Age", params: url.Values{"max_age": {"0"}}, wantMaxAge: seconds(0)},
+		{name: "Invalid Max Age", params: url.Values{"max_age": {"soon"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := service.New("client-1", tt.params)
+			if !slices.Equal(req.Prompt, tt.wantPrompt) {
+				t.Errorf("expected prompt %v, but got: %v", tt.wantPrompt, req.Prompt)
+			}
+			if (req.MaxAge == nil) != (tt.wantMaxAge == nil) || (req.MaxAge != nil && *req.MaxAge != *tt.wantMaxAge) {
+				t.Errorf("expected max_age %v, but got: %v", tt.wantMaxAge, req.MaxAge)
+			}
+		})
+	}
+}
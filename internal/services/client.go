@@ -2,43 +2,105 @@ package services
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"slices"
 
 	"github.com/aminshahid573/authexa/internal/models"
 	"github.com/aminshahid573/authexa/internal/storage"
 	"github.com/aminshahid573/authexa/internal/utils"
+	"github.com/lestrrat-go/jwx/v2/jwk"
 )
 
 // ClientService provides business logic for OAuth2 clients.
 type ClientService struct {
 	clientStore storage.ClientStore
 	baseURL     string
+	// secretKey encrypts the secrets of client_secret_jwt clients at rest.
+	secretKey string
 }
 
 // CreateClientRequest defines the payload for creating a new client.
 type CreateClientRequest struct {
 	Name          string   `json:"name" validate:"required"`
 	RedirectURIs  []string `json:"redirect_uris" validate:"required,dive,url"`
-	GrantTypes    []string `json:"grant_types" validate:"required,dive,oneof=authorization_code client_credentials refresh_token urn:ietf:params:oauth:grant-type:device_code urn:ietf:params:oauth:grant-type:jwt-bearer"`
+	GrantTypes    []string `json:"grant_types" validate:"required,dive,oneof=authorization_code client_credentials refresh_token urn:ietf:params:oauth:grant-type:device_code urn:ietf:params:oauth:grant-type:jwt-bearer urn:ietf:params:oauth:grant-type:token-exchange"`
 	ResponseTypes []string `json:"response_types" validate:"required"`
 	Scopes        []string `json:"scopes" validate:"required"`
 	JWKSURL       string   `json:"jwks_url" validate:"omitempty,url"`
+	// PostLogoutRedirectURIs lists where RP-initiated logout may send the user afterwards.
+	PostLogoutRedirectURIs []string `json:"post_logout_redirect_uris" validate:"omitempty,dive,url"`
+	// JWKS is an inline JSON Web Key Set, for private_key_jwt clients without a JWKS URL.
+	JWKS json.RawMessage `json:"jwks,omitempty"`
+	// RequestURIs lists the URLs the client may pass as a request_uri for its request objects.
+	RequestURIs []string `json:"request_uris" validate:"omitempty,dive,url"`
+	// RequiredScopes lists the scopes the user cannot deselect on the consent page.
+	RequiredScopes []string `json:"required_scopes"`
+
+	// TokenEndpointAuthMethod cannot be changed after creation, as switching
+	// between public and confidential would require issuing or discarding a secret.
+	TokenEndpointAuthMethod string `json:"token_endpoint_auth_method" validate:"omitempty,oneof=client_secret_basic client_secret_post none private_key_jwt client_secret_jwt"`
+
+	RefreshTokenRotationDisabled       bool   `json:"refresh_token_rotation_disabled"`
+	RequirePushedAuthorizationRequests bool   `json:"require_pushed_authorization_requests"`
+	IDTokenSignedResponseAlg           string `json:"id_token_signed_response_alg" validate:"omitempty,oneof=RS256 PS256 ES256 EdDSA"`
+	AccessTokenSigningAlg              string `json:"access_token_signing_alg" validate:"omitempty,oneof=RS256 PS256 ES256 EdDSA"`
+	AccessTokenFormat                  string `json:"access_token_format" validate:"omitempty,oneof=jwt reference"`
+	RequestObjectSigningAlg            string `json:"request_object_signing_alg" validate:"omitempty,oneof=RS256 PS256 ES256 EdDSA"`
+	RequireSignedRequestObject         bool   `json:"require_signed_request_object"`
+	AuthorizationSignedResponseAlg     string `json:"authorization_signed_response_alg" validate:"omitempty,oneof=RS256 PS256 ES256 EdDSA"`
+	DefaultResponseMode                string `json:"default_response_mode" validate:"omitempty,oneof=query fragment form_post query.jwt fragment.jwt form_post.jwt"`
+	Trusted                            bool   `json:"trusted"`
+
+	// TokenExchangeAudiences and TokenExchangeScopes are the client's token exchange policy.
+	TokenExchangeAudiences []string `json:"token_exchange_audiences"`
+	TokenExchangeScopes    []string `json:"token_exchange_scopes"`
+
+	// RegistrationAccessToken is the hashed RFC 7592 management token of a dynamically
+	// registered client. It is never read from the request body.
+	RegistrationAccessToken string `json:"-"`
 }
 
 type UpdateClientRequest struct {
 	Name          string   `json:"name" validate:"required"`
 	RedirectURIs  []string `json:"redirect_uris" validate:"required,dive,url"`
-	GrantTypes    []string `json:"grant_types" validate:"required,dive,oneof=authorization_code client_credentials refresh_token urn:ietf:params:oauth:grant-type:device_code urn:ietf:params:oauth:grant-type:jwt-bearer"`
+	GrantTypes    []string `json:"grant_types" validate:"required,dive,oneof=authorization_code client_credentials refresh_token urn:ietf:params:oauth:grant-type:device_code urn:ietf:params:oauth:grant-type:jwt-bearer urn:ietf:params:oauth:grant-type:token-exchange"`
 	ResponseTypes []string `json:"response_types" validate:"required"`
 	Scopes        []string `json:"scopes" validate:"required"`
 	JWKSURL       string   `json:"jwks_url" validate:"omitempty,url"`
+	// PostLogoutRedirectURIs lists where RP-initiated logout may send the user afterwards.
+	PostLogoutRedirectURIs []string `json:"post_logout_redirect_uris" validate:"omitempty,dive,url"`
+	// JWKS is an inline JSON Web Key Set, for private_key_jwt clients without a JWKS URL.
+	JWKS json.RawMessage `json:"jwks,omitempty"`
+	// RequestURIs lists the URLs the client may pass as a request_uri for its request objects.
+	RequestURIs []string `json:"request_uris" validate:"omitempty,dive,url"`
+	// RequiredScopes lists the scopes the user cannot deselect on the consent page.
+	RequiredScopes []string `json:"required_scopes"`
+
+	RefreshTokenRotationDisabled       bool   `json:"refresh_token_rotation_disabled"`
+	RequirePushedAuthorizationRequests bool   `json:"require_pushed_authorization_requests"`
+	IDTokenSignedResponseAlg           string `json:"id_token_signed_response_alg" validate:"omitempty,oneof=RS256 PS256 ES256 EdDSA"`
+	AccessTokenSigningAlg              string `json:"access_token_signing_alg" validate:"omitempty,oneof=RS256 PS256 ES256 EdDSA"`
+	AccessTokenFormat                  string `json:"access_token_format" validate:"omitempty,oneof=jwt reference"`
+	RequestObjectSigningAlg            string `json:"request_object_signing_alg" validate:"omitempty,oneof=RS256 PS256 ES256 EdDSA"`
+	RequireSignedRequestObject         bool   `json:"require_signed_request_object"`
+	AuthorizationSignedResponseAlg     string `json:"authorization_signed_response_alg" validate:"omitempty,oneof=RS256 PS256 ES256 EdDSA"`
+	DefaultResponseMode                string `json:"default_response_mode" validate:"omitempty,oneof=query fragment form_post query.jwt fragment.jwt form_post.jwt"`
+	Trusted                            bool   `json:"trusted"`
+
+	// TokenExchangeAudiences and TokenExchangeScopes are the client's token exchange policy.
+	TokenExchangeAudiences []string `json:"token_exchange_audiences"`
+	TokenExchangeScopes    []string `json:"token_exchange_scopes"`
 }
 
-// NewClientService creates a new ClientService.
-func NewClientService(clientStore storage.ClientStore, baseURL string) *ClientService {
+// NewClientService creates a new ClientService. Secrets of client_secret_jwt
+// clients are encrypted at rest with secretKey.
+func NewClientService(clientStore storage.ClientStore, baseURL, secretKey string) *ClientService {
 	return &ClientService{
 		clientStore: clientStore,
 		baseURL:     baseURL,
+		secretKey:   secretKey,
 	}
 }
 
@@ -46,22 +108,23 @@ func (s *ClientService) GetBaseURL() string {
 	return s.baseURL
 }
 
-// ValidateClientCredentials checks if the provided client ID and secret are valid.
-// It fetches the client and compares the hashed secret.
-func (s *ClientService) ValidateClientCredentials(ctx context.Context, clientID, clientSecret string) (*models.Client, error) {
-	client, err := s.clientStore.GetByClientID(ctx, clientID)
-	if err != nil {
-		// If the client is not found, or any other error occurs, return an invalid client error.
-		// This prevents leaking information about which client IDs exist.
-		return nil, utils.ErrInvalidClient
-	}
+// CheckSecret reports whether secret is the client's secret. The authentication method
+// is checked separately by ClientPolicy.
+func (s *ClientService) CheckSecret(client *models.Client, secret string) bool {
+	return client.ClientSecret != "" && utils.CheckPasswordHash(secret, client.ClientSecret)
+}
 
-	// Compare the provided secret with the stored hash.
-	if !utils.CheckPasswordHash(clientSecret, client.ClientSecret) {
-		return nil, utils.ErrInvalidClient
+// SecretForHMAC returns the plaintext secret of a client_secret_jwt client, the key its
+// client assertions are signed with.
+func (s *ClientService) SecretForHMAC(client *models.Client) (string, error) {
+	if client.ClientSecretEncrypted == "" {
+		return "", utils.ErrInvalidClient
 	}
-
-	return client, nil
+	secret, err := utils.DecryptSecret(s.secretKey, client.ClientSecretEncrypted)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt client secret: %w", err)
+	}
+	return secret, nil
 }
 
 // GetClient retrieves a client by its ID.
@@ -75,20 +138,43 @@ func (s *ClientService) GetClient(ctx context.Context, clientID string) (*models
 
 // CreateClient handles the business logic for creating a new client.
 // It returns the client with the plaintext secret for one-time display.
+// Public and private_key_jwt clients are not issued a secret, so the returned secret is empty.
 func (s *ClientService) CreateClient(ctx context.Context, req CreateClientRequest) (*models.Client, string, error) {
-	clientID, err := utils.GenerateSecureToken(16)
+	public := req.TokenEndpointAuthMethod == models.ClientAuthMethodNone
+	if err := validatePublicClientGrants(public, req.GrantTypes); err != nil {
+		return nil, "", err
+	}
+	if err := validateRequiredScopes(req.Scopes, req.RequiredScopes); err != nil {
+		return nil, "", err
+	}
+	jwks, err := validateClientKeys(req.TokenEndpointAuthMethod, req.JWKSURL, req.JWKS)
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to generate client_id: %w", err)
+		return nil, "", err
 	}
 
-	clientSecret, err := utils.GenerateSecureToken(32)
+	clientID, err := utils.GenerateSecureToken(16)
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to generate client_secret: %w", err)
+		return nil, "", fmt.Errorf("failed to generate client_id: %w", err)
 	}
 
-	hashedSecret, err := utils.HashPassword(clientSecret)
-	if err != nil {
-		return nil, "", fmt.Errorf("failed to hash client_secret: %w", err)
+	var clientSecret, hashedSecret, encryptedSecret string
+	if !public && req.TokenEndpointAuthMethod != models.ClientAuthMethodPrivateKeyJWT {
+		clientSecret, err = utils.GenerateSecureToken(32)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to generate client_secret: %w", err)
+		}
+
+		hashedSecret, err = utils.HashPassword(clientSecret)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to hash client_secret: %w", err)
+		}
+
+		if req.TokenEndpointAuthMethod == models.ClientAuthMethodSecretJWT {
+			encryptedSecret, err = utils.EncryptSecret(s.secretKey, clientSecret)
+			if err != nil {
+				return nil, "", fmt.Errorf("failed to encrypt client_secret: %w", err)
+			}
+		}
 	}
 
 	client := &models.Client{
@@ -100,6 +186,28 @@ func (s *ClientService) CreateClient(ctx context.Context, req CreateClientReques
 		ResponseTypes: req.ResponseTypes,
 		Scopes:        req.Scopes,
 		JWKSURL:       req.JWKSURL,
+		Trusted:       req.Trusted,
+
+		TokenEndpointAuthMethod:      req.TokenEndpointAuthMethod,
+		PostLogoutRedirectURIs:       req.PostLogoutRedirectURIs,
+		JWKS:                         jwks,
+		ClientSecretEncrypted:        encryptedSecret,
+		RegistrationAccessToken:      req.RegistrationAccessToken,
+		RefreshTokenRotationDisabled: req.RefreshTokenRotationDisabled,
+		IDTokenSignedResponseAlg:     req.IDTokenSignedResponseAlg,
+		AccessTokenSigningAlg:        req.AccessTokenSigningAlg,
+		AccessTokenFormat:            req.AccessTokenFormat,
+		RequestObjectSigningAlg:      req.RequestObjectSigningAlg,
+		RequestURIs:                  req.RequestURIs,
+		RequiredScopes:               req.RequiredScopes,
+		RequireSignedRequestObject:   req.RequireSignedRequestObject,
+		DefaultResponseMode:          req.DefaultResponseMode,
+
+		RequirePushedAuthorizationRequests: req.RequirePushedAuthorizationRequests,
+		AuthorizationSignedResponseAlg:     req.AuthorizationSignedResponseAlg,
+
+		TokenExchangeAudiences: req.TokenExchangeAudiences,
+		TokenExchangeScopes:    req.TokenExchangeScopes,
 	}
 
 	if err := s.clientStore.Create(ctx, client); err != nil {
@@ -132,13 +240,40 @@ func (s *ClientService) UpdateClient(ctx context.Context, clientID string, req U
 		return nil, err // Will be ErrNotFound if it doesn't exist
 	}
 
+	if err := validatePublicClientGrants(existingClient.IsPublic(), req.GrantTypes); err != nil {
+		return nil, err
+	}
+	if err := validateRequiredScopes(req.Scopes, req.RequiredScopes); err != nil {
+		return nil, err
+	}
+	jwks, err := validateClientKeys(existingClient.TokenEndpointAuthMethod, req.JWKSURL, req.JWKS)
+	if err != nil {
+		return nil, err
+	}
+
 	// Update the fields from the request.
 	existingClient.Name = req.Name
 	existingClient.RedirectURIs = req.RedirectURIs
+	existingClient.PostLogoutRedirectURIs = req.PostLogoutRedirectURIs
 	existingClient.GrantTypes = req.GrantTypes
 	existingClient.ResponseTypes = req.ResponseTypes
 	existingClient.Scopes = req.Scopes
 	existingClient.JWKSURL = req.JWKSURL
+	existingClient.JWKS = jwks
+	existingClient.RefreshTokenRotationDisabled = req.RefreshTokenRotationDisabled
+	existingClient.RequirePushedAuthorizationRequests = req.RequirePushedAuthorizationRequests
+	existingClient.IDTokenSignedResponseAlg = req.IDTokenSignedResponseAlg
+	existingClient.AccessTokenSigningAlg = req.AccessTokenSigningAlg
+	existingClient.AccessTokenFormat = req.AccessTokenFormat
+	existingClient.RequestObjectSigningAlg = req.RequestObjectSigningAlg
+	existingClient.RequestURIs = req.RequestURIs
+	existingClient.RequireSignedRequestObject = req.RequireSignedRequestObject
+	existingClient.AuthorizationSignedResponseAlg = req.AuthorizationSignedResponseAlg
+	existingClient.DefaultResponseMode = req.DefaultResponseMode
+	existingClient.Trusted = req.Trusted
+	existingClient.RequiredScopes = req.RequiredScopes
+	existingClient.TokenExchangeAudiences = req.TokenExchangeAudiences
+	existingClient.TokenExchangeScopes = req.TokenExchangeScopes
 
 	// Persist the changes.
 	if err := s.clientStore.Update(ctx, existingClient); err != nil {
@@ -147,3 +282,45 @@ func (s *ClientService) UpdateClient(ctx context.Context, clientID string, req U
 
 	return existingClient, nil
 }
+
+// validateRequiredScopes rejects required scopes the client is not allowed to request.
+func validateRequiredScopes(scopes, requiredScopes []string) error {
+	for _, scope := range requiredScopes {
+		if !slices.Contains(scopes, scope) {
+			return &utils.AppError{Code: "VALIDATION_ERROR", Message: "The required scope " + scope + " is not one of the client's scopes.", HTTPStatus: http.StatusBadRequest}
+		}
+	}
+	return nil
+}
+
+// validatePublicClientGrants rejects grant types that require client authentication for public clients.
+func validatePublicClientGrants(public bool, grantTypes []string) error {
+	if !public {
+		return nil
+	}
+	for _, gt := range grantTypes {
+		if !publicClientGrantType(gt) {
+			return &utils.AppError{Code: "VALIDATION_ERROR", Message: "Public clients cannot use the " + gt + " grant type.", HTTPStatus: http.StatusBadRequest}
+		}
+	}
+	return nil
+}
+
+// validateClientKeys checks the inline JWKS, if any, and requires private_key_jwt clients
+// to register their keys. It returns the JWKS in the form it is stored.
+func validateClientKeys(authMethod, jwksURL string, jwks json.RawMessage) (string, error) {
+	if len(jwks) > 0 && string(jwks) != "null" {
+		if jwksURL != "" {
+			return "", &utils.AppError{Code: "VALIDATION_ERROR", Message: "Only one of jwks and jwks_url may be set.", HTTPStatus: http.StatusBadRequest}
+		}
+		if _, err := jwk.Parse(jwks); err != nil {
+			return "", &utils.AppError{Code: "VALIDATION_ERROR", Message: "The jwks is not a valid JSON Web Key Set.", HTTPStatus: http.StatusBadRequest, Err: err}
+		}
+		return string(jwks), nil
+	}
+
+	if authMethod == models.ClientAuthMethodPrivateKeyJWT && jwksURL == "" {
+		return "", &utils.AppError{Code: "VALIDATION_ERROR", Message: "Clients using private_key_jwt must register a jwks or jwks_url.", HTTPStatus: http.StatusBadRequest}
+	}
+	return "", nil
+}
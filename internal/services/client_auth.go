@@ -0,0 +1,187 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/aminshahid573/authexa/internal/models"
+	"github.com/aminshahid573/authexa/internal/storage"
+	"github.com/aminshahid573/authexa/internal/utils"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// assertionLeeway is the clock skew tolerated when validating client assertions.
+const assertionLeeway = 30 * time.Second
+
+// hmacAssertionAlgorithms lists the algorithms accepted for client_secret_jwt assertions.
+var hmacAssertionAlgorithms = []string{
+	jwt.SigningMethodHS256.Alg(),
+	jwt.SigningMethodHS384.Alg(),
+	jwt.SigningMethodHS512.Alg(),
+}
+
+// ClientAssertionSigningAlgorithms lists every algorithm accepted for client assertions.
+var ClientAssertionSigningAlgorithms = append(slices.Clone(utils.SupportedSigningAlgorithms), hmacAssertionAlgorithms...)
+
+// ClientCredentials holds the credentials a client presented with a request.
+type ClientCredentials struct {
+	ClientID     string
+	ClientSecret string
+	// Method is the secret-based method used (client_secret_basic, client_secret_post or none).
+	// It is ignored when an assertion is present.
+	Method        string
+	Assertion     string
+	AssertionType string
+}
+
+// ClientAuthenticator authenticates clients at the token, introspection and revocation
+// endpoints, using either a client secret or an RFC 7523 client assertion.
+type ClientAuthenticator struct {
+	clientService *ClientService
+	policy        *ClientPolicy
+	jwksResolver  *JWKSResolver
+	replayCache   storage.ReplayCache
+	issuer        string
+}
+
+// NewClientAuthenticator creates a new ClientAuthenticator.
+func NewClientAuthenticator(clientService *ClientService, policy *ClientPolicy, jwksResolver *JWKSResolver, replayCache storage.ReplayCache, issuer string) *ClientAuthenticator {
+	return &ClientAuthenticator{
+		clientService: clientService,
+		policy:        policy,
+		jwksResolver:  jwksResolver,
+		replayCache:   replayCache,
+		issuer:        issuer,
+	}
+}
+
+// Authenticate verifies the presented credentials and returns the authenticated client.
+// endpoint is the URL the request was sent to, which client assertions may use as audience.
+func (a *ClientAuthenticator) Authenticate(ctx context.Context, creds ClientCredentials, endpoint string) (*models.Client, error) {
+	if creds.Assertion == "" && creds.AssertionType == "" {
+		return a.authenticateSecret(ctx, creds)
+	}
+	if creds.AssertionType != models.ClientAssertionTypeJWTBearer || creds.Assertion == "" {
+		return nil, fmt.Errorf("%w: unsupported client_assertion_type", utils.ErrInvalidClient)
+	}
+	return a.authenticateAssertion(ctx, creds, endpoint)
+}
+
+// authenticateSecret authenticates a client with its client secret, or by its client_id
+// alone for public clients. The method used must be the one the client registered.
+func (a *ClientAuthenticator) authenticateSecret(ctx context.Context, creds ClientCredentials) (*models.Client, error) {
+	client, err := a.clientService.GetClientByID(ctx, creds.ClientID)
+	if err != nil {
+		return nil, utils.ErrInvalidClient
+	}
+	if err := a.policy.CheckAuthMethod(client, creds.Method); err != nil {
+		return nil, err
+	}
+	if creds.Method == models.ClientAuthMethodNone {
+		return client, nil
+	}
+	if !a.clientService.CheckSecret(client, creds.ClientSecret) {
+		return nil, utils.ErrInvalidClient
+	}
+	return client, nil
+}
+
+// authenticateAssertion validates a private_key_jwt or client_secret_jwt assertion
+// as described in RFC 7523 section 3.
+func (a *ClientAuthenticator) authenticateAssertion(ctx context.Context, creds ClientCredentials, endpoint string) (*models.Client, error) {
+	// The client is identified by the unverified "sub" claim, then the signature is checked with its keys.
+	unverified, _, err := jwt.NewParser().ParseUnverified(creds.Assertion, &jwt.RegisteredClaims{})
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed client assertion", utils.ErrInvalidClient)
+	}
+	clientID, _ := unverified.Claims.GetSubject()
+	if clientID == "" || (creds.ClientID != "" && creds.ClientID != clientID) {
+		return nil, fmt.Errorf("%w: client assertion subject does not match client_id", utils.ErrInvalidClient)
+	}
+
+	client, err := a.clientService.GetClientByID(ctx, clientID)
+	if err != nil {
+		return nil, utils.ErrInvalidClient
+	}
+
+	// An HMAC-signed assertion is client_secret_jwt, any other private_key_jwt.
+	method := models.ClientAuthMethodPrivateKeyJWT
+	if slices.Contains(hmacAssertionAlgorithms, unverified.Method.Alg()) {
+		method = models.ClientAuthMethodSecretJWT
+	}
+	if err := a.policy.CheckAuthMethod(client, method); err != nil {
+		return nil, err
+	}
+
+	var keyFunc jwt.Keyfunc
+	var methods []string
+	switch method {
+	case models.ClientAuthMethodPrivateKeyJWT:
+		methods = utils.SupportedSigningAlgorithms
+		keyFunc = func(t *jwt.Token) (interface{}, error) {
+			kid, _ := t.Header["kid"].(string)
+			return a.jwksResolver.LookupKey(ctx, client, kid)
+		}
+	case models.ClientAuthMethodSecretJWT:
+		secret, err := a.clientService.SecretForHMAC(client)
+		if err != nil {
+			return nil, err
+		}
+		methods = hmacAssertionAlgorithms
+		keyFunc = func(*jwt.Token) (interface{}, error) {
+			return []byte(secret), nil
+		}
+	}
+
+	var claims jwt.RegisteredClaims
+	_, err = jwt.ParseWithClaims(creds.Assertion, &claims, keyFunc,
+		jwt.WithValidMethods(methods),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuer(clientID),
+		jwt.WithLeeway(assertionLeeway),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", utils.ErrInvalidClient, err)
+	}
+
+	if !a.acceptsAudience(claims.Audience, endpoint) {
+		return nil, fmt.Errorf("%w: client assertion has an invalid audience", utils.ErrInvalidClient)
+	}
+
+	if err := a.checkReplay(ctx, clientID, &claims); err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// acceptsAudience reports whether the assertion is addressed to this authorization server:
+// its issuer identifier, its token endpoint, or the endpoint receiving the request.
+func (a *ClientAuthenticator) acceptsAudience(audience jwt.ClaimStrings, endpoint string) bool {
+	accepted := []string{a.issuer, a.clientService.GetBaseURL() + "/oauth2/token", endpoint}
+	for _, aud := range audience {
+		if slices.Contains(accepted, aud) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkReplay rejects an assertion whose "jti" has already been used by the same client.
+// The identifier is remembered until the assertion expires, after which it is rejected anyway.
+func (a *ClientAuthenticator) checkReplay(ctx context.Context, clientID string, claims *jwt.RegisteredClaims) error {
+	if claims.ID == "" {
+		return fmt.Errorf("%w: client assertion is missing jti", utils.ErrInvalidClient)
+	}
+
+	ttl := time.Until(claims.ExpiresAt.Time) + assertionLeeway
+	added, err := a.replayCache.Add(ctx, "client_assertion:"+clientID+":"+claims.ID, ttl)
+	if err != nil {
+		return err
+	}
+	if !added {
+		return fmt.Errorf("%w: client assertion has already been used", utils.ErrInvalidClient)
+	}
+	return nil
+}
@@ -0,0 +1,351 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aminshahid573/authexa/internal/models"
+	"github.com/aminshahid573/authexa/internal/utils"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+)
+
+const (
+	testIssuer    = "https://auth.example.com"
+	testSecretKey = "0123456789abcdef0123456789abcdef"
+)
+
+// MockClientStore is an in-memory implementation of the storage.ClientStore interface.
+type MockClientStore struct {
+	Clients map[string]*models.Client
+}
+
+func NewMockClientStore(clients ...*models.Client) *MockClientStore {
+	m := &MockClientStore{Clients: make(map[string]*models.Client)}
+	for _, client := range clients {
+		m.Clients[client.ClientID] = client
+	}
+	return m
+}
+
+func (m *MockClientStore) GetByClientID(ctx context.Context, clientID string) (*models.Client, error) {
+	client, ok := m.Clients[clientID]
+	if !ok {
+		return nil, utils.ErrNotFound
+	}
+	return client, nil
+}
+
+func (m *MockClientStore) Create(ctx context.Context, client *models.Client) error {
+	m.Clients[client.ClientID] = client
+	return nil
+}
+
+func (m *MockClientStore) List(ctx context.Context) ([]models.Client, error) {
+	return nil, nil
+}
+
+func (m *MockClientStore) Update(ctx context.Context, client *models.Client) error {
+	m.Clients[client.ClientID] = client
+	return nil
+}
+
+func (m *MockClientStore) Delete(ctx context.Context, clientID string) error {
+	delete(m.Clients, clientID)
+	return nil
+}
+
+func (m *MockClientStore) Count(ctx context.Context) (int64, error) {
+	return int64(len(m.Clients)), nil
+}
+
+// MockReplayCache is an in-memory implementation of the storage.ReplayCache interface.
+type MockReplayCache struct {
+	Keys map[string]bool
+}
+
+func NewMockReplayCache() *MockReplayCache {
+	return &MockReplayCache{Keys: make(map[string]bool)}
+}
+
+func (m *MockReplayCache) Add(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	if m.Keys[key] {
+		return false, nil
+	}
+	m.Keys[key] = true
+	return true, nil
+}
+
+// newTestKeySet generates an RSA key and returns it with a JSON Web Key Set holding its
+// public key under the given kid.
+func newTestKeySet(t *testing.T, kid string) (*rsa.PrivateKey, string) {
+	t.Helper()
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	key, err := jwk.FromRaw(privateKey.Public())
+	if err != nil {
+		t.Fatalf("failed to create jwk: %v", err)
+	}
+	if err := key.Set(jwk.KeyIDKey, kid); err != nil {
+		t.Fatalf("failed to set kid: %v", err)
+	}
+	set := jwk.NewSet()
+	if err := set.AddKey(key); err != nil {
+		t.Fatalf("failed to add key: %v", err)
+	}
+	data, err := json.Marshal(set)
+	if err != nil {
+		t.Fatalf("failed to marshal jwks: %v", err)
+	}
+	return privateKey, string(data)
+}
+
+// signTestJWT signs claims with RS256 under the given kid.
+func signTestJWT(t *testing.T, privateKey *rsa.PrivateKey, kid string, claims jwt.Claims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(privateKey)
+	if err != nil {
+		t.Fatalf("failed to sign jwt: %v", err)
+	}
+	return signed
+}
+
+// TestClientAuthenticator_Secret tests authentication with a client secret or, for public
+// clients, the client_id alone.
+func TestClientAuthenticator_Secret(t *testing.T) {
+	ctx := context.Background()
+	hashedSecret, err := utils.HashPassword("client-secret")
+	if err != nil {
+		t.Fatalf("failed to hash secret: %v", err)
+	}
+	confidential := &models.Client{ClientID: "confidential", ClientSecret: hashedSecret}
+	public := &models.Client{ClientID: "public", TokenEndpointAuthMethod: models.ClientAuthMethodNone}
+	clientService := NewClientService(NewMockClientStore(confidential, public), testIssuer, testSecretKey)
+	authenticator := NewClientAuthenticator(clientService, NewClientPolicy(nil), NewJWKSResolver(nil), NewMockReplayCache(), testIssuer)
+
+	tests := []struct {
+		name    string
+		creds   ClientCredentials
+		wantErr bool
+	}{
+		{name: "Basic Secret", creds: ClientCredentials{ClientID: "confidential", ClientSecret: "client-secret", Method: models.ClientAuthMethodSecretBasic}},
+		{name: "Post Secret", creds: ClientCredentials{ClientID: "confidential", ClientSecret: "client-secret", Method: models.ClientAuthMethodSecretPost}},
+		{name: "Wrong Secret", creds: ClientCredentials{ClientID: "confidential", ClientSecret: "wrong", Method: models.ClientAuthMethodSecretBasic}, wantErr: true},
+		{name: "Confidential Client Without Secret", creds: ClientCredentials{ClientID: "confidential", Method: models.ClientAuthMethodNone}, wantErr: true},
+		{name: "Public Client", creds: ClientCredentials{ClientID: "public", Method: models.ClientAuthMethodNone}},
+		{name: "Public Client With Secret", creds: ClientCredentials{ClientID: "public", ClientSecret: "client-secret", Method: models.ClientAuthMethodSecretPost}, wantErr: true},
+		{name: "Unknown Client", creds: ClientCredentials{ClientID: "unknown", Method: models.ClientAuthMethodNone}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := authenticator.Authenticate(ctx, tt.creds, testIssuer+"/oauth2/token")
+			if tt.wantErr {
+				if !errors.Is(err, utils.ErrInvalidClient) {
+					t.Errorf("expected ErrInvalidClient, but got: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, but got: %v", err)
+			}
+			if client.ClientID != tt.creds.ClientID {
+				t.Errorf("expected client %s, but got: %s", tt.creds.ClientID, client.ClientID)
+			}
+		})
+	}
+}
+
+// TestClientAuthenticator_Assertion tests private_key_jwt and client_secret_jwt assertions,
+// including their audience and replay checks.
+func TestClientAuthenticator_Assertion(t *testing.T) {
+	ctx := context.Background()
+	privateKey, jwks := newTestKeySet(t, "k1")
+	otherKey, _ := newTestKeySet(t, "k1")
+	encryptedSecret, err := utils.EncryptSecret(testSecretKey, "hmac-client-secret")
+	if err != nil {
+		t.Fatalf("failed to encrypt secret: %v", err)
+	}
+	keyClient := &models.Client{ClientID: "key-client", TokenEndpointAuthMethod: models.ClientAuthMethodPrivateKeyJWT, JWKS: jwks}
+	hmacClient := &models.Client{ClientID: "hmac-client", TokenEndpointAuthMethod: models.ClientAuthMethodSecretJWT, ClientSecretEncrypted: encryptedSecret}
+	secretClient := &models.Client{ClientID: "secret-client", ClientSecret: "hashed"}
+	clientService := NewClientService(NewMockClientStore(keyClient, hmacClient, secretClient), testIssuer, testSecretKey)
+	introspectionEndpoint := testIssuer + "/oauth2/introspect"
+
+	claimsFor := func(clientID string, audience ...string) jwt.RegisteredClaims {
+		return jwt.RegisteredClaims{
+			Issuer:    clientID,
+			Subject:   clientID,
+			Audience:  audience,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+			ID:        "jti-" + clientID,
+		}
+	}
+	signHMAC := func(claims jwt.Claims) string {
+		signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("hmac-client-secret"))
+		if err != nil {
+			t.Fatalf("failed to sign jwt: %v", err)
+		}
+		return signed
+	}
+
+	tests := []struct {
+		name      string
+		clientID  string
+		assertion func() string
+		// replayed presents the same assertion a second time.
+		replayed bool
+		wantErr  bool
+	}{
+		{
+			name: "Private Key JWT",
+			assertion: func() string {
+				return signTestJWT(t, privateKey, "k1", claimsFor("key-client", testIssuer))
+			},
+		},
+		{
+			name: "Token Endpoint Audience",
+			assertion: func() string {
+				return signTestJWT(t, privateKey, "k1", claimsFor("key-client", testIssuer+"/oauth2/token"))
+			},
+		},
+		{
+			name: "Receiving Endpoint Audience",
+			assertion: func() string {
+				return signTestJWT(t, privateKey, "k1", claimsFor("key-client", introspectionEndpoint))
+			},
+		},
+		{
+			name: "Client Secret JWT",
+			assertion: func() string {
+				return signHMAC(claimsFor("hmac-client", testIssuer))
+			},
+		},
+		{
+			name:     "Replayed Assertion",
+			replayed: true,
+			wantErr:  true,
+			assertion: func() string {
+				return signTestJWT(t, privateKey, "k1", claimsFor("key-client", testIssuer))
+			},
+		},
+		{
+			name:    "Foreign Audience",
+			wantErr: true,
+			assertion: func() string {
+				return signTestJWT(t, privateKey, "k1", claimsFor("key-client", "https://other.example.com"))
+			},
+		},
+		{
+			name:    "Missing Audience",
+			wantErr: true,
+			assertion: func() string {
+				return signTestJWT(t, privateKey, "k1", claimsFor("key-client"))
+			},
+		},
+		{
+			name:    "Wrong Key",
+			wantErr: true,
+			assertion: func() string {
+				return signTestJWT(t, otherKey, "k1", claimsFor("key-client", testIssuer))
+			},
+		},
+		{
+			name:    "Issuer Is Not The Client",
+			wantErr: true,
+			assertion: func() string {
+				claims := claimsFor("key-client", testIssuer)
+				claims.Issuer = "someone-else"
+				return signTestJWT(t, privateKey, "k1", claims)
+			},
+		},
+		{
+			name:     "Subject Does Not Match client_id",
+			clientID: "hmac-client",
+			wantErr:  true,
+			assertion: func() string {
+				return signTestJWT(t, privateKey, "k1", claimsFor("key-client", testIssuer))
+			},
+		},
+		{
+			name:    "Missing Expiry",
+			wantErr: true,
+			assertion: func() string {
+				claims := claimsFor("key-client", testIssuer)
+				claims.ExpiresAt = nil
+				return signTestJWT(t, privateKey, "k1", claims)
+			},
+		},
+		{
+			name:    "Expired",
+			wantErr: true,
+			assertion: func() string {
+				claims := claimsFor("key-client", testIssuer)
+				claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Hour))
+				return signTestJWT(t, privateKey, "k1", claims)
+			},
+		},
+		{
+			name:    "Missing jti",
+			wantErr: true,
+			assertion: func() string {
+				claims := claimsFor("key-client", testIssuer)
+				claims.ID = ""
+				return signTestJWT(t, privateKey, "k1", claims)
+			},
+		},
+		{
+			name:    "Method Not Registered",
+			wantErr: true,
+			assertion: func() string {
+				return signHMAC(claimsFor("key-client", testIssuer))
+			},
+		},
+		{
+			name:    "Secret Client Using An Assertion",
+			wantErr: true,
+			assertion: func() string {
+				return signHMAC(claimsFor("secret-client", testIssuer))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authenticator := NewClientAuthenticator(clientService, NewClientPolicy(nil), NewJWKSResolver(nil), NewMockReplayCache(), testIssuer)
+			creds := ClientCredentials{
+				ClientID:      tt.clientID,
+				Assertion:     tt.assertion(),
+				AssertionType: models.ClientAssertionTypeJWTBearer,
+			}
+			if tt.replayed {
+				if _, err := authenticator.Authenticate(ctx, creds, introspectionEndpoint); err != nil {
+					t.Fatalf("expected the first use to succeed, but got: %v", err)
+				}
+			}
+
+			client, err := authenticator.Authenticate(ctx, creds, introspectionEndpoint)
+			if tt.wantErr {
+				if !errors.Is(err, utils.ErrInvalidClient) {
+					t.Errorf("expected ErrInvalidClient, but got: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, but got: %v", err)
+			}
+			if client == nil {
+				t.Fatal("expected the authenticated client, but got nil")
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package services
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/aminshahid573/authexa/internal/models"
+	"github.com/aminshahid573/authexa/internal/utils"
+)
+
+// publicClientGrantType reports whether public clients may use a grant type. Grants that
+// rely on the client's own credentials are reserved for confidential clients.
+func publicClientGrantType(grantType string) bool {
+	switch grantType {
+	case models.GrantTypeClientCredentials, models.GrantTypeJWTBearer, models.GrantTypeTokenExchange:
+		return false
+	}
+	return true
+}
+
+// SupportedResponseTypes lists the response types accepted at the authorization endpoint.
+var SupportedResponseTypes = []string{"code"}
+
+// defaultClientResponseTypes applies to clients that registered no response types (RFC 7591 section 2).
+var defaultClientResponseTypes = []string{"code"}
+
+// Errors returned by ClientPolicy, carrying the RFC 6749 error codes for the client.
+var (
+	ErrUnauthorizedClient      = &utils.AppError{Code: "unauthorized_client", Message: "The client is not authorized to use this grant type.", HTTPStatus: http.StatusBadRequest}
+	ErrUnsupportedResponseType = &utils.AppError{Code: "unsupported_response_type", Message: "Only the code response type is supported.", HTTPStatus: http.StatusBadRequest}
+	ErrClientResponseType      = &utils.AppError{Code: "unauthorized_client", Message: "The client is not authorized to use this response type.", HTTPStatus: http.StatusBadRequest}
+	ErrInvalidScope            = &utils.AppError{Code: "invalid_scope", Message: "The requested scope is invalid, unknown, or not allowed for this client.", HTTPStatus: http.StatusBadRequest}
+)
+
+// ClientPolicy decides what a registered client may do: the grant types and response types
+// it may use, the scopes it may obtain and how it must authenticate. The authorization,
+// device authorization and token endpoints all consult it, so every flow applies the same rules.
+type ClientPolicy struct {
+	scopeService *ScopeService
+}
+
+// NewClientPolicy creates a new ClientPolicy.
+func NewClientPolicy(scopeService *ScopeService) *ClientPolicy {
+	return &ClientPolicy{
+		scopeService: scopeService,
+	}
+}
+
+// CheckGrantType returns ErrUnauthorizedClient unless the client registered the grant type.
+// Public clients can never use grants that rely on the client's own credentials.
+func (p *ClientPolicy) CheckGrantType(client *models.Client, grantType string) error {
+	if !slices.Contains(client.GrantTypes, grantType) {
+		return ErrUnauthorizedClient
+	}
+	if client.IsPublic() && !publicClientGrantType(grantType) {
+		return ErrUnauthorizedClient
+	}
+	return nil
+}
+
+// CheckResponseType checks a response type requested at the authorization endpoint. It must
+// be supported by the server and registered by the client, which must also be allowed to
+// redeem the authorization code it yields.
+func (p *ClientPolicy) CheckResponseType(client *models.Client, responseType string) error {
+	if !slices.Contains(SupportedResponseTypes, responseType) {
+		return ErrUnsupportedResponseType
+	}
+
+	registered := client.ResponseTypes
+	if len(registered) == 0 {
+		registered = defaultClientResponseTypes
+	}
+	if !slices.Contains(registered, responseType) {
+		return ErrClientResponseType
+	}
+	if err := p.CheckGrantType(client, models.GrantTypeAuthorizationCode); err != nil {
+		return &utils.AppError{Code: "unauthorized_client", Message: "The client is not allowed to use the authorization code grant.", HTTPStatus: http.StatusBadRequest}
+	}
+	return nil
+}
+
+// CheckScopes returns ErrInvalidScope unless every scope is in the registry and covered by
+// the scopes the client registered, following the registry's wildcard and implication rules.
+func (p *ClientPolicy) CheckScopes(client *models.Client, scopes []string) error {
+	if !p.scopeService.ValidateScopes(scopes) || !p.scopeService.CoversAll(client.Scopes, scopes) {
+		return ErrInvalidScope
+	}
+	return nil
+}
+
+// DefaultScopes returns the registry's default scopes that the client may obtain.
+func (p *ClientPolicy) DefaultScopes(client *models.Client) []string {
+	return slices.DeleteFunc(p.scopeService.DefaultScopes(), func(scope string) bool {
+		return !p.scopeService.Covers(client.Scopes, scope)
+	})
+}
+
+// CheckAuthMethod checks that a client authenticated with the method it registered (see
+// models.ClientAuthMethod*). Clients registered without a method may use either
+// client_secret_basic or client_secret_post.
+func (p *ClientPolicy) CheckAuthMethod(client *models.Client, method string) error {
+	required := client.TokenEndpointAuthMethod
+	if required == "" {
+		if method == models.ClientAuthMethodSecretBasic || method == models.ClientAuthMethodSecretPost {
+			return nil
+		}
+		return fmt.Errorf("%w: client must authenticate with a client secret", utils.ErrInvalidClient)
+	}
+	if method != required {
+		return fmt.Errorf("%w: client must authenticate with %s", utils.ErrInvalidClient, required)
+	}
+	return nil
+}
+
+// CheckExchangeAudience returns ErrInvalidTarget unless the client's token exchange policy
+// allows it to obtain tokens for audience. A client may always obtain tokens for itself,
+// which an empty audience stands for.
+func (p *ClientPolicy) CheckExchangeAudience(client *models.Client, audience string) error {
+	if audience == "" || audience == client.ClientID || slices.Contains(client.TokenExchangeAudiences, audience) {
+		return nil
+	}
+	return ErrInvalidTarget
+}
+
+// ExchangeScopes decides the scopes of a token obtained by token exchange. Requested scopes
+// must be covered by the client's token exchange policy and by the subject's scopes, so that
+// exchanging a token can only narrow it. Without requested scopes, the subject's scopes that
+// the policy covers are used. Subjects resolved from unscoped tokens must have their scopes
+// filled in first.
+func (p *ClientPolicy) ExchangeScopes(client *models.Client, subject *TokenSubject, requested []string) ([]string, error) {
+	if len(requested) == 0 {
+		scopes := slices.DeleteFunc(slices.Clone(subject.Scopes), func(scope string) bool {
+			return !p.scopeService.Covers(client.TokenExchangeScopes, scope)
+		})
+		if len(scopes) == 0 {
+			return nil, ErrInvalidScope
+		}
+		return scopes, nil
+	}
+
+	if !p.scopeService.ValidateScopes(requested) || !p.scopeService.CoversAll(client.TokenExchangeScopes, requested) {
+		return nil, ErrInvalidScope
+	}
+	if !p.scopeService.CoversAll(subject.Scopes, requested) {
+		return nil, ErrInvalidScope
+	}
+	return requested, nil
+}
+
+// AssertionScopes decides the scopes of a token obtained with a JWT bearer assertion from a
+// trusted issuer. The token carries only the requested scopes, which must be allowed for both
+// the client and the issuer, so a scope must be requested.
+func (p *ClientPolicy) AssertionScopes(client *models.Client, issuer *models.TrustedIssuer, requested []string) ([]string, error) {
+	if len(requested) == 0 {
+		return nil, &utils.AppError{Code: "invalid_scope", Message: "The scope parameter is required for this grant type.", HTTPStatus: http.StatusBadRequest}
+	}
+	if err := p.CheckScopes(client, requested); err != nil {
+		return nil, err
+	}
+	if !p.scopeService.CoversAll(issuer.Scopes, requested) {
+		return nil, ErrInvalidScope
+	}
+	return requested, nil
+}
@@ -0,0 +1,164 @@
+package services
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/aminshahid573/authexa/internal/models"
+	"github.com/aminshahid573/authexa/internal/utils"
+)
+
+// TestClientPolicy_CheckGrantType tests that clients may only use the grant types they
+// registered, and public clients never those relying on client credentials.
+func TestClientPolicy_CheckGrantType(t *testing.T) {
+	policy := NewClientPolicy(newTestScopeService(t))
+	allGrants := []string{models.GrantTypeAuthorizationCode, models.GrantTypeRefreshToken, models.GrantTypeClientCredentials, models.GrantTypeTokenExchange}
+
+	tests := []struct {
+		name      string
+		client    *models.Client
+		grantType string
+		wantErr   bool
+	}{
+		{name: "Registered Grant", client: &models.Client{GrantTypes: allGrants}, grantType: models.GrantTypeClientCredentials},
+		{name: "Unregistered Grant", client: &models.Client{GrantTypes: allGrants}, grantType: models.GrantTypeDeviceCode, wantErr: true},
+		{name: "Public Client Code Grant", client: &models.Client{GrantTypes: allGrants, TokenEndpointAuthMethod: models.ClientAuthMethodNone}, grantType: models.GrantTypeAuthorizationCode},
+		{name: "Public Client Credentials Grant", client: &models.Client{GrantTypes: allGrants, TokenEndpointAuthMethod: models.ClientAuthMethodNone}, grantType: models.GrantTypeClientCredentials, wantErr: true},
+		{name: "Public Client Token Exchange", client: &models.Client{GrantTypes: allGrants, TokenEndpointAuthMethod: models.ClientAuthMethodNone}, grantType: models.GrantTypeTokenExchange, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.CheckGrantType(tt.client, tt.grantType)
+			if tt.wantErr && !errors.Is(err, ErrUnauthorizedClient) {
+				t.Errorf("expected ErrUnauthorizedClient, but got: %v", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("expected no error, but got: %v", err)
+			}
+		})
+	}
+}
+
+// TestClientPolicy_CheckScopes tests that requested scopes must be registered and covered
+// by the client's scopes.
+func TestClientPolicy_CheckScopes(t *testing.T) {
+	policy := NewClientPolicy(newTestScopeService(t))
+	client := &models.Client{Scopes: []string{"openid", "api:write", "documents:write:f1"}}
+
+	tests := []struct {
+		name    string
+		scopes  []string
+		wantErr bool
+	}{
+		{name: "Registered Scopes", scopes: []string{"openid", "api:write"}},
+		{name: "Implied Scope", scopes: []string{"api:read"}},
+		{name: "Implied Template Instance", scopes: []string{"documents:read:f1"}},
+		{name: "Scope Not Registered By Client", scopes: []string{"profile"}, wantErr: true},
+		{name: "Unknown Scope", scopes: []string{"admin"}, wantErr: true},
+		{name: "Other Template Instance", scopes: []string{"documents:read:f2"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.CheckScopes(client, tt.scopes)
+			if tt.wantErr && !errors.Is(err, ErrInvalidScope) {
+				t.Errorf("expected ErrInvalidScope, but got: %v", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("expected no error, but got: %v", err)
+			}
+		})
+	}
+}
+
+// TestClientPolicy_DefaultScopes tests that only the default scopes a client may obtain are used.
+func TestClientPolicy_DefaultScopes(t *testing.T) {
+	policy := NewClientPolicy(newTestScopeService(t))
+
+	if got := policy.DefaultScopes(&models.Client{Scopes: []string{"openid", "profile"}}); !slices.Equal(got, []string{"openid"}) {
+		t.Errorf("expected default scopes [openid], but got: %v", got)
+	}
+	if got := policy.DefaultScopes(&models.Client{Scopes: []string{"profile"}}); len(got) != 0 {
+		t.Errorf("expected no default scopes, but got: %v", got)
+	}
+}
+
+// TestClientPolicy_CheckAuthMethod tests that clients authenticate with the method they registered.
+func TestClientPolicy_CheckAuthMethod(t *testing.T) {
+	policy := NewClientPolicy(newTestScopeService(t))
+
+	tests := []struct {
+		name       string
+		registered string
+		method     string
+		wantErr    bool
+	}{
+		{name: "Unregistered Method With Basic", registered: "", method: models.ClientAuthMethodSecretBasic},
+		{name: "Unregistered Method With Post", registered: "", method: models.ClientAuthMethodSecretPost},
+		{name: "Unregistered Method With None", registered: "", method: models.ClientAuthMethodNone, wantErr: true},
+		{name: "Unregistered Method With Assertion", registered: "", method: models.ClientAuthMethodPrivateKeyJWT, wantErr: true},
+		{name: "Registered Method", registered: models.ClientAuthMethodPrivateKeyJWT, method: models.ClientAuthMethodPrivateKeyJWT},
+		{name: "Other Method", registered: models.ClientAuthMethodPrivateKeyJWT, method: models.ClientAuthMethodSecretBasic, wantErr: true},
+		{name: "Public Client", registered: models.ClientAuthMethodNone, method: models.ClientAuthMethodNone},
+		{name: "Public Client With Secret", registered: models.ClientAuthMethodNone, method: models.ClientAuthMethodSecretPost, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.CheckAuthMethod(&models.Client{TokenEndpointAuthMethod: tt.registered}, tt.method)
+			if tt.wantErr && !errors.Is(err, utils.ErrInvalidClient) {
+				t.Errorf("expected ErrInvalidClient, but got: %v", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("expected no error, but got: %v", err)
+			}
+		})
+	}
+}
+
+// TestClientPolicy_ExchangeScopes tests that token exchange can only narrow the subject
+// token's scopes, within the client's token exchange policy.
+func TestClientPolicy_ExchangeScopes(t *testing.T) {
+	policy := NewClientPolicy(newTestScopeService(t))
+	client := &models.Client{TokenExchangeScopes: []string{"openid", "api:*", "documents:read:f1"}}
+
+	tests := []struct {
+		name          string
+		subjectScopes []string
+		requested     []string
+		want          []string
+		wantErr       bool
+	}{
+		{name: "Requested Subset", subjectScopes: []string{"openid", "api:read", "api:write"}, requested: []string{"api:read"}, want: []string{"api:read"}},
+		{name: "Implied By Subject", subjectScopes: []string{"api:write"}, requested: []string{"api:read"}, want: []string{"api:read"}},
+		{name: "Template Implied By Subject", subjectScopes: []string{"documents:write:f1"}, requested: []string{"documents:read:f1"}, want: []string{"documents:read:f1"}},
+		{name: "Broader Than Subject", subjectScopes: []string{"api:read"}, requested: []string{"api:write"}, wantErr: true},
+		{name: "Outside Exchange Policy", subjectScopes: []string{"openid", "profile"}, requested: []string{"profile"}, wantErr: true},
+		{name: "Other Template Instance", subjectScopes: []string{"documents:write:f2"}, requested: []string{"documents:read:f2"}, wantErr: true},
+		{name: "Unknown Scope", subjectScopes: []string{"api:*"}, requested: []string{"api:delete"}, wantErr: true},
+		{name: "Subject Without Scopes", subjectScopes: nil, requested: []string{"openid"}, wantErr: true},
+		{name: "Nothing Requested", subjectScopes: []string{"openid", "profile", "api:read"}, requested: nil, want: []string{"openid", "api:read"}},
+		{name: "Nothing Requested Nor Allowed", subjectScopes: []string{"profile"}, requested: nil, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subject := &TokenSubject{Subject: "user-1", Scoped: true, Scopes: tt.subjectScopes}
+			got, err := policy.ExchangeScopes(client, subject, tt.requested)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidScope) {
+					t.Errorf("expected ErrInvalidScope, but got: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, but got: %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("expected scopes %v, but got: %v", tt.want, got)
+			}
+		})
+	}
+}
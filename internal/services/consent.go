@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/aminshahid573/authexa/internal/config"
+	"github.com/aminshahid573/authexa/internal/models"
+	"github.com/aminshahid573/authexa/internal/storage"
+	"github.com/aminshahid573/authexa/internal/utils"
+)
+
+// ConsentService remembers the scopes users have approved for clients, so that the consent
+// page is skipped for requests the user already agreed to.
+type ConsentService struct {
+	store storage.ConsentStore
+	cfg   config.ConsentConfig
+}
+
+// NewConsentService creates a new ConsentService.
+func NewConsentService(store storage.ConsentStore, cfg config.ConsentConfig) *ConsentService {
+	return &ConsentService{
+		store: store,
+		cfg:   cfg,
+	}
+}
+
+// GetGrant returns the consent a user has given a client, or nil if the user has never
+// approved the client or the grant has expired.
+func (s *ConsentService) GetGrant(ctx context.Context, userID, clientID string) (*models.ConsentGrant, error) {
+	grant, err := s.store.Get(ctx, userID, clientID)
+	if err != nil {
+		if errors.Is(err, utils.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if !grant.ExpiresAt.IsZero() && time.Now().After(grant.ExpiresAt) {
+		return nil, nil
+	}
+	return grant, nil
+}
+
+// GrantedScopes returns the scopes a user has granted a client. Trusted clients have
+// implicit consent to all of their scopes.
+func (s *ConsentService) GrantedScopes(ctx context.Context, client *models.Client, userID string) ([]string, error) {
+	if client.Trusted {
+		return client.Scopes, nil
+	}
+	grant, err := s.GetGrant(ctx, userID, client.ClientID)
+	if err != nil || grant == nil {
+		return nil, err
+	}
+	return grant.Scopes, nil
+}
+
+// Grant records that a user approved scopes for a client. The scopes are added to those
+// approved before, and the grant's lifetime starts again.
+func (s *ConsentService) Grant(ctx context.Context, userID, clientID string, scopes []string) error {
+	grant, err := s.GetGrant(ctx, userID, clientID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if grant == nil {
+		grant = &models.ConsentGrant{
+			UserID:    userID,
+			ClientID:  clientID,
+			CreatedAt: now,
+		}
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(grant.Scopes, scope) {
+			grant.Scopes = append(grant.Scopes, scope)
+		}
+	}
+	grant.UpdatedAt = now
+	grant.ExpiresAt = time.Time{}
+	if s.cfg.Lifetime > 0 {
+		grant.ExpiresAt = now.Add(s.cfg.Lifetime)
+	}
+	return s.store.Save(ctx, grant)
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/aminshahid573/authexa/internal/models"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+)
+
+const (
+	// jwksCacheTTL is how long a fetched JWKS is reused before it is fetched again.
+	jwksCacheTTL = 5 * time.Minute
+	// jwksMinRefreshInterval limits refetches triggered by an unknown "kid", so that
+	// requests naming random key IDs cannot make us hammer the client's server.
+	jwksMinRefreshInterval = 30 * time.Second
+)
+
+// JWKSResolver resolves the public keys of clients, from their inline JWKS or by
+// fetching and caching their JWKS URL.
+type JWKSResolver struct {
+	httpClient *http.Client
+
+	mu    sync.Mutex
+	cache map[string]cachedKeySet
+}
+
+type cachedKeySet struct {
+	set       jwk.Set
+	fetchedAt time.Time
+}
+
+// NewJWKSResolver creates a new JWKSResolver that fetches key sets with httpClient.
+func NewJWKSResolver(httpClient *http.Client) *JWKSResolver {
+	return &JWKSResolver{httpClient: httpClient, cache: make(map[string]cachedKeySet)}
+}
+
+// LookupKey returns the raw public key of a client identified by kid. An empty kid
+// is accepted when the key set holds exactly one key.
+func (r *JWKSResolver) LookupKey(ctx context.Context, client *models.Client, kid string) (any, error) {
+	if client.JWKS != "" {
+		set, err := jwk.Parse([]byte(client.JWKS))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse client jwks: %w", err)
+		}
+		return rawKey(set, kid)
+	}
+	if client.JWKSURL == "" {
+		return nil, fmt.Errorf("client %s has no registered keys", client.ClientID)
+	}
+	return r.LookupKeyAt(ctx, client.JWKSURL, kid)
+}
+
+// LookupKeyAt returns the raw public key identified by kid in the key set published at url.
+func (r *JWKSResolver) LookupKeyAt(ctx context.Context, url, kid string) (any, error) {
+	set, err := r.fetch(ctx, url, false)
+	if err != nil {
+		return nil, err
+	}
+	key, err := rawKey(set, kid)
+	if err == nil {
+		return key, nil
+	}
+
+	// The publisher may have rotated its keys since we cached them.
+	if set, err = r.fetch(ctx, url, true); err != nil {
+		return nil, err
+	}
+	return rawKey(set, kid)
+}
+
+// fetch returns the key set at url, from the cache unless it has expired. When refresh
+// is set, the cached set is replaced unless it was fetched very recently.
+func (r *JWKSResolver) fetch(ctx context.Context, url string, refresh bool) (jwk.Set, error) {
+	r.mu.Lock()
+	cached, ok := r.cache[url]
+	r.mu.Unlock()
+
+	age := time.Since(cached.fetchedAt)
+	if ok && age < jwksCacheTTL && (!refresh || age < jwksMinRefreshInterval) {
+		return cached.set, nil
+	}
+
+	set, err := jwk.Fetch(ctx, url, jwk.WithHTTPClient(r.httpClient))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch client jwks from %s: %w", url, err)
+	}
+
+	r.mu.Lock()
+	r.cache[url] = cachedKeySet{set: set, fetchedAt: time.Now()}
+	r.mu.Unlock()
+	return set, nil
+}
+
+// rawKey finds the key named by kid in set and returns its raw public key.
+func rawKey(set jwk.Set, kid string) (any, error) {
+	var key jwk.Key
+	if kid == "" {
+		if set.Len() != 1 {
+			return nil, fmt.Errorf("token has no kid and the key set holds %d keys", set.Len())
+		}
+		key, _ = set.Key(0)
+	} else {
+		var ok bool
+		if key, ok = set.LookupKeyID(kid); !ok {
+			return nil, fmt.Errorf("key %q not found in client jwks", kid)
+		}
+	}
+
+	raw, err := jwk.PublicRawKeyOf(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract public key: %w", err)
+	}
+	return raw, nil
+}
@@ -0,0 +1,354 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/aminshahid573/authexa/internal/config"
+	"github.com/aminshahid573/authexa/internal/models"
+	"github.com/aminshahid573/authexa/internal/storage"
+	"github.com/aminshahid573/authexa/internal/utils"
+)
+
+// KeyService manages the lifecycle of persisted JWT signing keys and keeps
+// the JWTManager's keyring in sync with storage. Every supported signing and
+// encryption algorithm has its own next, active and retired keys, rotated independently.
+type KeyService struct {
+	store         storage.SigningKeyStore
+	jwtManager    *utils.JWTManager
+	encryptionKey string
+	cfg           config.KeyConfig
+}
+
+// NewKeyService creates a new KeyService. Private keys are encrypted at rest with encryptionKey.
+func NewKeyService(store storage.SigningKeyStore, jwtManager *utils.JWTManager, encryptionKey string, cfg config.KeyConfig) *KeyService {
+	return &KeyService{
+		store:         store,
+		jwtManager:    jwtManager,
+		encryptionKey: encryptionKey,
+		cfg:           cfg,
+	}
+}
+
+// Initialize makes sure an active and a next key exist for every supported algorithm, then
+// loads them into the JWTManager. On first start the active RS256 key is imported from
+// seedPEMBase64 when one is configured.
+func (s *KeyService) Initialize(ctx context.Context, seedPEMBase64 string) error {
+	keys, err := s.store.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, alg := range utils.ManagedKeyAlgorithms {
+		if findKey(keys, alg, models.SigningKeyStateActive) == nil {
+			var seed *utils.SigningKey
+			imported := seedPEMBase64 != "" && alg == utils.DefaultSigningAlgorithm
+			if imported {
+				seed, err = parseSeedKey(seedPEMBase64)
+			} else {
+				seed, err = utils.GenerateSigningKey(alg)
+			}
+			if err != nil {
+				return err
+			}
+
+			if _, err := s.saveKey(ctx, seed, models.SigningKeyStateActive); err != nil {
+				return err
+			}
+			slog.Info("initial JWT signing key stored", "key_id", seed.KeyID, "alg", alg, "imported", imported)
+		}
+
+		if findKey(keys, alg, models.SigningKeyStateNext) == nil {
+			if _, err := s.createNextKey(ctx, alg); err != nil {
+				return err
+			}
+		}
+	}
+
+	return s.Reload(ctx)
+}
+
+// Reload reads the keys from storage and replaces the JWTManager's keyring. The next key is
+// published ahead of its activation and retired keys until their grace period ends, so
+// relying parties with a cached JWKS can always verify tokens across a rotation.
+func (s *KeyService) Reload(ctx context.Context) error {
+	keys, err := s.store.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	var active, published []*utils.SigningKey
+	for i := range keys {
+		key := &keys[i]
+		if !s.isPublished(key) {
+			continue
+		}
+
+		signingKey, err := s.decodeKey(key)
+		if err != nil {
+			return err
+		}
+		// Keys are listed newest first, so a race that left two active keys resolves to the newest.
+		if key.State == models.SigningKeyStateActive && findKey(keys[:i], key.Algorithm, models.SigningKeyStateActive) == nil {
+			active = append(active, signingKey)
+		}
+		published = append(published, signingKey)
+	}
+
+	if len(active) == 0 {
+		return errors.New("no active signing key in storage")
+	}
+	s.jwtManager.SetKeys(active, published)
+	return nil
+}
+
+// Run reloads the keys on every tick and rotates each active key once it is older
+// than the rotation interval. It blocks until ctx is cancelled.
+func (s *KeyService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.ReloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.rotateIfDue(ctx); err != nil {
+				slog.Error("scheduled signing key rotation failed", "error", err)
+			}
+			if err := s.Reload(ctx); err != nil {
+				slog.Error("failed to reload signing keys", "error", err)
+			}
+		}
+	}
+}
+
+// ListKeys retrieves every signing key, including retired and revoked ones.
+func (s *KeyService) ListKeys(ctx context.Context) ([]models.SigningKey, error) {
+	return s.store.List(ctx)
+}
+
+// Rotate retires the active key of each given algorithm, promotes its next key and generates
+// a new next key. With no algorithms, every supported algorithm is rotated. It returns the
+// newly active keys.
+func (s *KeyService) Rotate(ctx context.Context, algs ...string) ([]models.SigningKey, error) {
+	if len(algs) == 0 {
+		algs = utils.ManagedKeyAlgorithms
+	}
+
+	rotated := make([]models.SigningKey, 0, len(algs))
+	for _, alg := range algs {
+		if !slices.Contains(utils.ManagedKeyAlgorithms, alg) {
+			return nil, utils.ErrBadRequest
+		}
+		key, err := s.rotateAlgorithm(ctx, alg)
+		if err != nil {
+			return nil, err
+		}
+		rotated = append(rotated, *key)
+	}
+
+	if err := s.Reload(ctx); err != nil {
+		return nil, err
+	}
+	return rotated, nil
+}
+
+// rotateAlgorithm rotates the keys of a single algorithm without reloading the keyring.
+func (s *KeyService) rotateAlgorithm(ctx context.Context, alg string) (*models.SigningKey, error) {
+	keys, err := s.store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	next := findKey(keys, alg, models.SigningKeyStateNext)
+	if next == nil {
+		if next, err = s.createNextKey(ctx, alg); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	if active := findKey(keys, alg, models.SigningKeyStateActive); active != nil {
+		if err := s.store.UpdateState(ctx, active.KeyID, models.SigningKeyStateActive, models.SigningKeyStateRetired, now); err != nil {
+			if errors.Is(err, utils.ErrNotFound) {
+				// Another replica rotated first; adopt its result.
+				return s.activeKey(ctx, alg)
+			}
+			return nil, err
+		}
+	}
+
+	if err := s.store.UpdateState(ctx, next.KeyID, models.SigningKeyStateNext, models.SigningKeyStateActive, now); err != nil {
+		return nil, fmt.Errorf("failed to activate next signing key: %w", err)
+	}
+	if _, err := s.createNextKey(ctx, alg); err != nil {
+		return nil, err
+	}
+
+	slog.Info("JWT signing key rotated", "key_id", next.KeyID, "alg", alg)
+	return s.store.GetByKeyID(ctx, next.KeyID)
+}
+
+// Revoke withdraws a key immediately: it disappears from the JWKS, and tokens signed
+// with it stop verifying. Revoking the active key rotates first, and revoking the next
+// key replaces it.
+func (s *KeyService) Revoke(ctx context.Context, keyID string) error {
+	key, err := s.store.GetByKeyID(ctx, keyID)
+	if err != nil {
+		return err
+	}
+
+	state := key.State
+	switch state {
+	case models.SigningKeyStateRevoked:
+		return nil
+	case models.SigningKeyStateActive:
+		if _, err := s.rotateAlgorithm(ctx, key.Algorithm); err != nil {
+			return err
+		}
+		state = models.SigningKeyStateRetired
+	}
+
+	if err := s.store.UpdateState(ctx, keyID, state, models.SigningKeyStateRevoked, time.Now()); err != nil {
+		return err
+	}
+	if state == models.SigningKeyStateNext {
+		if _, err := s.createNextKey(ctx, key.Algorithm); err != nil {
+			return err
+		}
+	}
+
+	slog.Warn("JWT signing key revoked", "key_id", keyID)
+	return s.Reload(ctx)
+}
+
+// rotateIfDue rotates every active key that has outlived the rotation interval,
+// when scheduled rotation is enabled.
+func (s *KeyService) rotateIfDue(ctx context.Context) error {
+	if s.cfg.RotationInterval <= 0 {
+		return nil
+	}
+
+	keys, err := s.store.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	var due []string
+	for _, alg := range utils.ManagedKeyAlgorithms {
+		active := findKey(keys, alg, models.SigningKeyStateActive)
+		if active == nil || time.Since(active.ActivatedAt) >= s.cfg.RotationInterval {
+			due = append(due, alg)
+		}
+	}
+	if len(due) == 0 {
+		return nil
+	}
+	_, err = s.Rotate(ctx, due...)
+	return err
+}
+
+// activeKey returns the stored key currently active for an algorithm.
+func (s *KeyService) activeKey(ctx context.Context, alg string) (*models.SigningKey, error) {
+	keys, err := s.store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if active := findKey(keys, alg, models.SigningKeyStateActive); active != nil {
+		return active, nil
+	}
+	return nil, utils.ErrNotFound
+}
+
+// isPublished reports whether a key belongs in the JWKS.
+func (s *KeyService) isPublished(key *models.SigningKey) bool {
+	switch key.State {
+	case models.SigningKeyStateNext, models.SigningKeyStateActive:
+		return true
+	case models.SigningKeyStateRetired:
+		return time.Since(key.RetiredAt) < s.cfg.GracePeriod
+	default:
+		return false
+	}
+}
+
+// createNextKey generates and stores a key that is published but not yet used for signing.
+func (s *KeyService) createNextKey(ctx context.Context, alg string) (*models.SigningKey, error) {
+	key, err := utils.GenerateSigningKey(alg)
+	if err != nil {
+		return nil, err
+	}
+	return s.saveKey(ctx, key, models.SigningKeyStateNext)
+}
+
+// saveKey encrypts and persists a signing key in the given state.
+func (s *KeyService) saveKey(ctx context.Context, key *utils.SigningKey, state models.SigningKeyState) (*models.SigningKey, error) {
+	pemData, err := utils.EncodePrivateKeyPEM(key.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := utils.EncryptSecret(s.encryptionKey, pemData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt signing key: %w", err)
+	}
+
+	record := &models.SigningKey{
+		KeyID:      key.KeyID,
+		Algorithm:  key.Algorithm,
+		State:      state,
+		PrivateKey: encrypted,
+	}
+	if state == models.SigningKeyStateActive {
+		record.ActivatedAt = time.Now()
+	}
+	if err := s.store.Create(ctx, record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// decodeKey decrypts and parses a stored signing key.
+func (s *KeyService) decodeKey(key *models.SigningKey) (*utils.SigningKey, error) {
+	pemData, err := utils.DecryptSecret(s.encryptionKey, key.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt signing key %s: %w", key.KeyID, err)
+	}
+	privateKey, err := utils.ParsePrivateKeyPEM([]byte(pemData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signing key %s: %w", key.KeyID, err)
+	}
+	return &utils.SigningKey{
+		KeyID:      key.KeyID,
+		Algorithm:  key.Algorithm,
+		PrivateKey: privateKey,
+	}, nil
+}
+
+// parseSeedKey decodes the base64-encoded PEM key from the configuration.
+func parseSeedKey(seedPEMBase64 string) (*utils.SigningKey, error) {
+	pemData, err := base64.StdEncoding.DecodeString(seedPEMBase64)
+	if err != nil {
+		return nil, fmt.Errorf("failed to base64 decode private key: %w", err)
+	}
+	privateKey, err := utils.ParsePrivateKeyPEM(pemData)
+	if err != nil {
+		return nil, err
+	}
+	return utils.NewSigningKey(utils.DefaultSigningAlgorithm, privateKey)
+}
+
+// findKey returns the first key of an algorithm in the given state, or nil.
+func findKey(keys []models.SigningKey, alg string, state models.SigningKeyState) *models.SigningKey {
+	for i := range keys {
+		if keys[i].Algorithm == alg && keys[i].State == state {
+			return &keys[i]
+		}
+	}
+	return nil
+}
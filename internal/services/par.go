@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/aminshahid573/authexa/internal/config"
+	"github.com/aminshahid573/authexa/internal/models"
+	"github.com/aminshahid573/authexa/internal/storage"
+	"github.com/aminshahid573/authexa/internal/utils"
+)
+
+// ErrInvalidRequestURI is returned when a request_uri is unknown, expired, already
+// used or was pushed by another client.
+var ErrInvalidRequestURI = &utils.AppError{Code: "invalid_request_uri", Message: "The request_uri is invalid or has expired.", HTTPStatus: http.StatusBadRequest}
+
+// PARService manages pushed authorization requests (RFC 9126).
+type PARService struct {
+	store storage.PushedRequestStore
+	cfg   config.PARConfig
+}
+
+// NewPARService creates a new PARService.
+func NewPARService(store storage.PushedRequestStore, cfg config.PARConfig) *PARService {
+	return &PARService{
+		store: store,
+		cfg:   cfg,
+	}
+}
+
+// RequiredGlobally reports whether every client must use pushed authorization requests.
+func (s *PARService) RequiredGlobally() bool {
+	return s.cfg.Required
+}
+
+// Required reports whether a client must use pushed authorization requests.
+func (s *PARService) Required(client *models.Client) bool {
+	return s.cfg.Required || client.RequirePushedAuthorizationRequests
+}
+
+// Push stores the validated parameters of an authorization request and returns
+// the request_uri referencing them, along with its lifetime.
+func (s *PARService) Push(ctx context.Context, clientID string, params url.Values) (string, time.Duration, error) {
+	reference, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to generate request_uri: %w", err)
+	}
+
+	req := &models.PushedAuthorizationRequest{
+		RequestURI: models.RequestURIPrefix + reference,
+		ClientID:   clientID,
+		Parameters: params,
+		ExpiresAt:  time.Now().Add(s.cfg.RequestURILifetime),
+	}
+	if err := s.store.Save(ctx, req); err != nil {
+		return "", 0, err
+	}
+	return req.RequestURI, s.cfg.RequestURILifetime, nil
+}
+
+// Consume returns the parameters of a pushed request and invalidates its request_uri.
+// The request must have been pushed by the given client.
+func (s *PARService) Consume(ctx context.Context, clientID, requestURI string) (url.Values, error) {
+	req, err := s.store.Consume(ctx, requestURI)
+	if err != nil {
+		if errors.Is(err, utils.ErrNotFound) {
+			return nil, ErrInvalidRequestURI
+		}
+		return nil, err
+	}
+	if req.ClientID != clientID || time.Now().After(req.ExpiresAt) {
+		return nil, ErrInvalidRequestURI
+	}
+	return req.Parameters, nil
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aminshahid573/authexa/internal/config"
+	"github.com/aminshahid573/authexa/internal/models"
+	"github.com/aminshahid573/authexa/internal/utils"
+)
+
+// MockPushedRequestStore is an in-memory implementation of the storage.PushedRequestStore interface.
+type MockPushedRequestStore struct {
+	Requests map[string]*models.PushedAuthorizationRequest
+}
+
+func NewMockPushedRequestStore() *MockPushedRequestStore {
+	return &MockPushedRequestStore{Requests: make(map[string]*models.PushedAuthorizationRequest)}
+}
+
+func (m *MockPushedRequestStore) Save(ctx context.Context, req *models.PushedAuthorizationRequest) error {
+	m.Requests[req.RequestURI] = req
+	return nil
+}
+
+func (m *MockPushedRequestStore) Consume(ctx context.Context, requestURI string) (*models.PushedAuthorizationRequest, error) {
+	req, ok := m.Requests[requestURI]
+	if !ok {
+		return nil, utils.ErrNotFound
+	}
+	delete(m.Requests, requestURI)
+	return req, nil
+}
+
+// TestPARService_Consume tests that a pushed request can be used once, only by the client
+// that pushed it and only before it expires.
+func TestPARService_Consume(t *testing.T) {
+	ctx := context.Background()
+	params := url.Values{"response_type": {"code"}, "scope": {"openid"}}
+
+	tests := []struct {
+		name     string
+		lifetime time.Duration
+		clientID string
+		// requestURI overrides the pushed request_uri. reused consumes the request twice.
+		requestURI string
+		reused     bool
+		wantErr    bool
+	}{
+		{name: "Pushed Request", lifetime: time.Minute, clientID: "par-client"},
+		{name: "Reused Request URI", lifetime: time.Minute, clientID: "par-client", reused: true, wantErr: true},
+		{name: "Other Client", lifetime: time.Minute, clientID: "other-client", wantErr: true},
+		{name: "Expired Request URI", lifetime: -time.Second, clientID: "par-client", wantErr: true},
+		{name: "Unknown Request URI", lifetime: time.Minute, clientID: "par-client", requestURI: models.RequestURIPrefix + "unknown", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewPARService(NewMockPushedRequestStore(), config.PARConfig{RequestURILifetime: tt.lifetime})
+			requestURI, expiresIn, err := service.Push(ctx, "par-client", params)
+			if err != nil {
+				t.Fatalf("expected the request to be pushed, but got: %v", err)
+			}
+			if !strings.HasPrefix(requestURI, models.RequestURIPrefix) || expiresIn != tt.lifetime {
+				t.Fatalf("expected a %s request_uri valid for %v, but got %q for %v", models.RequestURIPrefix, tt.lifetime, requestURI, expiresIn)
+			}
+			if tt.requestURI != "" {
+				requestURI = tt.requestURI
+			}
+			if tt.reused {
+				if _, err := service.Consume(ctx, tt.clientID, requestURI); err != nil {
+					t.Fatalf("expected the first use to succeed, but got: %v", err)
+				}
+			}
+
+			got, err := service.Consume(ctx, tt.clientID, requestURI)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidRequestURI) {
+					t.Errorf("expected ErrInvalidRequestURI, but got: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, but got: %v", err)
+			}
+			if got.Encode() != params.Encode() {
+				t.Errorf("expected the pushed parameters %v, but got: %v", params, got)
+			}
+		})
+	}
+}
+
+// TestPARService_Required tests that PAR can be required globally or per client.
+func TestPARService_Required(t *testing.T) {
+	tests := []struct {
+		name     string
+		global   bool
+		client   *models.Client
+		expected bool
+	}{
+		{name: "Not Required", client: &models.Client{}, expected: false},
+		{name: "Required For Client", client: &models.Client{RequirePushedAuthorizationRequests: true}, expected: true},
+		{name: "Required Globally", global: true, client: &models.Client{}, expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewPARService(NewMockPushedRequestStore(), config.PARConfig{Required: tt.global})
+			if got := service.Required(tt.client); got != tt.expected {
+				t.Errorf("expected Required to be %v, but got: %v", tt.expected, got)
+			}
+		})
+	}
+}
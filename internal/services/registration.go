@@ -0,0 +1,367 @@
+package services
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/aminshahid573/authexa/internal/config"
+	"github.com/aminshahid573/authexa/internal/models"
+	"github.com/aminshahid573/authexa/internal/utils"
+	"github.com/go-playground/validator/v10"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// defaultRegistrationScope is granted to dynamically registered clients that request no scope.
+const defaultRegistrationScope = "openid"
+
+// Errors defined by RFC 7591 section 3.2.2.
+var (
+	ErrInvalidSoftwareStatement    = &utils.AppError{Code: "invalid_software_statement", Message: "The software statement is invalid.", HTTPStatus: http.StatusBadRequest}
+	ErrUnapprovedSoftwareStatement = &utils.AppError{Code: "unapproved_software_statement", Message: "The software statement is not approved for use with this server.", HTTPStatus: http.StatusBadRequest}
+	ErrInvalidRegistrationToken    = &utils.AppError{Code: "invalid_token", Message: "The access token is missing, invalid or expired.", HTTPStatus: http.StatusUnauthorized}
+)
+
+// ClientMetadata is the client metadata of RFC 7591 section 2, as sent to and
+// returned by the registration endpoint.
+type ClientMetadata struct {
+	ClientID                 string          `json:"client_id,omitempty"`
+	RedirectURIs             []string        `json:"redirect_uris,omitempty"`
+	TokenEndpointAuthMethod  string          `json:"token_endpoint_auth_method,omitempty"`
+	GrantTypes               []string        `json:"grant_types,omitempty"`
+	ResponseTypes            []string        `json:"response_types,omitempty"`
+	ClientName               string          `json:"client_name,omitempty"`
+	Scope                    string          `json:"scope,omitempty"`
+	JWKSURI                  string          `json:"jwks_uri,omitempty"`
+	JWKS                     json.RawMessage `json:"jwks,omitempty"`
+	IDTokenSignedResponseAlg string          `json:"id_token_signed_response_alg,omitempty"`
+	RequestObjectSigningAlg  string          `json:"request_object_signing_alg,omitempty"`
+	RequestURIs              []string        `json:"request_uris,omitempty"`
+	SoftwareStatement        string          `json:"software_statement,omitempty"`
+	// PostLogoutRedirectURIs lists where RP-initiated logout may send the user afterwards.
+	PostLogoutRedirectURIs []string `json:"post_logout_redirect_uris,omitempty"`
+
+	RequirePushedAuthorizationRequests bool `json:"require_pushed_authorization_requests,omitempty"`
+	RequireSignedRequestObject         bool `json:"require_signed_request_object,omitempty"`
+
+	// AuthorizationSignedResponseAlg is the JARM response signing algorithm.
+	AuthorizationSignedResponseAlg string `json:"authorization_signed_response_alg,omitempty"`
+}
+
+// RegistrationService implements dynamic client registration (RFC 7591) and
+// the client configuration endpoint (RFC 7592) on top of the ClientService.
+type RegistrationService struct {
+	clientService *ClientService
+	scopeService  *ScopeService
+	jwksResolver  *JWKSResolver
+	validate      *validator.Validate
+	cfg           config.RegistrationConfig
+}
+
+// NewRegistrationService creates a new RegistrationService.
+func NewRegistrationService(clientService *ClientService, scopeService *ScopeService, jwksResolver *JWKSResolver, cfg config.RegistrationConfig) *RegistrationService {
+	return &RegistrationService{
+		clientService: clientService,
+		scopeService:  scopeService,
+		jwksResolver:  jwksResolver,
+		validate:      validator.New(),
+		cfg:           cfg,
+	}
+}
+
+// Enabled reports whether the registration endpoint is served.
+func (s *RegistrationService) Enabled() bool {
+	return s.cfg.Enabled
+}
+
+// CheckInitialAccessToken verifies the bearer token of a registration request
+// when initial access tokens are configured.
+func (s *RegistrationService) CheckInitialAccessToken(token string) error {
+	if len(s.cfg.InitialAccessTokens) == 0 {
+		return nil
+	}
+	for _, allowed := range s.cfg.InitialAccessTokens {
+		if token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(allowed)) == 1 {
+			return nil
+		}
+	}
+	return ErrInvalidRegistrationToken
+}
+
+// Register creates a client from the given metadata. It returns the client, its plaintext
+// secret (empty when it has none) and its registration access token.
+func (s *RegistrationService) Register(ctx context.Context, metadata ClientMetadata) (*models.Client, string, string, error) {
+	if err := s.applySoftwareStatement(ctx, &metadata); err != nil {
+		return nil, "", "", err
+	}
+
+	if metadata.TokenEndpointAuthMethod == "" {
+		// RFC 7591 section 2: the default is client_secret_basic.
+		metadata.TokenEndpointAuthMethod = models.ClientAuthMethodSecretBasic
+	}
+	if metadata.ClientName == "" {
+		metadata.ClientName = "Dynamically registered client"
+	}
+	if err := s.checkAllowed(&metadata, nil); err != nil {
+		return nil, "", "", err
+	}
+
+	registrationToken, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("failed to generate registration access token: %w", err)
+	}
+
+	req := CreateClientRequest{
+		Name:                     metadata.ClientName,
+		RedirectURIs:             metadata.redirectURIs(),
+		PostLogoutRedirectURIs:   metadata.PostLogoutRedirectURIs,
+		GrantTypes:               metadata.grantTypes(),
+		ResponseTypes:            metadata.responseTypes(),
+		Scopes:                   metadata.scopes(),
+		JWKSURL:                  metadata.JWKSURI,
+		JWKS:                     metadata.JWKS,
+		TokenEndpointAuthMethod:  metadata.TokenEndpointAuthMethod,
+		IDTokenSignedResponseAlg: metadata.IDTokenSignedResponseAlg,
+		RequestObjectSigningAlg:  metadata.RequestObjectSigningAlg,
+		RequestURIs:              metadata.RequestURIs,
+		RegistrationAccessToken:  hashToken(registrationToken),
+
+		RequirePushedAuthorizationRequests: metadata.RequirePushedAuthorizationRequests,
+		RequireSignedRequestObject:         metadata.RequireSignedRequestObject,
+		AuthorizationSignedResponseAlg:     metadata.AuthorizationSignedResponseAlg,
+	}
+	if err := s.validate.Struct(req); err != nil {
+		return nil, "", "", invalidClientMetadata(err)
+	}
+
+	client, secret, err := s.clientService.CreateClient(ctx, req)
+	if err != nil {
+		return nil, "", "", invalidClientMetadata(err)
+	}
+	return client, secret, registrationToken, nil
+}
+
+// Authorize returns the client whose configuration the registration access token grants access to.
+func (s *RegistrationService) Authorize(ctx context.Context, clientID, token string) (*models.Client, error) {
+	client, err := s.clientService.GetClientByID(ctx, clientID)
+	if err != nil || client.RegistrationAccessToken == "" || token == "" {
+		// An unknown client and a bad token are indistinguishable to the caller.
+		return nil, ErrInvalidRegistrationToken
+	}
+	if subtle.ConstantTimeCompare([]byte(hashToken(token)), []byte(client.RegistrationAccessToken)) != 1 {
+		return nil, ErrInvalidRegistrationToken
+	}
+	return client, nil
+}
+
+// Update replaces the metadata of a registered client, as RFC 7592 section 2.2 describes.
+// Omitted fields are reset to their defaults rather than left unchanged.
+func (s *RegistrationService) Update(ctx context.Context, client *models.Client, metadata ClientMetadata) (*models.Client, error) {
+	if metadata.ClientID != client.ClientID {
+		return nil, &utils.AppError{Code: "invalid_request", Message: "client_id does not match the client being updated.", HTTPStatus: http.StatusBadRequest}
+	}
+	if err := s.applySoftwareStatement(ctx, &metadata); err != nil {
+		return nil, err
+	}
+	if metadata.TokenEndpointAuthMethod != "" && metadata.TokenEndpointAuthMethod != client.TokenEndpointAuthMethod {
+		return nil, &utils.AppError{Code: "invalid_client_metadata", Message: "token_endpoint_auth_method cannot be changed.", HTTPStatus: http.StatusBadRequest}
+	}
+	if metadata.ClientName == "" {
+		metadata.ClientName = client.Name
+	}
+	if err := s.checkAllowed(&metadata, client); err != nil {
+		return nil, err
+	}
+
+	req := UpdateClientRequest{
+		Name:                         metadata.ClientName,
+		RedirectURIs:                 metadata.redirectURIs(),
+		PostLogoutRedirectURIs:       metadata.PostLogoutRedirectURIs,
+		GrantTypes:                   metadata.grantTypes(),
+		ResponseTypes:                metadata.responseTypes(),
+		Scopes:                       metadata.scopes(),
+		JWKSURL:                      metadata.JWKSURI,
+		JWKS:                         metadata.JWKS,
+		RefreshTokenRotationDisabled: client.RefreshTokenRotationDisabled,
+		IDTokenSignedResponseAlg:     metadata.IDTokenSignedResponseAlg,
+		AccessTokenSigningAlg:        client.AccessTokenSigningAlg,
+		AccessTokenFormat:            client.AccessTokenFormat,
+		RequestObjectSigningAlg:      metadata.RequestObjectSigningAlg,
+		RequestURIs:                  metadata.RequestURIs,
+
+		RequirePushedAuthorizationRequests: metadata.RequirePushedAuthorizationRequests,
+		RequireSignedRequestObject:         metadata.RequireSignedRequestObject,
+		AuthorizationSignedResponseAlg:     metadata.AuthorizationSignedResponseAlg,
+		DefaultResponseMode:                client.DefaultResponseMode,
+		Trusted:                            client.Trusted,
+		RequiredScopes:                     client.RequiredScopes,
+		TokenExchangeAudiences:             client.TokenExchangeAudiences,
+		TokenExchangeScopes:                client.TokenExchangeScopes,
+	}
+	if err := s.validate.Struct(req); err != nil {
+		return nil, invalidClientMetadata(err)
+	}
+
+	updated, err := s.clientService.UpdateClient(ctx, client.ClientID, req)
+	if err != nil {
+		return nil, invalidClientMetadata(err)
+	}
+	return updated, nil
+}
+
+// Delete deregisters a client.
+func (s *RegistrationService) Delete(ctx context.Context, client *models.Client) error {
+	return s.clientService.DeleteClient(ctx, client.ClientID)
+}
+
+// Metadata returns the registered metadata of a client.
+func (s *RegistrationService) Metadata(client *models.Client) ClientMetadata {
+	metadata := ClientMetadata{
+		ClientID:                 client.ClientID,
+		RedirectURIs:             client.RedirectURIs,
+		PostLogoutRedirectURIs:   client.PostLogoutRedirectURIs,
+		TokenEndpointAuthMethod:  client.TokenEndpointAuthMethod,
+		GrantTypes:               client.GrantTypes,
+		ResponseTypes:            client.ResponseTypes,
+		ClientName:               client.Name,
+		Scope:                    strings.Join(client.Scopes, " "),
+		JWKSURI:                  client.JWKSURL,
+		IDTokenSignedResponseAlg: client.IDTokenSignedResponseAlg,
+		RequestObjectSigningAlg:  client.RequestObjectSigningAlg,
+		RequestURIs:              client.RequestURIs,
+
+		RequirePushedAuthorizationRequests: client.RequirePushedAuthorizationRequests,
+		RequireSignedRequestObject:         client.RequireSignedRequestObject,
+
+		AuthorizationSignedResponseAlg: client.AuthorizationSignedResponseAlg,
+	}
+	if client.JWKS != "" {
+		metadata.JWKS = json.RawMessage(client.JWKS)
+	}
+	return metadata
+}
+
+// checkAllowed limits the grant types and scopes of self-registered metadata to those the
+// server allows for dynamic registration. Scopes must also be in the scope registry. When
+// a client updates its registration, it may keep the grant types and scopes it already has,
+// which an admin may have given it.
+func (s *RegistrationService) checkAllowed(metadata *ClientMetadata, client *models.Client) error {
+	for _, grantType := range metadata.grantTypes() {
+		if slices.Contains(s.cfg.AllowedGrantTypes, grantType) || (client != nil && slices.Contains(client.GrantTypes, grantType)) {
+			continue
+		}
+		return &utils.AppError{Code: "invalid_client_metadata", Message: "The grant type " + grantType + " cannot be registered.", HTTPStatus: http.StatusBadRequest}
+	}
+
+	scopes := metadata.scopes()
+	if !s.scopeService.ValidateScopes(scopes) {
+		return &utils.AppError{Code: "invalid_client_metadata", Message: "The scope contains unknown scopes.", HTTPStatus: http.StatusBadRequest}
+	}
+	for _, scope := range scopes {
+		if s.scopeService.Covers(s.cfg.AllowedScopes, scope) || (client != nil && s.scopeService.Covers(client.Scopes, scope)) {
+			continue
+		}
+		return &utils.AppError{Code: "invalid_client_metadata", Message: "The scope " + scope + " cannot be registered.", HTTPStatus: http.StatusBadRequest}
+	}
+	return nil
+}
+
+// applySoftwareStatement verifies the software statement, if any, and lets its claims
+// override the metadata sent alongside it (RFC 7591 section 2.3).
+func (s *RegistrationService) applySoftwareStatement(ctx context.Context, metadata *ClientMetadata) error {
+	statement := metadata.SoftwareStatement
+	metadata.SoftwareStatement = ""
+	if statement == "" {
+		if s.cfg.RequireSoftwareStatement {
+			return &utils.AppError{Code: "invalid_client_metadata", Message: "A software statement is required.", HTTPStatus: http.StatusBadRequest}
+		}
+		return nil
+	}
+	if s.cfg.SoftwareStatementJWKSURL == "" {
+		return ErrUnapprovedSoftwareStatement
+	}
+
+	opts := []jwt.ParserOption{jwt.WithValidMethods(utils.SupportedSigningAlgorithms)}
+	if s.cfg.SoftwareStatementIssuer != "" {
+		opts = append(opts, jwt.WithIssuer(s.cfg.SoftwareStatementIssuer))
+	}
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(statement, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return s.jwksResolver.LookupKeyAt(ctx, s.cfg.SoftwareStatementJWKSURL, kid)
+	}, opts...)
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenInvalidIssuer) {
+			return ErrUnapprovedSoftwareStatement
+		}
+		return ErrInvalidSoftwareStatement
+	}
+
+	// Only the metadata fields present in the statement are overridden.
+	for _, claim := range []string{"iss", "sub", "aud", "exp", "nbf", "iat", "jti", "client_id", "software_statement"} {
+		delete(claims, claim)
+	}
+	raw, err := json.Marshal(claims)
+	if err != nil {
+		return ErrInvalidSoftwareStatement
+	}
+	if err := json.Unmarshal(raw, metadata); err != nil {
+		return ErrInvalidSoftwareStatement
+	}
+	return nil
+}
+
+// grantTypes returns the requested grant types, defaulting to authorization_code.
+func (m *ClientMetadata) grantTypes() []string {
+	if len(m.GrantTypes) == 0 {
+		return []string{models.GrantTypeAuthorizationCode}
+	}
+	return m.GrantTypes
+}
+
+// redirectURIs returns the registered redirect URIs, which only clients using
+// the authorization code grant are required to have.
+func (m *ClientMetadata) redirectURIs() []string {
+	if m.RedirectURIs == nil && !slices.Contains(m.grantTypes(), models.GrantTypeAuthorizationCode) {
+		return []string{}
+	}
+	return m.RedirectURIs
+}
+
+// responseTypes returns the requested response types. Clients using only grants
+// without a redirect have none, and the others default to "code".
+func (m *ClientMetadata) responseTypes() []string {
+	if len(m.ResponseTypes) > 0 {
+		return m.ResponseTypes
+	}
+	if slices.Contains(m.grantTypes(), models.GrantTypeAuthorizationCode) {
+		return []string{"code"}
+	}
+	return []string{}
+}
+
+// scopes returns the requested scopes, defaulting to openid.
+func (m *ClientMetadata) scopes() []string {
+	if scopes := strings.Fields(m.Scope); len(scopes) > 0 {
+		return scopes
+	}
+	return []string{defaultRegistrationScope}
+}
+
+// invalidClientMetadata reports validation failures with the RFC 7591 error code.
+func invalidClientMetadata(err error) error {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		return &utils.AppError{Code: "invalid_client_metadata", Message: err.Error(), HTTPStatus: http.StatusBadRequest, Err: err}
+	}
+	var appErr *utils.AppError
+	if errors.As(err, &appErr) && appErr.Code == "VALIDATION_ERROR" {
+		return &utils.AppError{Code: "invalid_client_metadata", Message: appErr.Message, HTTPStatus: http.StatusBadRequest, Err: err}
+	}
+	return err
+}
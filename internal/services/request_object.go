@@ -0,0 +1,246 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aminshahid573/authexa/internal/models"
+	"github.com/aminshahid573/authexa/internal/utils"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwe"
+)
+
+const (
+	// maxRequestObjectSize limits the size of a request object fetched from a request_uri.
+	maxRequestObjectSize = 64 << 10
+	// maxRequestObjectLifetime limits how far in the future a request object may expire,
+	// so that a signed request cannot be replayed indefinitely.
+	maxRequestObjectLifetime = time.Hour
+)
+
+// RequestObjectEncryptionMethods lists the JWE content encryption algorithms accepted
+// for encrypted request objects.
+var RequestObjectEncryptionMethods = []string{
+	string(jwa.A128CBC_HS256),
+	string(jwa.A256CBC_HS512),
+	string(jwa.A128GCM),
+	string(jwa.A256GCM),
+}
+
+// requestObjectReservedClaims are JWT claims that describe the request object itself
+// rather than an authorization request parameter.
+var requestObjectReservedClaims = []string{"iss", "aud", "exp", "nbf", "iat", "jti", "sub", "request", "request_uri"}
+
+// RequestObjectService verifies JWT-secured authorization requests (RFC 9101), passed by
+// value in the "request" parameter or by reference in a registered "request_uri".
+type RequestObjectService struct {
+	jwksResolver *JWKSResolver
+	jwtManager   *utils.JWTManager
+	httpClient   *http.Client
+}
+
+// NewRequestObjectService creates a new RequestObjectService that fetches request URIs with httpClient.
+func NewRequestObjectService(jwksResolver *JWKSResolver, jwtManager *utils.JWTManager, httpClient *http.Client) *RequestObjectService {
+	return &RequestObjectService{
+		jwksResolver: jwksResolver,
+		jwtManager:   jwtManager,
+		httpClient:   httpClient,
+	}
+}
+
+// HasRequestObject reports whether an authorization request carries a request object,
+// either by value or by a request_uri that is not a pushed authorization request.
+func HasRequestObject(params url.Values) bool {
+	requestURI := params.Get("request_uri")
+	return params.Get("request") != "" || (requestURI != "" && !strings.HasPrefix(requestURI, models.RequestURIPrefix))
+}
+
+// Resolve verifies the request object of an authorization request and returns the request
+// parameters with its claims merged in. Claims take precedence over the plain parameters.
+func (s *RequestObjectService) Resolve(ctx context.Context, client *models.Client, params url.Values) (url.Values, error) {
+	request := params.Get("request")
+	requestURI := params.Get("request_uri")
+	if request != "" && requestURI != "" {
+		return nil, &utils.AppError{Code: "invalid_request", Message: "Only one of request and request_uri may be sent.", HTTPStatus: http.StatusBadRequest}
+	}
+
+	if requestURI != "" {
+		var err error
+		if request, err = s.fetch(ctx, client, requestURI); err != nil {
+			return nil, err
+		}
+	}
+
+	claims, err := s.verify(ctx, client, request)
+	if err != nil {
+		return nil, invalidRequestObject(err)
+	}
+
+	resolved := url.Values{}
+	for key, values := range params {
+		resolved[key] = values
+	}
+	resolved.Del("request")
+	resolved.Del("request_uri")
+	for _, claim := range requestObjectReservedClaims {
+		delete(claims, claim)
+	}
+	for key, value := range claims {
+		resolved[key] = claimValues(value)
+	}
+	return resolved, nil
+}
+
+// fetch retrieves the request object a client published at one of its registered request URIs.
+func (s *RequestObjectService) fetch(ctx context.Context, client *models.Client, requestURI string) (string, error) {
+	if !slices.Contains(client.RequestURIs, requestURI) {
+		return "", &utils.AppError{Code: "invalid_request_uri", Message: "The request_uri is not registered for this client.", HTTPStatus: http.StatusBadRequest}
+	}
+	if u, err := url.Parse(requestURI); err != nil || u.Scheme != "https" {
+		return "", &utils.AppError{Code: "invalid_request_uri", Message: "The request_uri must be an https URL.", HTTPStatus: http.StatusBadRequest}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURI, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to build request_uri request: %w", err)
+	}
+	req.Header.Set("Accept", "application/oauth-authz-req+jwt")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return "", &utils.AppError{Code: "invalid_request_uri", Message: "The request_uri could not be retrieved.", HTTPStatus: http.StatusBadRequest, Err: err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", &utils.AppError{Code: "invalid_request_uri", Message: "The request_uri could not be retrieved.", HTTPStatus: http.StatusBadRequest, Err: fmt.Errorf("unexpected status %d", resp.StatusCode)}
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxRequestObjectSize+1))
+	if err != nil {
+		return "", &utils.AppError{Code: "invalid_request_uri", Message: "The request_uri could not be retrieved.", HTTPStatus: http.StatusBadRequest, Err: err}
+	}
+	if len(body) > maxRequestObjectSize {
+		return "", &utils.AppError{Code: "invalid_request_uri", Message: "The request object is too large.", HTTPStatus: http.StatusBadRequest}
+	}
+	return strings.TrimSpace(string(body)), nil
+}
+
+// verify decrypts the request object if needed, checks its signature against the client's
+// registered keys and returns its claims. As RFC 9101 section 6.3 requires, the request
+// object must be issued by the client, addressed to this server and expire.
+func (s *RequestObjectService) verify(ctx context.Context, client *models.Client, request string) (jwt.MapClaims, error) {
+	// A compact JWE has five parts, a JWS three.
+	if strings.Count(request, ".") == 4 {
+		decrypted, err := s.decrypt(request)
+		if err != nil {
+			return nil, err
+		}
+		request = decrypted
+	}
+
+	methods := utils.SupportedSigningAlgorithms
+	if client.RequestObjectSigningAlg != "" {
+		methods = []string{client.RequestObjectSigningAlg}
+	}
+
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(request, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return s.jwksResolver.LookupKey(ctx, client, kid)
+	},
+		jwt.WithValidMethods(methods),
+		jwt.WithLeeway(assertionLeeway),
+		jwt.WithJSONNumber(),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuer(client.ClientID),
+		jwt.WithAudience(s.jwtManager.GetIssuer()),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if clientID, ok := claims["client_id"]; ok && clientID != client.ClientID {
+		return nil, errors.New("request object client_id does not match the client")
+	}
+	exp, err := claims.GetExpirationTime()
+	if err != nil {
+		return nil, err
+	}
+	if time.Until(exp.Time) > maxRequestObjectLifetime+assertionLeeway {
+		return nil, errors.New("request object expires too far in the future")
+	}
+	return claims, nil
+}
+
+// decrypt decrypts a request object encrypted to one of our published encryption keys.
+func (s *RequestObjectService) decrypt(request string) (string, error) {
+	msg, err := jwe.Parse([]byte(request))
+	if err != nil {
+		return "", fmt.Errorf("malformed encrypted request object: %w", err)
+	}
+
+	headers := msg.ProtectedHeaders()
+	key, ok := s.jwtManager.DecryptionKey(headers.KeyID())
+	if !ok {
+		return "", fmt.Errorf("unknown encryption key: %q", headers.KeyID())
+	}
+	if headers.Algorithm().String() != key.Algorithm {
+		return "", fmt.Errorf("unexpected key management algorithm: %s", headers.Algorithm())
+	}
+	if !slices.Contains(RequestObjectEncryptionMethods, headers.ContentEncryption().String()) {
+		return "", fmt.Errorf("unsupported content encryption algorithm: %s", headers.ContentEncryption())
+	}
+
+	payload, err := jwe.Decrypt([]byte(request), jwe.WithKey(jwa.KeyEncryptionAlgorithm(key.Algorithm), key.PrivateKey))
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt request object: %w", err)
+	}
+	return string(payload), nil
+}
+
+// claimValues converts a request object claim to authorization request parameter values.
+// Arrays of strings become repeated parameters and objects, such as "claims", are kept as JSON.
+func claimValues(value any) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case json.Number:
+		return []string{v.String()}
+	case bool:
+		return []string{strconv.FormatBool(v)}
+	case []any:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			str, ok := item.(string)
+			if !ok {
+				data, _ := json.Marshal(v)
+				return []string{string(data)}
+			}
+			values = append(values, str)
+		}
+		return values
+	default:
+		data, _ := json.Marshal(v)
+		return []string{string(data)}
+	}
+}
+
+// invalidRequestObject wraps a verification failure in the RFC 9101 invalid_request_object error.
+// Errors that already carry an OAuth error code are returned unchanged.
+func invalidRequestObject(err error) error {
+	var appErr *utils.AppError
+	if errors.As(err, &appErr) {
+		return err
+	}
+	return &utils.AppError{Code: "invalid_request_object", Message: "The request object is invalid.", HTTPStatus: http.StatusBadRequest, Err: err}
+}
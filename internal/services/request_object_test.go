@@ -0,0 +1,169 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/aminshahid573/authexa/internal/config"
+	"github.com/aminshahid573/authexa/internal/models"
+	"github.com/aminshahid573/authexa/internal/utils"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwe"
+)
+
+// TestRequestObjectService_Resolve tests the verification of request objects passed by
+// value, by reference and encrypted to the server.
+func TestRequestObjectService_Resolve(t *testing.T) {
+	ctx := context.Background()
+	privateKey, jwks := newTestKeySet(t, "k1")
+	otherKey, _ := newTestKeySet(t, "k1")
+
+	// The server publishes an encryption key that clients encrypt request objects to.
+	encryptionKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	decryptionKey, err := utils.NewSigningKey(string(jwa.RSA_OAEP_256), encryptionKey)
+	if err != nil {
+		t.Fatalf("failed to create encryption key: %v", err)
+	}
+	jwtManager := utils.NewJWTManager(config.JWTConfig{Issuer: testIssuer})
+	jwtManager.SetKeys(nil, []*utils.SigningKey{decryptionKey})
+
+	encrypt := func(alg jwa.KeyEncryptionAlgorithm, enc jwa.ContentEncryptionAlgorithm, kid string) func(request string) string {
+		return func(request string) string {
+			headers := jwe.NewHeaders()
+			if err := headers.Set(jwe.KeyIDKey, kid); err != nil {
+				t.Fatalf("failed to set kid: %v", err)
+			}
+			encrypted, err := jwe.Encrypt([]byte(request), jwe.WithKey(alg, &encryptionKey.PublicKey), jwe.WithContentEncryption(enc), jwe.WithProtectedHeaders(headers))
+			if err != nil {
+				t.Fatalf("failed to encrypt request object: %v", err)
+			}
+			return string(encrypted)
+		}
+	}
+
+	// The client also publishes its request objects at a registered request_uri.
+	var published string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, published)
+	}))
+	defer server.Close()
+	registeredURI := server.URL + "/request.jwt"
+
+	validClaims := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss":       "jar-client",
+			"aud":       testIssuer,
+			"exp":       time.Now().Add(5 * time.Minute).Unix(),
+			"client_id": "jar-client",
+			"scope":     "openid profile",
+			"state":     "xyz",
+		}
+	}
+
+	tests := []struct {
+		name   string
+		claims func(claims jwt.MapClaims)
+		// signer overrides the client's key. encrypt wraps the signed request object in a JWE.
+		signer  func(claims jwt.MapClaims) string
+		encrypt func(request string) string
+		// byReference passes the request object in a request_uri, the registered one unless
+		// requestURI is set. both also sends it by value.
+		byReference bool
+		requestURI  string
+		both        bool
+		signingAlg  string
+		wantCode    string
+	}{
+		{name: "Valid Request Object"},
+		{name: "Request By Reference", byReference: true},
+		{name: "Pinned Signing Algorithm", signingAlg: "RS256"},
+		{name: "Encrypted Request Object", encrypt: encrypt(jwa.RSA_OAEP_256, jwa.A128GCM, decryptionKey.KeyID)},
+		{name: "Request And Request URI", byReference: true, both: true, wantCode: "invalid_request"},
+		{name: "Unregistered Request URI", byReference: true, requestURI: server.URL + "/other.jwt", wantCode: "invalid_request_uri"},
+		{name: "Wrong Key", signer: func(c jwt.MapClaims) string { return signTestJWT(t, otherKey, "k1", c) }, wantCode: "invalid_request_object"},
+		{name: "Other Signing Algorithm", signingAlg: "ES256", wantCode: "invalid_request_object"},
+		{name: "Missing Issuer", claims: func(c jwt.MapClaims) { delete(c, "iss") }, wantCode: "invalid_request_object"},
+		{name: "Other Issuer", claims: func(c jwt.MapClaims) { c["iss"] = "other-client" }, wantCode: "invalid_request_object"},
+		{name: "Missing Audience", claims: func(c jwt.MapClaims) { delete(c, "aud") }, wantCode: "invalid_request_object"},
+		{name: "Foreign Audience", claims: func(c jwt.MapClaims) { c["aud"] = "https://other.example.com" }, wantCode: "invalid_request_object"},
+		{name: "Missing Expiry", claims: func(c jwt.MapClaims) { delete(c, "exp") }, wantCode: "invalid_request_object"},
+		{name: "Expired", claims: func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() }, wantCode: "invalid_request_object"},
+		{name: "Lifetime Too Long", claims: func(c jwt.MapClaims) { c["exp"] = time.Now().Add(2 * time.Hour).Unix() }, wantCode: "invalid_request_object"},
+		{name: "Other client_id", claims: func(c jwt.MapClaims) { c["client_id"] = "other-client" }, wantCode: "invalid_request_object"},
+		{name: "Encrypted To Unknown Key", encrypt: encrypt(jwa.RSA_OAEP_256, jwa.A128GCM, "unknown"), wantCode: "invalid_request_object"},
+		{name: "Other Key Management Algorithm", encrypt: encrypt(jwa.RSA_OAEP, jwa.A128GCM, decryptionKey.KeyID), wantCode: "invalid_request_object"},
+		{name: "Unsupported Content Encryption", encrypt: encrypt(jwa.RSA_OAEP_256, jwa.A192GCM, decryptionKey.KeyID), wantCode: "invalid_request_object"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &models.Client{
+				ClientID:                "jar-client",
+				JWKS:                    jwks,
+				RequestURIs:             []string{registeredURI},
+				RequestObjectSigningAlg: tt.signingAlg,
+			}
+			service := NewRequestObjectService(NewJWKSResolver(nil), jwtManager, server.Client())
+
+			claims := validClaims()
+			if tt.claims != nil {
+				tt.claims(claims)
+			}
+			request := signTestJWT(t, privateKey, "k1", claims)
+			if tt.signer != nil {
+				request = tt.signer(claims)
+			}
+			if tt.encrypt != nil {
+				request = tt.encrypt(request)
+			}
+
+			params := url.Values{"client_id": {"jar-client"}, "scope": {"openid"}, "response_type": {"code"}}
+			if tt.byReference {
+				published = request
+				requestURI := registeredURI
+				if tt.requestURI != "" {
+					requestURI = tt.requestURI
+				}
+				params.Set("request_uri", requestURI)
+				if tt.both {
+					params.Set("request", request)
+				}
+			} else {
+				params.Set("request", request)
+			}
+
+			resolved, err := service.Resolve(ctx, client, params)
+			if tt.wantCode != "" {
+				var appErr *utils.AppError
+				if !errors.As(err, &appErr) || appErr.Code != tt.wantCode {
+					t.Errorf("expected a %s error, but got: %v", tt.wantCode, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, but got: %v", err)
+			}
+			if got := resolved.Get("scope"); got != "openid profile" {
+				t.Errorf("expected the request object's scope to take precedence, but got: %q", got)
+			}
+			if resolved.Get("state") != "xyz" || resolved.Get("response_type") != "code" {
+				t.Errorf("expected the claims to be merged into the parameters, but got: %v", resolved)
+			}
+			if resolved.Has("request") || resolved.Has("request_uri") || resolved.Has("iss") || resolved.Has("exp") {
+				t.Errorf("expected the request object and its reserved claims to be dropped, but got: %v", resolved)
+			}
+		})
+	}
+}
@@ -0,0 +1,222 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/aminshahid573/authexa/internal/models"
+	"github.com/aminshahid573/authexa/internal/storage"
+	"github.com/aminshahid573/authexa/internal/utils"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// ErrInvalidTarget is returned when a resource parameter names an unknown resource server,
+// or one the grant does not cover (RFC 8707 section 2).
+var ErrInvalidTarget = &utils.AppError{Code: "invalid_target", Message: "The requested resource is invalid, unknown, or not allowed.", HTTPStatus: http.StatusBadRequest}
+
+// ErrNoScopesForTarget is returned when none of the granted scopes are owned by the resource
+// server an access token is requested for. Such a token would grant nothing.
+var ErrNoScopesForTarget = &utils.AppError{Code: "invalid_scope", Message: "None of the granted scopes apply to the requested resource.", HTTPStatus: http.StatusBadRequest}
+
+// ErrResourceServerExists is returned when registering an identifier that is already registered.
+var ErrResourceServerExists = &utils.AppError{Code: "RESOURCE_SERVER_EXISTS", Message: "A resource server with this identifier already exists.", HTTPStatus: http.StatusConflict}
+
+// ResourceServerRequest is the admin API payload for registering or changing a resource server.
+type ResourceServerRequest struct {
+	Identifier string `json:"identifier" validate:"required"`
+	Name       string `json:"name" validate:"required"`
+}
+
+// ResourceService manages the registry of resource servers and decides the audience and
+// scopes of the access tokens minted for them.
+type ResourceService struct {
+	store        storage.ResourceServerStore
+	scopeService *ScopeService
+}
+
+// NewResourceService creates a new ResourceService.
+func NewResourceService(store storage.ResourceServerStore, scopeService *ScopeService) *ResourceService {
+	return &ResourceService{
+		store:        store,
+		scopeService: scopeService,
+	}
+}
+
+// ValidateResources checks the resource parameters of an authorization request. Each must
+// be the identifier of a registered resource server.
+func (s *ResourceService) ValidateResources(ctx context.Context, resources []string) error {
+	for _, resource := range resources {
+		if _, err := s.lookup(ctx, resource); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Target decides the audience and scopes of an access token. requested holds the resource
+// parameters of the token request, and granted the resources of the grant, if any. A token
+// request may name one resource, which must be part of the grant. When it names none, the
+// grant's only resource is used. The token then carries the resource's identifier as audience
+// and only the granted scopes it owns, of which there must be at least one. Without any
+// resource the audience is left empty, for the client, and the scopes are unchanged.
+func (s *ResourceService) Target(ctx context.Context, requested, granted, scopes []string) (string, []string, error) {
+	var resource string
+	switch {
+	case len(requested) > 1:
+		return "", nil, &utils.AppError{Code: "invalid_target", Message: "Only one resource may be requested per access token.", HTTPStatus: http.StatusBadRequest}
+	case len(requested) == 1:
+		resource = requested[0]
+		if len(granted) > 0 && !slices.Contains(granted, resource) {
+			return "", nil, &utils.AppError{Code: "invalid_target", Message: "The requested resource was not part of the authorization grant.", HTTPStatus: http.StatusBadRequest}
+		}
+	case len(granted) == 1:
+		resource = granted[0]
+	case len(granted) > 1:
+		return "", nil, &utils.AppError{Code: "invalid_target", Message: "The grant covers several resources, so the resource parameter is required.", HTTPStatus: http.StatusBadRequest}
+	default:
+		return "", scopes, nil
+	}
+
+	if _, err := s.lookup(ctx, resource); err != nil {
+		return "", nil, err
+	}
+	return s.narrow(resource, scopes)
+}
+
+// ExchangeTarget decides the audience and scopes of a token obtained by token exchange
+// (RFC 8693). A resource is handled as in Target. An audience naming a registered resource
+// server narrows the scopes the same way, while any other audience is a logical name, such
+// as a client ID, and is used as is. At most one resource or audience may be requested.
+func (s *ResourceService) ExchangeTarget(ctx context.Context, resources, audiences, scopes []string) (string, []string, error) {
+	switch {
+	case len(resources)+len(audiences) > 1:
+		return "", nil, &utils.AppError{Code: "invalid_target", Message: "Only one resource or audience may be requested per access token.", HTTPStatus: http.StatusBadRequest}
+	case len(resources) == 1:
+		return s.Target(ctx, resources, nil, scopes)
+	case len(audiences) == 1:
+		audience := audiences[0]
+		if _, err := s.lookup(ctx, audience); err != nil {
+			if errors.Is(err, ErrInvalidTarget) {
+				return audience, scopes, nil
+			}
+			return "", nil, err
+		}
+		return s.narrow(audience, scopes)
+	default:
+		return "", scopes, nil
+	}
+}
+
+// narrow returns the scopes owned by a resource server, out of a set of scopes. It returns
+// ErrNoScopesForTarget if there are none.
+func (s *ResourceService) narrow(resource string, scopes []string) (string, []string, error) {
+	owned := s.scopeService.OwnedBy(resource, scopes)
+	if len(owned) == 0 {
+		return "", nil, ErrNoScopesForTarget
+	}
+	return resource, owned, nil
+}
+
+// lookup returns the resource server registered under an RFC 8707 resource identifier:
+// an absolute URI without a fragment.
+func (s *ResourceService) lookup(ctx context.Context, resource string) (*models.ResourceServer, error) {
+	if err := validateResourceIdentifier(resource); err != nil {
+		return nil, ErrInvalidTarget
+	}
+	server, err := s.store.GetByIdentifier(ctx, resource)
+	if err != nil {
+		if errors.Is(err, utils.ErrNotFound) {
+			return nil, ErrInvalidTarget
+		}
+		return nil, err
+	}
+	return server, nil
+}
+
+// ListResourceServers retrieves every registered resource server.
+func (s *ResourceService) ListResourceServers(ctx context.Context) ([]models.ResourceServer, error) {
+	return s.store.List(ctx)
+}
+
+// GetResourceServer retrieves a resource server by its ID.
+func (s *ResourceService) GetResourceServer(ctx context.Context, id string) (*models.ResourceServer, error) {
+	objID, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, utils.ErrNotFound
+	}
+	return s.store.GetByID(ctx, objID)
+}
+
+// CreateResourceServer registers a new resource server.
+func (s *ResourceService) CreateResourceServer(ctx context.Context, req ResourceServerRequest) (*models.ResourceServer, error) {
+	if err := validateResourceIdentifier(req.Identifier); err != nil {
+		return nil, &utils.AppError{Code: "VALIDATION_ERROR", Message: err.Error(), HTTPStatus: http.StatusBadRequest}
+	}
+	if _, err := s.store.GetByIdentifier(ctx, req.Identifier); err == nil {
+		return nil, ErrResourceServerExists
+	}
+
+	resource := &models.ResourceServer{
+		Identifier: req.Identifier,
+		Name:       req.Name,
+	}
+	if err := s.store.Create(ctx, resource); err != nil {
+		return nil, err
+	}
+	return resource, nil
+}
+
+// UpdateResourceServer changes the identifier and name of a resource server. Scopes
+// keep referring to the old identifier until they are updated too.
+func (s *ResourceService) UpdateResourceServer(ctx context.Context, id string, req ResourceServerRequest) (*models.ResourceServer, error) {
+	resource, err := s.GetResourceServer(ctx, id)
+	if err != nil {
+		return nil, err // Will be ErrNotFound if it doesn't exist
+	}
+	if err := validateResourceIdentifier(req.Identifier); err != nil {
+		return nil, &utils.AppError{Code: "VALIDATION_ERROR", Message: err.Error(), HTTPStatus: http.StatusBadRequest}
+	}
+	if existing, err := s.store.GetByIdentifier(ctx, req.Identifier); err == nil && existing.ID != resource.ID {
+		return nil, ErrResourceServerExists
+	}
+
+	resource.Identifier = req.Identifier
+	resource.Name = req.Name
+	if err := s.store.Update(ctx, resource); err != nil {
+		return nil, err
+	}
+	return resource, nil
+}
+
+// DeleteResourceServer removes a resource server from the registry. Tokens already issued
+// for it remain valid until they expire.
+func (s *ResourceService) DeleteResourceServer(ctx context.Context, id string) error {
+	objID, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return utils.ErrNotFound
+	}
+	return s.store.Delete(ctx, objID)
+}
+
+// OwnedScopes returns the registered scopes owned by a resource server.
+func (s *ResourceService) OwnedScopes(resource *models.ResourceServer) []string {
+	return s.scopeService.ScopesOf(resource.Identifier)
+}
+
+// validateResourceIdentifier checks that a resource identifier is an absolute URI without
+// a fragment, as RFC 8707 section 2 requires.
+func validateResourceIdentifier(identifier string) error {
+	u, err := url.Parse(identifier)
+	if err != nil || !u.IsAbs() {
+		return fmt.Errorf("resource identifier %q must be an absolute URI", identifier)
+	}
+	if strings.Contains(identifier, "#") {
+		return fmt.Errorf("resource identifier %q must not contain a fragment", identifier)
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/aminshahid573/authexa/internal/models"
+	"github.com/aminshahid573/authexa/internal/utils"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// MockResourceServerStore is an in-memory implementation of the storage.ResourceServerStore interface.
+type MockResourceServerStore struct {
+	Servers []*models.ResourceServer
+}
+
+func (m *MockResourceServerStore) GetByID(ctx context.Context, id bson.ObjectID) (*models.ResourceServer, error) {
+	for _, server := range m.Servers {
+		if server.ID == id {
+			return server, nil
+		}
+	}
+	return nil, utils.ErrNotFound
+}
+
+func (m *MockResourceServerStore) GetByIdentifier(ctx context.Context, identifier string) (*models.ResourceServer, error) {
+	for _, server := range m.Servers {
+		if server.Identifier == identifier {
+			return server, nil
+		}
+	}
+	return nil, utils.ErrNotFound
+}
+
+func (m *MockResourceServerStore) Create(ctx context.Context, resource *models.ResourceServer) error {
+	m.Servers = append(m.Servers, resource)
+	return nil
+}
+
+func (m *MockResourceServerStore) List(ctx context.Context) ([]models.ResourceServer, error) {
+	var servers []models.ResourceServer
+	for _, server := range m.Servers {
+		servers = append(servers, *server)
+	}
+	return servers, nil
+}
+
+func (m *MockResourceServerStore) Update(ctx context.Context, resource *models.ResourceServer) error {
+	return nil
+}
+
+func (m *MockResourceServerStore) Delete(ctx context.Context, id bson.ObjectID) error {
+	return nil
+}
+
+// TestResourceService_Target tests choosing the audience of an access token and narrowing
+// its scopes to the ones the resource server owns.
+func TestResourceService_Target(t *testing.T) {
+	ctx := context.Background()
+	const (
+		api   = "https://api.example.com"
+		other = "https://other.example.com"
+	)
+	scopeService := NewScopeService(&MockScopeStore{Scopes: []models.Scope{
+		{Name: "openid"},
+		{Name: "api:read", API: api},
+		{Name: "other:read", API: other},
+	}})
+	if err := scopeService.Reload(ctx); err != nil {
+		t.Fatalf("failed to load scopes: %v", err)
+	}
+	resourceService := NewResourceService(&MockResourceServerStore{Servers: []*models.ResourceServer{
+		{ID: bson.NewObjectID(), Identifier: api},
+		{ID: bson.NewObjectID(), Identifier: other},
+	}}, scopeService)
+	scopes := []string{"openid", "api:read"}
+
+	tests := []struct {
+		name         string
+		requested    []string
+		granted      []string
+		scopes       []string
+		wantAudience string
+		wantScopes   []string
+		wantCode     string
+	}{
+		{name: "No Resource", scopes: scopes, wantScopes: scopes},
+		{name: "Requested Resource", requested: []string{api}, scopes: scopes, wantAudience: api, wantScopes: []string{"api:read"}},
+		{name: "Granted Resource", granted: []string{api}, scopes: scopes, wantAudience: api, wantScopes: []string{"api:read"}},
+		{name: "Resource Outside Grant", requested: []string{other}, granted: []string{api}, scopes: scopes, wantCode: "invalid_target"},
+		{name: "Unknown Resource", requested: []string{"https://unknown.example.com"}, scopes: scopes, wantCode: "invalid_target"},
+		{name: "No Scopes For Resource", requested: []string{other}, scopes: scopes, wantCode: "invalid_scope"},
+		{name: "No Scopes At All", requested: []string{api}, wantCode: "invalid_scope"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			audience, narrowed, err := resourceService.Target(ctx, tt.requested, tt.granted, tt.scopes)
+			if tt.wantCode != "" {
+				var appErr *utils.AppError
+				if !errors.As(err, &appErr) || appErr.Code != tt.wantCode {
+					t.Fatalf("expected a %s error, but got: %v", tt.wantCode, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, but got: %v", err)
+			}
+			if audience != tt.wantAudience || !slices.Equal(narrowed, tt.wantScopes) {
+				t.Errorf("expected audience %q with scopes %v, but got %q with %v", tt.wantAudience, tt.wantScopes, audience, narrowed)
+			}
+		})
+	}
+}
+
+// TestResourceService_ExchangeTarget tests the audience and scopes of exchanged tokens.
+func TestResourceService_ExchangeTarget(t *testing.T) {
+	ctx := context.Background()
+	const api = "https://api.example.com"
+	scopeService := NewScopeService(&MockScopeStore{Scopes: []models.Scope{
+		{Name: "openid"},
+		{Name: "api:read", API: api},
+	}})
+	if err := scopeService.Reload(ctx); err != nil {
+		t.Fatalf("failed to load scopes: %v", err)
+	}
+	resourceService := NewResourceService(&MockResourceServerStore{Servers: []*models.ResourceServer{
+		{ID: bson.NewObjectID(), Identifier: api},
+	}}, scopeService)
+
+	tests := []struct {
+		name         string
+		resources    []string
+		audiences    []string
+		scopes       []string
+		wantAudience string
+		wantScopes   []string
+		wantErr      bool
+	}{
+		{name: "Registered Audience", audiences: []string{api}, scopes: []string{"openid", "api:read"}, wantAudience: api, wantScopes: []string{"api:read"}},
+		{name: "Logical Audience", audiences: []string{"client-2"}, scopes: []string{"openid"}, wantAudience: "client-2", wantScopes: []string{"openid"}},
+		{name: "Registered Audience Without Scopes", audiences: []string{api}, scopes: []string{"openid"}, wantErr: true},
+		{name: "Resource Without Scopes", resources: []string{api}, scopes: []string{"openid"}, wantErr: true},
+		{name: "Resource And Audience", resources: []string{api}, audiences: []string{"client-2"}, scopes: []string{"api:read"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			audience, narrowed, err := resourceService.ExchangeTarget(ctx, tt.resources, tt.audiences, tt.scopes)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error, but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, but got: %v", err)
+			}
+			if audience != tt.wantAudience || !slices.Equal(narrowed, tt.wantScopes) {
+				t.Errorf("expected audience %q with scopes %v, but got %q with %v", tt.wantAudience, tt.wantScopes, audience, narrowed)
+			}
+		})
+	}
+}
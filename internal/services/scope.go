@@ -1,34 +1,204 @@
 package services
 
-import "github.com/aminshahid573/authexa/internal/models"
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 
-// ScopeService provides logic for managing OAuth2 scopes.
+	"github.com/aminshahid573/authexa/internal/models"
+	"github.com/aminshahid573/authexa/internal/storage"
+	"github.com/aminshahid573/authexa/internal/utils"
+)
+
+// scopeReloadInterval is how often the registry is reloaded from storage, so that every
+// replica picks up changes made through another one.
+const scopeReloadInterval = time.Minute
+
+// maxScopeImplicationDepth bounds how many implication steps Covers follows, so that a
+// cycle in the registry cannot recurse forever.
+const maxScopeImplicationDepth = 8
+
+// scopePlaceholder matches a {parameter} reference in a scope template.
+var scopePlaceholder = regexp.MustCompile(`\{([A-Za-z][A-Za-z0-9_]*)\}`)
+
+// scopeParameterPatterns are the values accepted for each type of template parameter.
+// String values cannot contain the ":" separator, so a parameter matches a single segment,
+// nor "*", so that a template instance can never act as a wildcard over other instances.
+var scopeParameterPatterns = map[string]string{
+	models.ScopeParameterString:  `[^\s:{}*]+`,
+	models.ScopeParameterInteger: `[0-9]+`,
+	models.ScopeParameterUUID:    `[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`,
+}
+
+// defaultScopes seed the registry on first start.
+var defaultScopes = []models.Scope{
+	{Name: "openid", Description: "Access your user identifier.", Claims: []string{"sub"}, ConsentRequired: true},
+	{Name: "profile", Description: "Read your basic profile information.", Claims: []string{"name", "preferred_username"}, ConsentRequired: true},
+	{Name: "email", Description: "Access your email address.", Claims: []string{"email", "email_verified"}, ConsentRequired: true},
+	{Name: "offline", Description: "Allow the application to refresh tokens.", ConsentRequired: true},
+}
+
+// ErrScopeExists is returned when creating a scope whose name is already registered.
+var ErrScopeExists = &utils.AppError{Code: "SCOPE_EXISTS", Message: "A scope with this name already exists.", HTTPStatus: http.StatusConflict}
+
+// CreateScopeRequest is the admin API payload for registering a scope. ConsentRequired
+// defaults to true, so that granting a scope without consent is always a deliberate choice.
+type CreateScopeRequest struct {
+	Name            string   `json:"name" validate:"required"`
+	Description     string   `json:"description" validate:"required"`
+	API             string   `json:"api"`
+	Claims          []string `json:"claims"`
+	ConsentRequired *bool    `json:"consent_required"`
+	Default         bool     `json:"default"`
+
+	Parameters []models.ScopeParameter `json:"parameters"`
+	Implies    []string                `json:"implies"`
+}
+
+// UpdateScopeRequest is the admin API payload for changing a scope. The name cannot change,
+// and ConsentRequired defaults to true as when creating a scope.
+type UpdateScopeRequest struct {
+	Description     string   `json:"description" validate:"required"`
+	API             string   `json:"api"`
+	Claims          []string `json:"claims"`
+	ConsentRequired *bool    `json:"consent_required"`
+	Default         bool     `json:"default"`
+
+	Parameters []models.ScopeParameter `json:"parameters"`
+	Implies    []string                `json:"implies"`
+}
+
+// ScopeService provides logic for managing OAuth2 scopes. The registry is stored in the
+// database and cached in memory, as it is read on every authorization request.
 type ScopeService struct {
-	// For now, we use a simple map. This could be backed by a database.
-	availableScopes map[string]string
+	store storage.ScopeStore
+
+	mu        sync.RWMutex
+	scopes    map[string]models.Scope
+	templates []scopeTemplate
+}
+
+// scopeTemplate is a parameterized scope with the pattern matching its instances.
+type scopeTemplate struct {
+	scope   models.Scope
+	pattern *regexp.Regexp
 }
 
 // NewScopeService creates a new ScopeService.
-func NewScopeService() *ScopeService {
+func NewScopeService(store storage.ScopeStore) *ScopeService {
 	return &ScopeService{
-		availableScopes: map[string]string{
-			"openid":  "Access your user identifier.",
-			"profile": "Read your basic profile information.",
-			"email":   "Access your email address.",
-			"offline": "Allow the application to refresh tokens.",
-		},
+		store:  store,
+		scopes: map[string]models.Scope{},
+	}
+}
+
+// Initialize seeds an empty registry with the standard scopes, then loads it.
+func (s *ScopeService) Initialize(ctx context.Context) error {
+	scopes, err := s.store.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(scopes) == 0 {
+		for _, scope := range defaultScopes {
+			if err := s.store.Create(ctx, &scope); err != nil {
+				return err
+			}
+		}
+		slog.Info("scope registry seeded", "scopes", len(defaultScopes))
+	}
+	return s.Reload(ctx)
+}
+
+// Reload reads the registry from storage and replaces the cached scopes.
+func (s *ScopeService) Reload(ctx context.Context) error {
+	scopes, err := s.store.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	registry := make(map[string]models.Scope, len(scopes))
+	var templates []scopeTemplate
+	for _, scope := range scopes {
+		registry[scope.Name] = scope
+		if len(scope.Parameters) == 0 {
+			continue
+		}
+		pattern, err := compileScopeTemplate(scope.Name, scope.Parameters)
+		if err != nil {
+			slog.Error("skipping invalid scope template", "scope", scope.Name, "error", err)
+			continue
+		}
+		templates = append(templates, scopeTemplate{scope: scope, pattern: pattern})
+	}
+	slices.SortFunc(templates, func(a, b scopeTemplate) int {
+		return strings.Compare(a.scope.Name, b.scope.Name)
+	})
+
+	s.mu.Lock()
+	s.scopes = registry
+	s.templates = templates
+	s.mu.Unlock()
+	return nil
+}
+
+// Run reloads the registry periodically. It blocks until ctx is cancelled.
+func (s *ScopeService) Run(ctx context.Context) {
+	ticker := time.NewTicker(scopeReloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Reload(ctx); err != nil {
+				slog.Error("failed to reload scopes", "error", err)
+			}
+		}
+	}
+}
+
+// resolve finds the registered scope a requested name refers to: the scope of that name,
+// or the template it instantiates along with the parameter values. The caller must hold mu.
+func (s *ScopeService) resolve(name string) (models.Scope, map[string]string, bool) {
+	if scope, ok := s.scopes[name]; ok && len(scope.Parameters) == 0 {
+		return scope, nil, true
+	}
+	for _, t := range s.templates {
+		match := t.pattern.FindStringSubmatch(name)
+		if match == nil {
+			continue
+		}
+		params := make(map[string]string, len(t.scope.Parameters))
+		for i, param := range t.pattern.SubexpNames() {
+			if param != "" {
+				params[param] = match[i]
+			}
+		}
+		return t.scope, params, true
 	}
+	return models.Scope{}, nil, false
 }
 
-// GetScopeDetails retrieves the details for a list of scope names.
+// GetScopeDetails retrieves the details for a list of scope names. Instances of a
+// template are named as requested, with their parameters rendered in the description.
 func (s *ScopeService) GetScopeDetails(scopeNames []string) []models.Scope {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
 	var details []models.Scope
 	for _, name := range scopeNames {
-		if description, ok := s.availableScopes[name]; ok {
-			details = append(details, models.Scope{
-				Name:        name,
-				Description: description,
-			})
+		if scope, params, ok := s.resolve(name); ok {
+			scope.Name = name
+			scope.Description = renderScopeTemplate(scope.Description, params)
+			details = append(details, scope)
 		}
 	}
 	return details
@@ -36,10 +206,336 @@ func (s *ScopeService) GetScopeDetails(scopeNames []string) []models.Scope {
 
 // ValidateScopes checks if all requested scopes are valid.
 func (s *ScopeService) ValidateScopes(requestedScopes []string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
 	for _, rs := range requestedScopes {
-		if _, ok := s.availableScopes[rs]; !ok {
+		if _, _, ok := s.resolve(rs); !ok {
 			return false // Found an invalid scope
 		}
 	}
 	return true
 }
+
+// RequiresConsent reports whether the user must approve a scope. Unknown scopes do.
+func (s *ScopeService) RequiresConsent(name string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	scope, _, ok := s.resolve(name)
+	return !ok || scope.ConsentRequired
+}
+
+// Covers reports whether a set of granted scopes includes scope: by name, through a
+// wildcard such as "api:*", or through the scopes a granted scope implies.
+func (s *ScopeService) Covers(granted []string, scope string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, g := range granted {
+		if s.covers(g, scope, 0) {
+			return true
+		}
+	}
+	return false
+}
+
+// CoversAll reports whether a set of granted scopes includes every requested scope.
+func (s *ScopeService) CoversAll(granted, requested []string) bool {
+	for _, scope := range requested {
+		if !s.Covers(granted, scope) {
+			return false
+		}
+	}
+	return true
+}
+
+// covers reports whether a single granted scope includes scope. The caller must hold mu.
+func (s *ScopeService) covers(granted, scope string, depth int) bool {
+	if granted == scope {
+		return true
+	}
+	if prefix, ok := strings.CutSuffix(granted, "*"); ok && strings.HasPrefix(scope, prefix) {
+		// A wildcard only covers scopes that exist, not any name sharing its prefix.
+		_, _, ok := s.resolve(scope)
+		return ok
+	}
+	if depth == maxScopeImplicationDepth {
+		return false
+	}
+
+	grantedScope, params, ok := s.resolve(granted)
+	if !ok {
+		return false
+	}
+	for _, implied := range grantedScope.Implies {
+		if s.covers(renderScopeTemplate(implied, params), scope, depth+1) {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultScopes returns the sorted names of the scopes granted to requests that name none.
+func (s *ScopeService) DefaultScopes() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var names []string
+	for name, scope := range s.scopes {
+		if scope.Default && len(scope.Parameters) == 0 {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// ReleasedClaims returns the de-duplicated user claims released by a set of granted scopes.
+func (s *ScopeService) ReleasedClaims(scopeNames []string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var claims []string
+	for _, name := range scopeNames {
+		scope, _, _ := s.resolve(name)
+		for _, c := range scope.Claims {
+			if !slices.Contains(claims, c) {
+				claims = append(claims, c)
+			}
+		}
+	}
+	return claims
+}
+
+// OwnedBy returns the scopes owned by an API, out of a set of scopes.
+func (s *ScopeService) OwnedBy(api string, scopeNames []string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var owned []string
+	for _, name := range scopeNames {
+		if scope, _, ok := s.resolve(name); ok && scope.API == api {
+			owned = append(owned, name)
+		}
+	}
+	return owned
+}
+
+// ScopesOf returns the sorted names of the registered scopes owned by an API.
+func (s *ScopeService) ScopesOf(api string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	names := []string{}
+	for name, scope := range s.scopes {
+		if scope.API == api {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// SupportedScopes returns the sorted names of the registered scopes. Templates are left
+// out, as their names are not scopes that can be requested as such.
+func (s *ScopeService) SupportedScopes() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	names := make([]string, 0, len(s.scopes))
+	for name, scope := range s.scopes {
+		if len(scope.Parameters) == 0 {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// SupportedClaims returns the sorted, de-duplicated set of claims released by all scopes.
+func (s *ScopeService) SupportedClaims() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var claims []string
+	for _, scope := range s.scopes {
+		for _, c := range scope.Claims {
+			if !slices.Contains(claims, c) {
+				claims = append(claims, c)
+			}
+		}
+	}
+	slices.Sort(claims)
+	return claims
+}
+
+// ListScopes retrieves every registered scope.
+func (s *ScopeService) ListScopes(ctx context.Context) ([]models.Scope, error) {
+	return s.store.List(ctx)
+}
+
+// GetScope retrieves a registered scope by name.
+func (s *ScopeService) GetScope(ctx context.Context, name string) (*models.Scope, error) {
+	return s.store.GetByName(ctx, name)
+}
+
+// CreateScope registers a new scope.
+func (s *ScopeService) CreateScope(ctx context.Context, req CreateScopeRequest) (*models.Scope, error) {
+	if !isScopeToken(req.Name) {
+		return nil, &utils.AppError{Code: "VALIDATION_ERROR", Message: "The scope name may only contain printable ASCII characters other than space, double quote and backslash.", HTTPStatus: http.StatusBadRequest}
+	}
+	if err := validateScopeDefinition(req.Name, req.Parameters, req.Implies); err != nil {
+		return nil, err
+	}
+	if _, err := s.store.GetByName(ctx, req.Name); err == nil {
+		return nil, ErrScopeExists
+	}
+
+	scope := &models.Scope{
+		Name:            req.Name,
+		Description:     req.Description,
+		API:             req.API,
+		Claims:          req.Claims,
+		ConsentRequired: consentRequired(req.ConsentRequired),
+		Default:         req.Default,
+		Parameters:      req.Parameters,
+		Implies:         req.Implies,
+	}
+	if err := s.store.Create(ctx, scope); err != nil {
+		return nil, err
+	}
+	return scope, s.Reload(ctx)
+}
+
+// consentRequired returns the consent_required flag of a scope request, which is true unless
+// explicitly disabled.
+func consentRequired(flag *bool) bool {
+	return flag == nil || *flag
+}
+
+// UpdateScope changes the description, owning API, claims, flags, parameters and
+// implied scopes of a scope.
+func (s *ScopeService) UpdateScope(ctx context.Context, name string, req UpdateScopeRequest) (*models.Scope, error) {
+	scope, err := s.store.GetByName(ctx, name)
+	if err != nil {
+		return nil, err // Will be ErrNotFound if it doesn't exist
+	}
+	if err := validateScopeDefinition(name, req.Parameters, req.Implies); err != nil {
+		return nil, err
+	}
+
+	scope.Description = req.Description
+	scope.API = req.API
+	scope.Claims = req.Claims
+	scope.ConsentRequired = consentRequired(req.ConsentRequired)
+	scope.Default = req.Default
+	scope.Parameters = req.Parameters
+	scope.Implies = req.Implies
+	if err := s.store.Update(ctx, scope); err != nil {
+		return nil, err
+	}
+	return scope, s.Reload(ctx)
+}
+
+// DeleteScope removes a scope from the registry. Tokens already issued with it keep it.
+func (s *ScopeService) DeleteScope(ctx context.Context, name string) error {
+	if err := s.store.Delete(ctx, name); err != nil {
+		return err
+	}
+	return s.Reload(ctx)
+}
+
+// isScopeToken reports whether name is a valid RFC 6749 scope-token.
+func isScopeToken(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, c := range name {
+		if c < 0x21 || c > 0x7e || c == '"' || c == '\\' {
+			return false
+		}
+	}
+	return true
+}
+
+// validateScopeDefinition checks the template parameters and implied scopes of a scope.
+func validateScopeDefinition(name string, params []models.ScopeParameter, implies []string) error {
+	if len(params) > 0 {
+		if _, err := compileScopeTemplate(name, params); err != nil {
+			return &utils.AppError{Code: "VALIDATION_ERROR", Message: fmt.Sprintf("Invalid scope template: %v.", err), HTTPStatus: http.StatusBadRequest}
+		}
+	} else if scopePlaceholder.MatchString(name) {
+		return &utils.AppError{Code: "VALIDATION_ERROR", Message: "The scope name references parameters that are not declared.", HTTPStatus: http.StatusBadRequest}
+	}
+
+	for _, implied := range implies {
+		if !isScopeToken(implied) || implied == name {
+			return &utils.AppError{Code: "VALIDATION_ERROR", Message: fmt.Sprintf("The implied scope %q is invalid.", implied), HTTPStatus: http.StatusBadRequest}
+		}
+		for _, match := range scopePlaceholder.FindAllStringSubmatch(implied, -1) {
+			if !slices.ContainsFunc(params, func(p models.ScopeParameter) bool { return p.Name == match[1] }) {
+				return &utils.AppError{Code: "VALIDATION_ERROR", Message: fmt.Sprintf("The implied scope %q references an undeclared parameter.", implied), HTTPStatus: http.StatusBadRequest}
+			}
+		}
+	}
+	return nil
+}
+
+// compileScopeTemplate builds the pattern matching the instances of a scope template. Every
+// declared parameter must appear exactly once in the name, and no other may.
+func compileScopeTemplate(name string, params []models.ScopeParameter) (*regexp.Regexp, error) {
+	patterns := make(map[string]string, len(params))
+	for _, p := range params {
+		pattern, ok := scopeParameterPatterns[p.Type]
+		if !ok {
+			return nil, fmt.Errorf("parameter %q has unsupported type %q", p.Name, p.Type)
+		}
+		if _, dup := patterns[p.Name]; dup {
+			return nil, fmt.Errorf("parameter %q is declared more than once", p.Name)
+		}
+		patterns[p.Name] = pattern
+	}
+
+	var b strings.Builder
+	b.WriteString("^")
+	used := map[string]bool{}
+	last := 0
+	for _, loc := range scopePlaceholder.FindAllStringSubmatchIndex(name, -1) {
+		param := name[loc[2]:loc[3]]
+		pattern, ok := patterns[param]
+		if !ok {
+			return nil, fmt.Errorf("parameter %q is not declared", param)
+		}
+		if used[param] {
+			return nil, fmt.Errorf("parameter %q appears more than once", param)
+		}
+		used[param] = true
+		b.WriteString(regexp.QuoteMeta(name[last:loc[0]]))
+		fmt.Fprintf(&b, "(?P<%s>%s)", param, pattern)
+		last = loc[1]
+	}
+	b.WriteString(regexp.QuoteMeta(name[last:]))
+	b.WriteString("$")
+
+	for param := range patterns {
+		if !used[param] {
+			return nil, fmt.Errorf("parameter %q does not appear in the name", param)
+		}
+	}
+	return regexp.Compile(b.String())
+}
+
+// renderScopeTemplate substitutes parameter values for their {parameter} references.
+// References to unknown parameters are left as they are.
+func renderScopeTemplate(text string, params map[string]string) string {
+	if len(params) == 0 {
+		return text
+	}
+	return scopePlaceholder.ReplaceAllStringFunc(text, func(ref string) string {
+		if value, ok := params[ref[1:len(ref)-1]]; ok {
+			return value
+		}
+		return ref
+	})
+}
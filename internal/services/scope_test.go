@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aminshahid573/authexa/internal/models"
+	"github.com/aminshahid573/authexa/internal/utils"
+)
+
+// MockScopeStore is an in-memory implementation of the storage.ScopeStore interface.
+type MockScopeStore struct {
+	Scopes []models.Scope
+}
+
+func (m *MockScopeStore) GetByName(ctx context.Context, name string) (*models.Scope, error) {
+	for _, scope := range m.Scopes {
+		if scope.Name == name {
+			return &scope, nil
+		}
+	}
+	return nil, utils.ErrNotFound
+}
+
+func (m *MockScopeStore) Create(ctx context.Context, scope *models.Scope) error {
+	m.Scopes = append(m.Scopes, *scope)
+	return nil
+}
+
+func (m *MockScopeStore) List(ctx context.Context) ([]models.Scope, error) {
+	return m.Scopes, nil
+}
+
+func (m *MockScopeStore) Update(ctx context.Context, scope *models.Scope) error {
+	return nil
+}
+
+func (m *MockScopeStore) Delete(ctx context.Context, name string) error {
+	return nil
+}
+
+// newTestScopeService returns a ScopeService loaded with a registry of plain, wildcard,
+// templated and implied scopes.
+func newTestScopeService(t *testing.T) *ScopeService {
+	t.Helper()
+	folder := []models.ScopeParameter{{Name: "folderId", Type: models.ScopeParameterString}}
+	store := &MockScopeStore{Scopes: []models.Scope{
+		{Name: "openid", Default: true},
+		{Name: "profile", ConsentRequired: true},
+		{Name: "api:read"},
+		{Name: "api:write", Implies: []string{"api:read"}},
+		{Name: "documents:read:{folderId}", Parameters: folder},
+		{Name: "documents:write:{folderId}", Parameters: folder, Implies: []string{"documents:read:{folderId}"}},
+		{Name: "loop:a", Implies: []string{"loop:b"}},
+		{Name: "loop:b", Implies: []string{"loop:a"}},
+	}}
+	scopeService := NewScopeService(store)
+	if err := scopeService.Reload(context.Background()); err != nil {
+		t.Fatalf("failed to load scopes: %v", err)
+	}
+	return scopeService
+}
+
+// TestScopeService_Covers tests matching a requested scope against granted scopes by name,
+// wildcard and implication.
+func TestScopeService_Covers(t *testing.T) {
+	scopeService := newTestScopeService(t)
+
+	tests := []struct {
+		name    string
+		granted []string
+		scope   string
+		want    bool
+	}{
+		{name: "Exact Match", granted: []string{"openid", "profile"}, scope: "profile", want: true},
+		{name: "Not Granted", granted: []string{"openid"}, scope: "profile", want: false},
+		{name: "Nothing Granted", granted: nil, scope: "openid", want: false},
+		{name: "Wildcard", granted: []string{"api:*"}, scope: "api:write", want: true},
+		{name: "Wildcard Over Unregistered Scope", granted: []string{"api:*"}, scope: "api:delete", want: false},
+		{name: "Wildcard Over Template Instance", granted: []string{"documents:*"}, scope: "documents:read:f1", want: true},
+		{name: "Implied Scope", granted: []string{"api:write"}, scope: "api:read", want: true},
+		{name: "Implication Is One Way", granted: []string{"api:read"}, scope: "api:write", want: false},
+		{name: "Template Instance", granted: []string{"documents:read:f1"}, scope: "documents:read:f1", want: true},
+		{name: "Other Template Instance", granted: []string{"documents:read:f1"}, scope: "documents:read:f2", want: false},
+		{name: "Implied Template Instance", granted: []string{"documents:write:f1"}, scope: "documents:read:f1", want: true},
+		{name: "Implication Keeps Parameters", granted: []string{"documents:write:f1"}, scope: "documents:read:f2", want: false},
+		{name: "Implication Cycle", granted: []string{"loop:a"}, scope: "openid", want: false},
+		{name: "Implication Through Cycle", granted: []string{"loop:a"}, scope: "loop:b", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := scopeService.Covers(tt.granted, tt.scope); got != tt.want {
+				t.Errorf("expected Covers(%v, %q) to be %v, but got: %v", tt.granted, tt.scope, tt.want, got)
+			}
+		})
+	}
+}
+
+// TestScopeService_CoversAll tests that every requested scope must be covered.
+func TestScopeService_CoversAll(t *testing.T) {
+	scopeService := newTestScopeService(t)
+
+	tests := []struct {
+		name      string
+		granted   []string
+		requested []string
+		want      bool
+	}{
+		{name: "All Covered", granted: []string{"openid", "api:write"}, requested: []string{"openid", "api:read", "api:write"}, want: true},
+		{name: "One Missing", granted: []string{"openid", "api:read"}, requested: []string{"openid", "api:write"}, want: false},
+		{name: "Nothing Requested", granted: nil, requested: nil, want: true},
+		{name: "Wildcard Covers Instances", granted: []string{"documents:*"}, requested: []string{"documents:read:f1", "documents:write:f2"}, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := scopeService.CoversAll(tt.granted, tt.requested); got != tt.want {
+				t.Errorf("expected CoversAll(%v, %v) to be %v, but got: %v", tt.granted, tt.requested, tt.want, got)
+			}
+		})
+	}
+}
+
+// TestScopeService_ValidateScopes tests that requested scopes must be registered or
+// instantiate a registered template with a valid value.
+func TestScopeService_ValidateScopes(t *testing.T) {
+	scopeService := newTestScopeService(t)
+
+	tests := []struct {
+		name      string
+		requested []string
+		want      bool
+	}{
+		{name: "Registered Scopes", requested: []string{"openid", "api:read"}, want: true},
+		{name: "Unknown Scope", requested: []string{"openid", "admin"}, want: false},
+		{name: "Template Instance", requested: []string{"documents:read:f1"}, want: true},
+		{name: "Template Itself", requested: []string{"documents:read:{folderId}"}, want: false},
+		{name: "Wildcard Parameter", requested: []string{"documents:read:*"}, want: false},
+		{name: "Partial Wildcard Parameter", requested: []string{"documents:read:f*"}, want: false},
+		{name: "Parameter With Separator", requested: []string{"documents:read:f1:f2"}, want: false},
+		{name: "Empty Parameter", requested: []string{"documents:read:"}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := scopeService.ValidateScopes(tt.requested); got != tt.want {
+				t.Errorf("expected ValidateScopes(%v) to be %v, but got: %v", tt.requested, tt.want, got)
+			}
+		})
+	}
+}
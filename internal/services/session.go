@@ -23,17 +23,23 @@ func NewSessionService(sessionStore storage.SessionStore) *SessionService {
 	return &SessionService{sessionStore: sessionStore}
 }
 
-// CreateSession creates a new login session for a user.
-func (s *SessionService) CreateSession(ctx context.Context, userID bson.ObjectID) (*models.Session, error) {
+// CreateSession creates a new login session for a user, recording the authentication
+// methods (RFC 8176 "amr" values) the user just completed and the authentication context
+// class ("acr") they satisfy.
+func (s *SessionService) CreateSession(ctx context.Context, userID bson.ObjectID, amr []string, acr string) (*models.Session, error) {
 	sessionID, err := utils.GenerateSecureToken(32)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate session ID: %w", err)
 	}
 
+	now := time.Now()
 	session := &models.Session{
 		ID:        sessionID,
 		UserID:    userID,
-		ExpiresAt: time.Now().Add(SessionLifespan),
+		AuthTime:  now,
+		AMR:       amr,
+		ACR:       acr,
+		ExpiresAt: now.Add(SessionLifespan),
 	}
 
 	if err := s.sessionStore.Save(ctx, session); err != nil {
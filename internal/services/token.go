@@ -7,35 +7,42 @@ import (
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"net/url"
 	"strings"
 	"time"
 
 	"github.com/aminshahid573/authexa/internal/models"
 	"github.com/aminshahid573/authexa/internal/storage"
 	"github.com/aminshahid573/authexa/internal/utils"
+	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/v2/bson"
 )
 
 // TODO:: load from config , already defined in config
 const (
-	AuthCodeLifespan      = 10 * time.Minute
-	RefreshTokenLifespan  = 30 * 24 * time.Hour // 30 days
-	DeviceCodeLifespan    = 15 * time.Minute
-	PKCEChallengeLifespan = 10 * time.Minute
+	AuthCodeLifespan     = 10 * time.Minute
+	RefreshTokenLifespan = 30 * 24 * time.Hour // 30 days
+	DeviceCodeLifespan   = 15 * time.Minute
 )
 
 // TokenService provides business logic for creating and managing tokens.
 type TokenService struct {
 	jwtManager *utils.JWTManager
 	tokenStore storage.TokenStore
-	pkceStore  storage.PKCEStore
+	denylist   storage.TokenDenylist
+	userStore  storage.UserStore
 }
 
+// ErrTokenRevoked is returned when a cryptographically valid access token has been revoked.
+var ErrTokenRevoked = errors.New("token has been revoked")
+
 // NewTokenService creates a new TokenService.
-func NewTokenService(jwtManager *utils.JWTManager, tokenStore storage.TokenStore, pkceStore storage.PKCEStore) *TokenService {
+func NewTokenService(jwtManager *utils.JWTManager, tokenStore storage.TokenStore, denylist storage.TokenDenylist, userStore storage.UserStore) *TokenService {
 	return &TokenService{
 		jwtManager: jwtManager,
 		tokenStore: tokenStore,
-		pkceStore:  pkceStore,
+		denylist:   denylist,
+		userStore:  userStore,
 	}
 }
 
@@ -51,31 +58,288 @@ func (s *TokenService) GetAccessTokenLifespan() time.Duration {
 	return s.jwtManager.GetAccessTokenLifespan() // Assumes JWTManager exposes this
 }
 
+// GetIssuer returns the issuer identifier of the tokens this service mints.
+func (s *TokenService) GetIssuer() string {
+	return s.jwtManager.GetIssuer()
+}
+
 // --- Token Generation ---
 
-// GenerateAccessToken creates a new JWT access token.
-func (s *TokenService) GenerateAccessToken(userID, clientID string, scopes []string) (string, error) {
-	return s.jwtManager.GenerateAccessToken(userID, clientID, scopes)
+// AccessTokenRequest describes an access token to be minted.
+type AccessTokenRequest struct {
+	UserID   string
+	ClientID string
+	Scopes   []string
+	// GrantID is the refresh token family the token is minted from, if any.
+	// Tokens minted from a grant are revoked together with it.
+	GrantID string
+	// SigningAlg is the client's chosen JWS algorithm. Empty selects the default.
+	SigningAlg string
+	// Audience is the resource server the token is minted for (RFC 8707). Empty selects the client.
+	Audience string
+	// AuthTime, AMR and ACR describe how the user authenticated, for grants that involve one.
+	AuthTime time.Time
+	AMR      []string
+	ACR      string
+	// Format is the client's access token format (see models.AccessTokenFormat*). Empty selects JWT.
+	Format string
+	// Act is the delegation chain of a token obtained by token exchange.
+	Act *models.Actor
+	// ExpiresAt is when the token expires, for tokens that must not outlive another one.
+	// Zero selects the access token lifespan.
+	ExpiresAt time.Time
+}
+
+// AccessTokenExpiresAt returns when an access token minted now expires, if it may not
+// outlive notAfter. A zero notAfter does not limit the access token lifespan.
+func (s *TokenService) AccessTokenExpiresAt(notAfter time.Time) time.Time {
+	expiresAt := time.Now().Add(s.jwtManager.GetAccessTokenLifespan())
+	if !notAfter.IsZero() && notAfter.Before(expiresAt) {
+		return notAfter
+	}
+	return expiresAt
+}
+
+// GenerateAccessToken creates a new access token in the requested format. JWT access tokens
+// follow RFC 9068, and carry the user's role in the "roles" claim when that is enabled.
+func (s *TokenService) GenerateAccessToken(ctx context.Context, req AccessTokenRequest) (string, error) {
+	if req.Format == models.AccessTokenFormatReference {
+		return s.generateReferenceToken(ctx, req)
+	}
+	var roles []string
+	if s.jwtManager.AccessTokenRolesClaim() {
+		var err error
+		if roles, err = s.userRoles(ctx, req.UserID); err != nil {
+			return "", err
+		}
+	}
+	token, claims, err := s.jwtManager.GenerateAccessToken(utils.AccessTokenParams{
+		Subject:   req.UserID,
+		ClientID:  req.ClientID,
+		Audience:  req.Audience,
+		Scopes:    req.Scopes,
+		AuthTime:  req.AuthTime,
+		AMR:       req.AMR,
+		ACR:       req.ACR,
+		Roles:     roles,
+		Act:       req.Act,
+		Alg:       req.SigningAlg,
+		ExpiresAt: req.ExpiresAt,
+	})
+	if err != nil {
+		return "", err
+	}
+	if req.GrantID != "" {
+		if err := s.denylist.TrackIssued(ctx, req.GrantID, claims.ID, s.jwtManager.GetAccessTokenLifespan()); err != nil {
+			return "", err
+		}
+	}
+	return token, nil
+}
+
+// generateReferenceToken creates an opaque access token and stores its hash. The handle
+// carries no information, and deleting the stored record revokes it immediately.
+func (s *TokenService) generateReferenceToken(ctx context.Context, req AccessTokenRequest) (string, error) {
+	handle, err := utils.GenerateSecureToken(64)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate access token: %w", err)
+	}
+	expiresAt := req.ExpiresAt
+	if expiresAt.IsZero() {
+		expiresAt = time.Now().Add(s.jwtManager.GetAccessTokenLifespan())
+	}
+	token := &models.Token{
+		Signature: hashToken(handle),
+		ClientID:  req.ClientID,
+		UserID:    req.UserID,
+		Scopes:    req.Scopes,
+		ExpiresAt: expiresAt,
+		Type:      models.TokenTypeAccessToken,
+		AuthTime:  req.AuthTime,
+		AMR:       req.AMR,
+		ACR:       req.ACR,
+		Audience:  req.Audience,
+		GrantID:   req.GrantID,
+		Act:       req.Act,
+	}
+	if err := s.tokenStore.Save(ctx, token); err != nil {
+		return "", fmt.Errorf("failed to store access token: %w", err)
+	}
+	return handle, nil
+}
+
+// userRoles returns the roles of the user identified by subject. Subjects that are not
+// user IDs, such as a client acting on its own behalf, have no roles.
+func (s *TokenService) userRoles(ctx context.Context, subject string) ([]string, error) {
+	id, err := bson.ObjectIDFromHex(subject)
+	if err != nil {
+		return nil, nil
+	}
+	user, err := s.userStore.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, utils.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to look up user roles: %w", err)
+	}
+	if user.Role == "" {
+		return nil, nil
+	}
+	return []string{user.Role}, nil
+}
+
+// VerifyAccessToken validates an access token and checks it against the revocation denylist.
+// Reference tokens are resolved from the token store into the claims a JWT would carry.
+// Every endpoint that accepts access tokens must verify them through this method.
+func (s *TokenService) VerifyAccessToken(ctx context.Context, tokenStr string) (*utils.CustomClaims, error) {
+	// A JWT always has three dot-separated parts, which a reference handle never contains.
+	if !strings.Contains(tokenStr, ".") {
+		return s.resolveReferenceToken(ctx, tokenStr)
+	}
+	claims, err := s.jwtManager.VerifyToken(tokenStr)
+	if err != nil {
+		return nil, err
+	}
+	revoked, err := s.denylist.Contains(ctx, claims.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check token revocation: %w", err)
+	}
+	if revoked {
+		return nil, ErrTokenRevoked
+	}
+	return claims, nil
+}
+
+// resolveReferenceToken looks up a reference access token. Revoked tokens have been deleted
+// from the store, so they are simply not found.
+func (s *TokenService) resolveReferenceToken(ctx context.Context, handle string) (*utils.CustomClaims, error) {
+	token, err := s.tokenStore.GetBySignature(ctx, hashToken(handle))
+	if err != nil {
+		return nil, fmt.Errorf("invalid access token: %w", err)
+	}
+	if token.Type != models.TokenTypeAccessToken {
+		return nil, fmt.Errorf("invalid token type provided")
+	}
+	if time.Now().After(token.ExpiresAt) {
+		return nil, fmt.Errorf("access token has expired")
+	}
+
+	audience := token.Audience
+	if audience == "" {
+		audience = token.ClientID
+	}
+	claims := &utils.CustomClaims{
+		Scope:    token.Scopes,
+		ClientID: token.ClientID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    s.jwtManager.GetIssuer(),
+			Subject:   token.UserID,
+			Audience:  jwt.ClaimStrings{audience},
+			ExpiresAt: jwt.NewNumericDate(token.ExpiresAt),
+			NotBefore: jwt.NewNumericDate(token.CreatedAt),
+			IssuedAt:  jwt.NewNumericDate(token.CreatedAt),
+			ID:        token.ID.Hex(),
+		},
+		AMR: token.AMR,
+		ACR: token.ACR,
+		Act: token.Act,
+	}
+	if !token.AuthTime.IsZero() {
+		claims.AuthTime = token.AuthTime.Unix()
+	}
+	return claims, nil
+}
+
+// RevokeAccessToken denylists an access token for the remainder of its lifetime.
+func (s *TokenService) RevokeAccessToken(ctx context.Context, claims *utils.CustomClaims) error {
+	if claims.ExpiresAt == nil {
+		return s.denylist.Add(ctx, claims.ID, s.jwtManager.GetAccessTokenLifespan())
+	}
+	ttl := time.Until(claims.ExpiresAt.Time)
+	if ttl <= 0 {
+		return nil // Already expired; nothing to revoke.
+	}
+	return s.denylist.Add(ctx, claims.ID, ttl)
+}
+
+// RevokeGrantAccessTokens revokes every access token minted from a refresh token family.
+// Reference tokens are deleted and JWTs are denylisted. The exact expiry of each JWT is
+// unknown here, so the full access token lifespan is used.
+func (s *TokenService) RevokeGrantAccessTokens(ctx context.Context, grantID string) error {
+	if err := s.tokenStore.DeleteByGrantID(ctx, grantID); err != nil {
+		return err
+	}
+	jtis, err := s.denylist.ListIssued(ctx, grantID)
+	if err != nil {
+		return err
+	}
+	for _, jti := range jtis {
+		if err := s.denylist.Add(ctx, jti, s.jwtManager.GetAccessTokenLifespan()); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-// GenerateIDToken creates a new OIDC ID token.
-func (s *TokenService) GenerateIDToken(userID, clientID string, nonce string, authTime time.Time) (string, error) {
-	return s.jwtManager.GenerateIDToken(userID, clientID, nonce, authTime)
+// GenerateIDToken creates a new OIDC ID token signed with signingAlg, or the default algorithm if empty.
+func (s *TokenService) GenerateIDToken(userID, clientID string, nonce string, authTime time.Time, amr []string, signingAlg string) (string, error) {
+	return s.jwtManager.GenerateIDToken(userID, clientID, nonce, authTime, amr, signingAlg)
 }
 
-// GenerateAndStoreAuthorizationCode creates a new authorization code and stores its hash.
-func (s *TokenService) GenerateAndStoreAuthorizationCode(ctx context.Context, userID, clientID string, scopes []string) (string, error) {
+// GenerateAuthorizationResponse signs the parameters of an authorization response for
+// a JARM response mode, with signingAlg or the default algorithm if empty.
+func (s *TokenService) GenerateAuthorizationResponse(clientID string, response url.Values, signingAlg string) (string, error) {
+	params := make(map[string]string, len(response))
+	for key := range response {
+		params[key] = response.Get(key)
+	}
+	return s.jwtManager.GenerateAuthorizationResponse(clientID, params, signingAlg)
+}
+
+// ParseIDTokenHint verifies an ID token issued by this server, ignoring its expiry.
+func (s *TokenService) ParseIDTokenHint(idToken string) (*utils.IDTokenClaims, error) {
+	return s.jwtManager.ParseIDTokenHint(idToken)
+}
+
+// AuthorizationCodeParams holds the authorization request context bound to a new authorization code.
+type AuthorizationCodeParams struct {
+	UserID              string
+	ClientID            string
+	Scopes              []string
+	RedirectURI         string
+	Nonce               string
+	AuthTime            time.Time
+	AMR                 []string
+	ACR                 string
+	SessionID           string
+	CodeChallenge       string
+	CodeChallengeMethod string
+	Resources           []string
+}
+
+// GenerateAndStoreAuthorizationCode creates a new authorization code and stores its hash
+// together with the context of the authorization request it was issued for.
+func (s *TokenService) GenerateAndStoreAuthorizationCode(ctx context.Context, params AuthorizationCodeParams) (string, error) {
 	code, err := utils.GenerateSecureToken(32)
 	if err != nil {
 		return "", fmt.Errorf("failed to generate authorization code: %w", err)
 	}
 	token := &models.Token{
-		Signature: hashToken(code),
-		ClientID:  clientID,
-		UserID:    userID,
-		Scopes:    scopes,
-		ExpiresAt: time.Now().Add(AuthCodeLifespan),
-		Type:      models.TokenTypeAuthorizationCode,
+		Signature:           hashToken(code),
+		ClientID:            params.ClientID,
+		UserID:              params.UserID,
+		Scopes:              params.Scopes,
+		ExpiresAt:           time.Now().Add(AuthCodeLifespan),
+		Type:                models.TokenTypeAuthorizationCode,
+		RedirectURI:         params.RedirectURI,
+		Nonce:               params.Nonce,
+		AuthTime:            params.AuthTime,
+		AMR:                 params.AMR,
+		ACR:                 params.ACR,
+		SessionID:           params.SessionID,
+		CodeChallenge:       params.CodeChallenge,
+		CodeChallengeMethod: params.CodeChallengeMethod,
+		Resources:           params.Resources,
 	}
 	if err := s.tokenStore.Save(ctx, token); err != nil {
 		return "", fmt.Errorf("failed to store authorization code: %w", err)
@@ -83,28 +347,107 @@ func (s *TokenService) GenerateAndStoreAuthorizationCode(ctx context.Context, us
 	return code, nil
 }
 
-// GenerateAndStoreRefreshToken creates a new refresh token and stores its hash.
-func (s *TokenService) GenerateAndStoreRefreshToken(ctx context.Context, userID, clientID string, scopes []string) (string, error) {
-	token, err := utils.GenerateSecureToken(64)
+// RefreshTokenParams holds the grant a new refresh token represents.
+type RefreshTokenParams struct {
+	UserID   string
+	ClientID string
+	Scopes   []string
+	// AuthTime, AMR and ACR are carried over so that tokens issued on refresh keep the
+	// original auth_time, amr and acr.
+	AuthTime time.Time
+	AMR      []string
+	ACR      string
+	// Resources are the resource servers the grant was authorized for (RFC 8707).
+	Resources []string
+}
+
+// GenerateAndStoreRefreshToken creates a new refresh token, starting a new token family, and stores its hash.
+// The stored record is returned so callers can tie access tokens to its family.
+func (s *TokenService) GenerateAndStoreRefreshToken(ctx context.Context, params RefreshTokenParams) (string, *models.Token, error) {
+	familyID, err := utils.GenerateSecureToken(32)
 	if err != nil {
-		return "", fmt.Errorf("failed to generate refresh token: %w", err)
+		return "", nil, fmt.Errorf("failed to generate token family id: %w", err)
 	}
-	refreshToken := &models.Token{
-		Signature: hashToken(token),
-		ClientID:  clientID,
-		UserID:    userID,
-		Scopes:    scopes,
+	return s.storeRefreshToken(ctx, &models.Token{
+		ClientID:  params.ClientID,
+		UserID:    params.UserID,
+		Scopes:    params.Scopes,
 		ExpiresAt: time.Now().Add(RefreshTokenLifespan),
-		Type:      models.TokenTypeRefreshToken,
+		AuthTime:  params.AuthTime,
+		AMR:       params.AMR,
+		ACR:       params.ACR,
+		Resources: params.Resources,
+		FamilyID:  familyID,
+	})
+}
+
+// RotateRefreshToken marks a validated refresh token as rotated and issues its successor
+// in the same family. The successor inherits the parent's expiry, so rotation never
+// extends the lifetime of the original grant.
+func (s *TokenService) RotateRefreshToken(ctx context.Context, parent *models.Token) (string, *models.Token, error) {
+	if err := s.tokenStore.MarkRotated(ctx, parent.Signature, time.Now()); err != nil {
+		if errors.Is(err, utils.ErrNotFound) {
+			// A concurrent request rotated the token first: this is a replay.
+			return "", nil, s.revokeFamily(ctx, parent)
+		}
+		return "", nil, fmt.Errorf("failed to rotate refresh token: %w", err)
+	}
+	familyID := parent.FamilyID
+	if familyID == "" {
+		// Tokens issued before families were introduced start a family on first rotation.
+		var err error
+		if familyID, err = utils.GenerateSecureToken(32); err != nil {
+			return "", nil, fmt.Errorf("failed to generate token family id: %w", err)
+		}
+	}
+	return s.storeRefreshToken(ctx, &models.Token{
+		ClientID:  parent.ClientID,
+		UserID:    parent.UserID,
+		Scopes:    parent.Scopes,
+		ExpiresAt: parent.ExpiresAt,
+		AuthTime:  parent.AuthTime,
+		AMR:       parent.AMR,
+		ACR:       parent.ACR,
+		Resources: parent.Resources,
+		FamilyID:  familyID,
+		ParentID:  parent.ID,
+	})
+}
+
+// storeRefreshToken generates a refresh token value and persists the given record under its hash.
+func (s *TokenService) storeRefreshToken(ctx context.Context, refreshToken *models.Token) (string, *models.Token, error) {
+	token, err := utils.GenerateSecureToken(64)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to generate refresh token: %w", err)
 	}
+	refreshToken.Signature = hashToken(token)
+	refreshToken.Type = models.TokenTypeRefreshToken
 	if err := s.tokenStore.Save(ctx, refreshToken); err != nil {
-		return "", fmt.Errorf("failed to store refresh token: %w", err)
+		return "", nil, fmt.Errorf("failed to store refresh token: %w", err)
 	}
-	return token, nil
+	return token, refreshToken, nil
+}
+
+// RevokeRefreshToken deletes a refresh token together with the rest of its family.
+// When revokeAccessTokens is set, access tokens minted from the family are denylisted too.
+func (s *TokenService) RevokeRefreshToken(ctx context.Context, token *models.Token, revokeAccessTokens bool) error {
+	if token.FamilyID == "" {
+		if err := s.tokenStore.DeleteBySignature(ctx, token.Signature); err != nil {
+			return fmt.Errorf("failed to delete refresh token: %w", err)
+		}
+		return nil
+	}
+	if err := s.tokenStore.DeleteByFamilyID(ctx, token.FamilyID); err != nil {
+		return fmt.Errorf("failed to delete refresh token family: %w", err)
+	}
+	if revokeAccessTokens {
+		return s.RevokeGrantAccessTokens(ctx, token.FamilyID)
+	}
+	return nil
 }
 
 // GenerateAndStoreDeviceCode creates a new device and user code.
-func (s *TokenService) GenerateAndStoreDeviceCode(ctx context.Context, clientID string, scopes []string) (string, string, error) {
+func (s *TokenService) GenerateAndStoreDeviceCode(ctx context.Context, clientID string, scopes, resources []string) (string, string, error) {
 	deviceCode, err := utils.GenerateSecureToken(64)
 	if err != nil {
 		return "", "", fmt.Errorf("failed to generate device code: %w", err)
@@ -118,6 +461,7 @@ func (s *TokenService) GenerateAndStoreDeviceCode(ctx context.Context, clientID
 		UserCode:  strings.ToUpper(userCode),
 		ClientID:  clientID,
 		Scopes:    scopes,
+		Resources: resources,
 		ExpiresAt: time.Now().Add(DeviceCodeLifespan),
 		Type:      models.TokenTypeDeviceCode,
 		Approved:  false,
@@ -160,22 +504,46 @@ func (s *TokenService) ValidateAndConsumeAuthCode(ctx context.Context, code stri
 }
 
 // ValidateAndConsumeRefreshToken checks if a refresh token is valid.
+// Presenting a token that has already been rotated revokes its entire family and
+// returns a *RefreshTokenReuseError. Callers consume a valid token by rotating it.
 func (s *TokenService) ValidateAndConsumeRefreshToken(ctx context.Context, tokenStr string) (*models.Token, error) {
 	signature := hashToken(tokenStr)
 	token, err := s.tokenStore.GetBySignature(ctx, signature)
 	if err != nil {
 		return nil, fmt.Errorf("invalid refresh token: %w", err)
 	}
+	if token.Type != models.TokenTypeRefreshToken {
+		return nil, fmt.Errorf("invalid token type provided")
+	}
+	if !token.RotatedAt.IsZero() {
+		return nil, s.revokeFamily(ctx, token)
+	}
 	if time.Now().After(token.ExpiresAt) {
 		_ = s.tokenStore.DeleteBySignature(ctx, signature)
 		return nil, fmt.Errorf("refresh token has expired")
 	}
-	if token.Type != models.TokenTypeRefreshToken {
-		return nil, fmt.Errorf("invalid token type provided")
-	}
 	return token, nil
 }
 
+// RefreshTokenReuseError reports that a rotated refresh token was presented again.
+// By the time it is returned, every token in the family has been revoked.
+type RefreshTokenReuseError struct {
+	Token *models.Token
+}
+
+func (e *RefreshTokenReuseError) Error() string {
+	return "refresh token reuse detected"
+}
+
+// revokeFamily revokes every refresh token sharing the given token's family, along with
+// the access tokens minted from it, since the family must be assumed compromised.
+func (s *TokenService) revokeFamily(ctx context.Context, token *models.Token) error {
+	if err := s.RevokeRefreshToken(ctx, token, true); err != nil {
+		return fmt.Errorf("failed to revoke reused refresh token: %w", err)
+	}
+	return &RefreshTokenReuseError{Token: token}
+}
+
 // ApproveDeviceCode marks a device code as approved by a user.
 func (s *TokenService) ApproveDeviceCode(ctx context.Context, userCode, userID string) (*models.Token, error) {
 	token, err := s.GetTokenByUserCode(ctx, userCode)
@@ -198,27 +566,14 @@ func (s *TokenService) DeleteTokenBySignature(ctx context.Context, signature str
 	return s.tokenStore.DeleteBySignature(ctx, signature)
 }
 
-// StorePKCEChallenge saves the code challenge associated with an authorization code.
-func (s *TokenService) StorePKCEChallenge(ctx context.Context, code, challenge string) error {
-	return s.pkceStore.Save(ctx, code, challenge, PKCEChallengeLifespan)
-}
-
-// ValidatePKCE retrieves the stored challenge for a code and validates it against the verifier.
-func (s *TokenService) ValidatePKCE(ctx context.Context, code, verifier string) error {
-	challenge, err := s.pkceStore.Get(ctx, code)
-	if err != nil {
-		if errors.Is(err, utils.ErrNotFound) {
-			// If no challenge is found, it might be a flow that didn't use PKCE,
-			// or the code is simply invalid. For security, we treat it as an error.
-			return fmt.Errorf("invalid authorization code or no PKCE challenge found")
-		}
-		return fmt.Errorf("failed to retrieve PKCE challenge: %w", err)
+// VerifyPKCE checks a code_verifier against the challenge bound to an authorization code.
+// PKCE is mandatory, so codes issued without a challenge are rejected.
+func (s *TokenService) VerifyPKCE(authCode *models.Token, verifier string) error {
+	if authCode.CodeChallenge == "" {
+		return fmt.Errorf("no code_challenge was sent in the authorization request")
 	}
 
-	// IMPORTANT: Delete the challenge immediately after retrieval to prevent reuse.
-	defer s.pkceStore.Delete(ctx, code)
-
-	// Check if the client provided a verifier. If a challenge was stored, a verifier is mandatory.
+	// Check if the client provided a verifier. A verifier is mandatory.
 	if verifier == "" {
 		return fmt.Errorf("code_verifier is required")
 	}
@@ -227,7 +582,7 @@ func (s *TokenService) ValidatePKCE(ctx context.Context, code, verifier string)
 	generatedChallenge := utils.GeneratePKCEChallengeS256(verifier)
 
 	// Compare in constant time to prevent timing attacks.
-	if subtle.ConstantTimeCompare([]byte(challenge), []byte(generatedChallenge)) != 1 {
+	if subtle.ConstantTimeCompare([]byte(authCode.CodeChallenge), []byte(generatedChallenge)) != 1 {
 		return fmt.Errorf("invalid code_verifier")
 	}
 
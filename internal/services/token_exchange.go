@@ -0,0 +1,142 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/aminshahid573/authexa/internal/models"
+	"github.com/aminshahid573/authexa/internal/utils"
+)
+
+// Token type identifiers used by the token exchange grant (RFC 8693 section 3).
+const (
+	TokenTypeURIAccessToken  = "urn:ietf:params:oauth:token-type:access_token"
+	TokenTypeURIRefreshToken = "urn:ietf:params:oauth:token-type:refresh_token"
+	TokenTypeURIIDToken      = "urn:ietf:params:oauth:token-type:id_token"
+	TokenTypeURIJWT          = "urn:ietf:params:oauth:token-type:jwt"
+)
+
+// ErrUnsupportedTokenType is returned for subject and actor token types that cannot be exchanged.
+var ErrUnsupportedTokenType = &utils.AppError{Code: "invalid_request", Message: "The token type is not supported.", HTTPStatus: http.StatusBadRequest}
+
+// TokenSubject describes who a subject or actor token of a token exchange was issued for.
+type TokenSubject struct {
+	Subject  string
+	ClientID string
+	// Scoped is false for tokens that carry no scopes, such as ID tokens. The scopes the
+	// user granted the client must then be looked up before the token can be exchanged.
+	Scoped   bool
+	Scopes   []string
+	AuthTime time.Time
+	AMR      []string
+	ACR      string
+	Act      *models.Actor
+	// ExpiresAt is when the token expires. Tokens obtained by exchanging it expire no later.
+	ExpiresAt time.Time
+	// GrantID is the refresh token family the token belongs to, if any. Tokens obtained by
+	// exchanging it are revoked with the family.
+	GrantID string
+}
+
+// ResolveExchangeToken validates a subject or actor token presented to the token exchange
+// grant by client, and returns who it was issued for. Tokens must have been issued by this
+// server and to the client presenting them: refresh tokens and ID tokens to the client itself,
+// and access tokens for an audience that is the client or one of its token exchange audiences.
+// tokenType is one of the TokenTypeURI* identifiers.
+func (s *TokenService) ResolveExchangeToken(ctx context.Context, token, tokenType string, client *models.Client) (*TokenSubject, error) {
+	switch tokenType {
+	case TokenTypeURIAccessToken:
+		return s.resolveExchangeAccessToken(ctx, token, client)
+	case TokenTypeURIRefreshToken:
+		return s.resolveExchangeRefreshToken(ctx, token, client.ClientID)
+	case TokenTypeURIIDToken:
+		return s.resolveExchangeIDToken(token, client.ClientID)
+	case TokenTypeURIJWT:
+		// ID tokens are only accepted with their own type, so a generic JWT must be an access token.
+		return s.resolveExchangeAccessToken(ctx, token, client)
+	default:
+		return nil, ErrUnsupportedTokenType
+	}
+}
+
+func (s *TokenService) resolveExchangeAccessToken(ctx context.Context, token string, client *models.Client) (*TokenSubject, error) {
+	claims, err := s.VerifyAccessToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	// Otherwise any client allowed to exchange tokens could exchange every access token it
+	// got hold of, such as one leaked by a resource server.
+	if !slices.ContainsFunc(claims.Audience, func(aud string) bool {
+		return aud == client.ClientID || slices.Contains(client.TokenExchangeAudiences, aud)
+	}) {
+		return nil, fmt.Errorf("access token was issued for another audience")
+	}
+	subject := &TokenSubject{
+		Subject:  claims.Subject,
+		ClientID: claims.ClientID,
+		Scoped:   true,
+		Scopes:   claims.Scope,
+		AMR:      claims.AMR,
+		ACR:      claims.ACR,
+		Act:      claims.Act,
+	}
+	if claims.ExpiresAt != nil {
+		subject.ExpiresAt = claims.ExpiresAt.Time
+	}
+	if claims.AuthTime != 0 {
+		subject.AuthTime = time.Unix(claims.AuthTime, 0)
+	}
+	return subject, nil
+}
+
+func (s *TokenService) resolveExchangeRefreshToken(ctx context.Context, token, clientID string) (*TokenSubject, error) {
+	record, err := s.tokenStore.GetBySignature(ctx, hashToken(token))
+	if err != nil {
+		return nil, fmt.Errorf("invalid refresh token: %w", err)
+	}
+	if record.Type != models.TokenTypeRefreshToken || !record.RotatedAt.IsZero() || time.Now().After(record.ExpiresAt) {
+		return nil, fmt.Errorf("refresh token is not active")
+	}
+	if record.ClientID != clientID {
+		return nil, fmt.Errorf("refresh token was issued to another client")
+	}
+	return &TokenSubject{
+		Subject:  record.UserID,
+		ClientID: record.ClientID,
+		Scoped:   true,
+		Scopes:   record.Scopes,
+		AuthTime: record.AuthTime,
+		AMR:      record.AMR,
+		ACR:      record.ACR,
+
+		ExpiresAt: record.ExpiresAt,
+		GrantID:   record.FamilyID,
+	}, nil
+}
+
+func (s *TokenService) resolveExchangeIDToken(token, clientID string) (*TokenSubject, error) {
+	claims, err := s.jwtManager.VerifyIDToken(token)
+	if err != nil {
+		return nil, err
+	}
+	// ID tokens are handed around, for example as id_token_hint, so only the client they
+	// were issued to may exchange them.
+	if !slices.Contains(claims.Audience, clientID) {
+		return nil, fmt.Errorf("ID token was issued to another client")
+	}
+	subject := &TokenSubject{
+		Subject:  claims.Subject,
+		ClientID: clientID,
+		AMR:      claims.AMR,
+	}
+	if claims.ExpiresAt != nil {
+		subject.ExpiresAt = claims.ExpiresAt.Time
+	}
+	if claims.AuthTime != 0 {
+		subject.AuthTime = time.Unix(claims.AuthTime, 0)
+	}
+	return subject, nil
+}
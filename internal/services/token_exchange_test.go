@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aminshahid573/authexa/internal/config"
+	"github.com/aminshahid573/authexa/internal/models"
+	"github.com/aminshahid573/authexa/internal/utils"
+)
+
+// newTestJWTManager returns a JWTManager with an RS256 signing key.
+func newTestJWTManager(t *testing.T) *utils.JWTManager {
+	t.Helper()
+	return newTestJWTManagerWithConfig(t, config.JWTConfig{Issuer: testIssuer, AccessTokenLifespan: 15 * time.Minute})
+}
+
+// TestTokenService_ResolveExchangeToken tests that only tokens issued to the requesting
+// client can be exchanged, and that the subject carries their expiry and grant.
+func TestTokenService_ResolveExchangeToken(t *testing.T) {
+	ctx := context.Background()
+	jwtManager := newTestJWTManager(t)
+	refreshExpiry := time.Now().Add(time.Hour).Truncate(time.Second)
+	tokenStore := NewMockTokenStore(
+		&models.Token{Signature: hashToken("own-refresh-token"), Type: models.TokenTypeRefreshToken, ClientID: "exchanger", UserID: "user-1", Scopes: []string{"openid"}, ExpiresAt: refreshExpiry, FamilyID: "family-1"},
+		&models.Token{Signature: hashToken("other-refresh-token"), Type: models.TokenTypeRefreshToken, ClientID: "other-client", UserID: "user-1", ExpiresAt: refreshExpiry, FamilyID: "family-2"},
+	)
+	tokenService := NewTokenService(jwtManager, tokenStore, NewMockTokenDenylist(), &MockUserStore{})
+	client := &models.Client{ClientID: "exchanger", TokenExchangeAudiences: []string{"https://api.example.com"}}
+
+	accessToken := func(clientID, audience string) string {
+		token, err := tokenService.GenerateAccessToken(ctx, AccessTokenRequest{UserID: "user-1", ClientID: clientID, Audience: audience, Scopes: []string{"openid"}})
+		if err != nil {
+			t.Fatalf("failed to generate access token: %v", err)
+		}
+		return token
+	}
+	idToken := func(clientID string) string {
+		token, err := jwtManager.GenerateIDToken("user-1", clientID, "", time.Time{}, nil, "")
+		if err != nil {
+			t.Fatalf("failed to generate id token: %v", err)
+		}
+		return token
+	}
+
+	tests := []struct {
+		name        string
+		token       string
+		tokenType   string
+		wantErr     bool
+		wantScoped  bool
+		wantGrantID string
+	}{
+		{name: "Access Token For The Client", token: accessToken("exchanger", ""), tokenType: TokenTypeURIAccessToken, wantScoped: true},
+		{name: "Access Token For An Exchange Audience", token: accessToken("other-client", "https://api.example.com"), tokenType: TokenTypeURIAccessToken, wantScoped: true},
+		{name: "Access Token As JWT", token: accessToken("exchanger", ""), tokenType: TokenTypeURIJWT, wantScoped: true},
+		{name: "Access Token For Another Client", token: accessToken("other-client", ""), tokenType: TokenTypeURIAccessToken, wantErr: true},
+		{name: "Access Token For Another Audience", token: accessToken("other-client", "https://billing.example.com"), tokenType: TokenTypeURIAccessToken, wantErr: true},
+		{name: "JWT For Another Audience", token: accessToken("other-client", "https://billing.example.com"), tokenType: TokenTypeURIJWT, wantErr: true},
+		{name: "ID Token As JWT", token: idToken("exchanger"), tokenType: TokenTypeURIJWT, wantErr: true},
+		{name: "Own Refresh Token", token: "own-refresh-token", tokenType: TokenTypeURIRefreshToken, wantScoped: true, wantGrantID: "family-1"},
+		{name: "Refresh Token Of Another Client", token: "other-refresh-token", tokenType: TokenTypeURIRefreshToken, wantErr: true},
+		{name: "Own ID Token", token: idToken("exchanger"), tokenType: TokenTypeURIIDToken},
+		{name: "ID Token Of Another Client", token: idToken("other-client"), tokenType: TokenTypeURIIDToken, wantErr: true},
+		{name: "Access Token As ID Token", token: accessToken("exchanger", ""), tokenType: TokenTypeURIIDToken, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subject, err := tokenService.ResolveExchangeToken(ctx, tt.token, tt.tokenType, client)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("expected an error, but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, but got: %v", err)
+			}
+			if subject.Subject != "user-1" || subject.Scoped != tt.wantScoped || subject.GrantID != tt.wantGrantID {
+				t.Errorf("unexpected subject: %+v", subject)
+			}
+			if subject.ExpiresAt.IsZero() || subject.ExpiresAt.After(refreshExpiry) {
+				t.Errorf("expected the subject to carry the token's expiry, but got: %v", subject.ExpiresAt)
+			}
+		})
+	}
+
+	t.Run("Unsupported Token Type Error", func(t *testing.T) {
+		_, err := tokenService.ResolveExchangeToken(ctx, "token", "urn:ietf:params:oauth:token-type:saml2", client)
+		if !errors.Is(err, ErrUnsupportedTokenType) {
+			t.Errorf("expected ErrUnsupportedTokenType, but got: %v", err)
+		}
+	})
+}
+
+// TestTokenService_AccessTokenExpiresAt tests that exchanged tokens cannot outlive the subject token.
+func TestTokenService_AccessTokenExpiresAt(t *testing.T) {
+	tokenService := NewTokenService(newTestJWTManager(t), NewMockTokenStore(), NewMockTokenDenylist(), &MockUserStore{})
+	soon := time.Now().Add(time.Minute)
+
+	tests := []struct {
+		name     string
+		notAfter time.Time
+		want     func(got time.Time) bool
+	}{
+		{name: "No Limit", notAfter: time.Time{}, want: func(got time.Time) bool { return got.After(time.Now().Add(14 * time.Minute)) }},
+		{name: "Earlier Limit", notAfter: soon, want: func(got time.Time) bool { return got.Equal(soon) }},
+		{name: "Later Limit", notAfter: time.Now().Add(time.Hour), want: func(got time.Time) bool { return got.Before(time.Now().Add(16 * time.Minute)) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tokenService.AccessTokenExpiresAt(tt.notAfter); !tt.want(got) {
+				t.Errorf("unexpected expiry for limit %v: %v", tt.notAfter, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,305 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/aminshahid573/authexa/internal/config"
+	"github.com/aminshahid573/authexa/internal/models"
+	"github.com/aminshahid573/authexa/internal/utils"
+	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// newTestJWTManagerWithConfig returns a JWTManager for cfg with an RS256 signing key.
+func newTestJWTManagerWithConfig(t *testing.T, cfg config.JWTConfig) *utils.JWTManager {
+	t.Helper()
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	key, err := utils.NewSigningKey(jwt.SigningMethodRS256.Alg(), privateKey)
+	if err != nil {
+		t.Fatalf("failed to create signing key: %v", err)
+	}
+	jwtManager := utils.NewJWTManager(cfg)
+	jwtManager.SetKeys([]*utils.SigningKey{key}, []*utils.SigningKey{key})
+	return jwtManager
+}
+
+// MockTokenStore is an in-memory implementation of the storage.TokenStore interface.
+type MockTokenStore struct {
+	Tokens map[string]*models.Token
+}
+
+func NewMockTokenStore(tokens ...*models.Token) *MockTokenStore {
+	m := &MockTokenStore{Tokens: make(map[string]*models.Token)}
+	for _, token := range tokens {
+		m.Tokens[token.Signature] = token
+	}
+	return m
+}
+
+func (m *MockTokenStore) Save(ctx context.Context, token *models.Token) error {
+	if token.ID.IsZero() {
+		token.ID = bson.NewObjectID()
+	}
+	m.Tokens[token.Signature] = token
+	return nil
+}
+
+func (m *MockTokenStore) GetBySignature(ctx context.Context, signature string) (*models.Token, error) {
+	token, ok := m.Tokens[signature]
+	if !ok {
+		return nil, utils.ErrNotFound
+	}
+	return token, nil
+}
+
+func (m *MockTokenStore) GetByUserCode(ctx context.Context, userCode string) (*models.Token, error) {
+	for _, token := range m.Tokens {
+		if token.UserCode == userCode {
+			return token, nil
+		}
+	}
+	return nil, utils.ErrNotFound
+}
+
+func (m *MockTokenStore) Update(ctx context.Context, token *models.Token) error {
+	m.Tokens[token.Signature] = token
+	return nil
+}
+
+func (m *MockTokenStore) DeleteBySignature(ctx context.Context, signature string) error {
+	delete(m.Tokens, signature)
+	return nil
+}
+
+// MarkRotated mirrors the atomic update of the real store: it fails with ErrNotFound
+// once the token has been rotated.
+func (m *MockTokenStore) MarkRotated(ctx context.Context, signature string, rotatedAt time.Time) error {
+	token, ok := m.Tokens[signature]
+	if !ok || !token.RotatedAt.IsZero() {
+		return utils.ErrNotFound
+	}
+	token.RotatedAt = rotatedAt
+	return nil
+}
+
+func (m *MockTokenStore) DeleteByFamilyID(ctx context.Context, familyID string) error {
+	for signature, token := range m.Tokens {
+		if token.FamilyID == familyID {
+			delete(m.Tokens, signature)
+		}
+	}
+	return nil
+}
+
+func (m *MockTokenStore) DeleteByGrantID(ctx context.Context, grantID string) error {
+	for signature, token := range m.Tokens {
+		if token.GrantID == grantID {
+			delete(m.Tokens, signature)
+		}
+	}
+	return nil
+}
+
+func (m *MockTokenStore) Count(ctx context.Context) (int64, error) {
+	return int64(len(m.Tokens)), nil
+}
+
+// MockTokenDenylist is an in-memory implementation of the storage.TokenDenylist interface.
+type MockTokenDenylist struct {
+	Denied map[string]bool
+	Issued map[string][]string
+}
+
+func NewMockTokenDenylist() *MockTokenDenylist {
+	return &MockTokenDenylist{Denied: make(map[string]bool), Issued: make(map[string][]string)}
+}
+
+func (m *MockTokenDenylist) Add(ctx context.Context, jti string, ttl time.Duration) error {
+	m.Denied[jti] = true
+	return nil
+}
+
+func (m *MockTokenDenylist) Contains(ctx context.Context, jti string) (bool, error) {
+	return m.Denied[jti], nil
+}
+
+func (m *MockTokenDenylist) TrackIssued(ctx context.Context, grantID, jti string, ttl time.Duration) error {
+	m.Issued[grantID] = append(m.Issued[grantID], jti)
+	return nil
+}
+
+func (m *MockTokenDenylist) ListIssued(ctx context.Context, grantID string) ([]string, error) {
+	return m.Issued[grantID], nil
+}
+
+// TestTokenService_VerifyPKCE checks the code_verifier against the challenge bound to an authorization code.
+func TestTokenService_VerifyPKCE(t *testing.T) {
+	tokenService := &TokenService{}
+	verifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	challenge := utils.GeneratePKCEChallengeS256(verifier)
+
+	tests := []struct {
+		name      string
+		challenge string
+		verifier  string
+		wantErr   bool
+	}{
+		{name: "Matching Verifier", challenge: challenge, verifier: verifier},
+		{name: "Wrong Verifier", challenge: challenge, verifier: verifier + "x", wantErr: true},
+		{name: "Missing Verifier", challenge: challenge, verifier: "", wantErr: true},
+		{name: "Code Without Challenge", challenge: "", verifier: verifier, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authCode := &models.Token{CodeChallenge: tt.challenge, CodeChallengeMethod: "S256"}
+			err := tokenService.VerifyPKCE(authCode, tt.verifier)
+			if tt.wantErr && err == nil {
+				t.Error("expected an error, but got none")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("expected no error, but got: %v", err)
+			}
+		})
+	}
+}
+
+// TestTokenService_RefreshTokenReuse checks that presenting a rotated refresh token again
+// revokes its whole family, along with the access tokens minted from it.
+func TestTokenService_RefreshTokenReuse(t *testing.T) {
+	ctx := context.Background()
+	const familyID = "family-1"
+
+	tests := []struct {
+		name string
+		// replay presents the rotated parent again after it has been rotated once.
+		replay func(tokenService *TokenService, parentToken string, parent *models.Token) error
+	}{
+		{
+			name: "Rotated Token Presented Again",
+			replay: func(tokenService *TokenService, parentToken string, parent *models.Token) error {
+				_, err := tokenService.ValidateAndConsumeRefreshToken(ctx, parentToken)
+				return err
+			},
+		},
+		{
+			name: "Concurrent Rotation",
+			replay: func(tokenService *TokenService, parentToken string, parent *models.Token) error {
+				_, _, err := tokenService.RotateRefreshToken(ctx, parent)
+				return err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parentToken := "parent-refresh-token"
+			parent := &models.Token{
+				ID:        bson.NewObjectID(),
+				Signature: hashToken(parentToken),
+				ClientID:  "client-1",
+				UserID:    "user-1",
+				Scopes:    []string{"openid", "offline"},
+				ExpiresAt: time.Now().Add(time.Hour),
+				Type:      models.TokenTypeRefreshToken,
+				FamilyID:  familyID,
+			}
+			accessToken := &models.Token{
+				Signature: "reference-access-token",
+				Type:      models.TokenTypeAccessToken,
+				GrantID:   familyID,
+				ExpiresAt: time.Now().Add(time.Hour),
+			}
+			tokenStore := NewMockTokenStore(parent, accessToken)
+			denylist := NewMockTokenDenylist()
+			denylist.Issued[familyID] = []string{"jwt-access-token"}
+			jwtManager := utils.NewJWTManager(config.JWTConfig{Issuer: "https://auth.example.com", AccessTokenLifespan: 15 * time.Minute})
+			tokenService := NewTokenService(jwtManager, tokenStore, denylist, &MockUserStore{})
+
+			consumed, err := tokenService.ValidateAndConsumeRefreshToken(ctx, parentToken)
+			if err != nil {
+				t.Fatalf("expected the refresh token to be valid, but got: %v", err)
+			}
+			_, child, err := tokenService.RotateRefreshToken(ctx, consumed)
+			if err != nil {
+				t.Fatalf("expected rotation to succeed, but got: %v", err)
+			}
+			if child.FamilyID != familyID || child.ParentID != parent.ID {
+				t.Fatalf("expected the successor to join family %q under its parent, but got family %q", familyID, child.FamilyID)
+			}
+
+			err = tt.replay(tokenService, parentToken, consumed)
+			var reuseErr *RefreshTokenReuseError
+			if !errors.As(err, &reuseErr) {
+				t.Fatalf("expected a RefreshTokenReuseError, but got: %v", err)
+			}
+			if len(tokenStore.Tokens) != 0 {
+				t.Errorf("expected the token family to be revoked, but %d tokens remain", len(tokenStore.Tokens))
+			}
+			if !denylist.Denied["jwt-access-token"] {
+				t.Error("expected the family's access tokens to be denylisted")
+			}
+		})
+	}
+}
+
+// TestTokenService_GenerateAccessToken checks the authentication context and role claims
+// of access tokens in both formats.
+func TestTokenService_GenerateAccessToken(t *testing.T) {
+	ctx := context.Background()
+	userID := bson.NewObjectID()
+	userStore := &MockUserStore{
+		GetByIDFunc: func(ctx context.Context, id bson.ObjectID) (*models.User, error) {
+			return &models.User{ID: id, Role: "admin"}, nil
+		},
+	}
+
+	tests := []struct {
+		name       string
+		format     string
+		rolesClaim bool
+		wantRoles  []string
+	}{
+		{name: "JWT Without Roles", format: models.AccessTokenFormatJWT},
+		{name: "JWT With Roles", format: models.AccessTokenFormatJWT, rolesClaim: true, wantRoles: []string{"admin"}},
+		{name: "Reference Token", format: models.AccessTokenFormatReference, rolesClaim: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jwtManager := newTestJWTManagerWithConfig(t, config.JWTConfig{Issuer: testIssuer, AccessTokenLifespan: 15 * time.Minute, AccessTokenRolesClaim: tt.rolesClaim})
+			tokenService := NewTokenService(jwtManager, NewMockTokenStore(), NewMockTokenDenylist(), userStore)
+
+			token, err := tokenService.GenerateAccessToken(ctx, AccessTokenRequest{
+				UserID:   userID.Hex(),
+				ClientID: "client-1",
+				Scopes:   []string{"openid"},
+				AMR:      []string{models.AMRPassword},
+				ACR:      models.ACRPassword,
+				Format:   tt.format,
+			})
+			if err != nil {
+				t.Fatalf("expected an access token, but got: %v", err)
+			}
+			claims, err := tokenService.VerifyAccessToken(ctx, token)
+			if err != nil {
+				t.Fatalf("expected the access token to verify, but got: %v", err)
+			}
+			if claims.ACR != models.ACRPassword {
+				t.Errorf("expected acr %q, but got: %q", models.ACRPassword, claims.ACR)
+			}
+			if !slices.Equal(claims.Roles, tt.wantRoles) {
+				t.Errorf("expected roles %v, but got: %v", tt.wantRoles, claims.Roles)
+			}
+		})
+	}
+}
@@ -0,0 +1,281 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aminshahid573/authexa/internal/models"
+	"github.com/aminshahid573/authexa/internal/storage"
+	"github.com/aminshahid573/authexa/internal/utils"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// ErrInvalidAssertion is returned for JWT bearer grant assertions that cannot be accepted.
+var ErrInvalidAssertion = &utils.AppError{Code: "invalid_grant", Message: "The assertion is invalid, expired, or not from a trusted issuer.", HTTPStatus: http.StatusBadRequest}
+
+// ErrTrustedIssuerExists is returned when registering an issuer that is already registered.
+var ErrTrustedIssuerExists = &utils.AppError{Code: "TRUSTED_ISSUER_EXISTS", Message: "A trusted issuer with this issuer identifier already exists.", HTTPStatus: http.StatusConflict}
+
+// TrustedIssuerRequest is the admin API payload for registering or changing a trusted issuer.
+type TrustedIssuerRequest struct {
+	Issuer  string `json:"issuer" validate:"required"`
+	Name    string `json:"name" validate:"required"`
+	JWKSURL string `json:"jwks_url" validate:"omitempty,url"`
+	// JWKS is an inline JSON Web Key Set, for issuers without a JWKS URL.
+	JWKS json.RawMessage `json:"jwks,omitempty"`
+
+	AllowedSubjects []string                `json:"allowed_subjects" validate:"required,min=1,dive,required"`
+	SubjectMappings []models.SubjectMapping `json:"subject_mappings" validate:"required,min=1"`
+	Scopes          []string                `json:"scopes"`
+	AllowedClients  []string                `json:"allowed_clients" validate:"required,min=1,dive,required"`
+}
+
+// AssertionSubject is the local user a verified JWT bearer assertion was issued for.
+type AssertionSubject struct {
+	Issuer *models.TrustedIssuer
+	// Subject is the assertion's "sub", as known to the issuer.
+	Subject string
+	UserID  string
+}
+
+// TrustedIssuerService manages the registry of trusted assertion issuers and verifies the
+// assertions they sign for the JWT bearer grant (RFC 7523 section 2.1).
+type TrustedIssuerService struct {
+	store        storage.TrustedIssuerStore
+	userStore    storage.UserStore
+	jwksResolver *JWKSResolver
+	replayCache  storage.ReplayCache
+	// audiences are the values an assertion's "aud" must contain one of: the issuer
+	// identifier and the token endpoint of this server.
+	audiences []string
+}
+
+// NewTrustedIssuerService creates a new TrustedIssuerService.
+func NewTrustedIssuerService(store storage.TrustedIssuerStore, userStore storage.UserStore, jwksResolver *JWKSResolver, replayCache storage.ReplayCache, issuer, baseURL string) *TrustedIssuerService {
+	return &TrustedIssuerService{
+		store:        store,
+		userStore:    userStore,
+		jwksResolver: jwksResolver,
+		replayCache:  replayCache,
+		audiences:    []string{issuer, baseURL + "/oauth2/token"},
+	}
+}
+
+// VerifyAssertion validates a JWT bearer grant assertion presented by clientID: its issuer
+// must be registered and allow the client, its signature made with one of the issuer's keys,
+// its audience this server, its subject allowed and mapped to a local user, and it must carry
+// "exp" and a "jti" that has not been used.
+func (s *TrustedIssuerService) VerifyAssertion(ctx context.Context, assertion, clientID string) (*AssertionSubject, error) {
+	// The issuer is identified by the unverified "iss" claim, then the signature is checked with its keys.
+	unverified, _, err := jwt.NewParser().ParseUnverified(assertion, &jwt.RegisteredClaims{})
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed assertion", ErrInvalidAssertion)
+	}
+	iss, _ := unverified.Claims.GetIssuer()
+	issuer, err := s.store.GetByIssuer(ctx, iss)
+	if err != nil {
+		if errors.Is(err, utils.ErrNotFound) {
+			return nil, fmt.Errorf("%w: issuer %q is not trusted", ErrInvalidAssertion, iss)
+		}
+		return nil, err
+	}
+	// Assertions are bearer credentials, so an issuer's are only accepted from the clients it was registered for.
+	if !slices.Contains(issuer.AllowedClients, clientID) {
+		return nil, fmt.Errorf("%w: client %q may not present assertions of issuer %q", ErrInvalidAssertion, clientID, iss)
+	}
+
+	keyFunc := func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return s.lookupKey(ctx, issuer, kid)
+	}
+	var claims jwt.RegisteredClaims
+	_, err = jwt.ParseWithClaims(assertion, &claims, keyFunc,
+		jwt.WithValidMethods(utils.SupportedSigningAlgorithms),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuer(issuer.Issuer),
+		jwt.WithLeeway(assertionLeeway),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidAssertion, err)
+	}
+
+	if !slices.ContainsFunc(claims.Audience, func(aud string) bool { return slices.Contains(s.audiences, aud) }) {
+		return nil, fmt.Errorf("%w: assertion has an invalid audience", ErrInvalidAssertion)
+	}
+	if claims.Subject == "" || !slices.ContainsFunc(issuer.AllowedSubjects, func(pattern string) bool { return matchSubject(pattern, claims.Subject) }) {
+		return nil, fmt.Errorf("%w: subject %q is not allowed", ErrInvalidAssertion, claims.Subject)
+	}
+	userID, err := s.mapSubject(ctx, issuer, claims.Subject)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkReplay(ctx, issuer, &claims); err != nil {
+		return nil, err
+	}
+	return &AssertionSubject{Issuer: issuer, Subject: claims.Subject, UserID: userID}, nil
+}
+
+// lookupKey returns the public key of the issuer identified by kid.
+func (s *TrustedIssuerService) lookupKey(ctx context.Context, issuer *models.TrustedIssuer, kid string) (any, error) {
+	if issuer.JWKS != "" {
+		set, err := jwk.Parse([]byte(issuer.JWKS))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse issuer jwks: %w", err)
+		}
+		return rawKey(set, kid)
+	}
+	return s.jwksResolver.LookupKeyAt(ctx, issuer.JWKSURL, kid)
+}
+
+// mapSubject returns the ID of the local user a subject of the issuer maps to.
+func (s *TrustedIssuerService) mapSubject(ctx context.Context, issuer *models.TrustedIssuer, subject string) (string, error) {
+	for _, mapping := range issuer.SubjectMappings {
+		if !matchSubject(mapping.Subject, subject) {
+			continue
+		}
+		id, err := bson.ObjectIDFromHex(mapping.UserID)
+		if err != nil {
+			return "", fmt.Errorf("%w: subject %q maps to an invalid user", ErrInvalidAssertion, subject)
+		}
+		if _, err := s.userStore.GetByID(ctx, id); err != nil {
+			if errors.Is(err, utils.ErrNotFound) {
+				return "", fmt.Errorf("%w: subject %q maps to an unknown user", ErrInvalidAssertion, subject)
+			}
+			return "", err
+		}
+		return mapping.UserID, nil
+	}
+	return "", fmt.Errorf("%w: subject %q is not mapped to a user", ErrInvalidAssertion, subject)
+}
+
+// checkReplay rejects an assertion whose "jti" has already been used with the same issuer.
+// The identifier is remembered until the assertion expires, after which it is rejected anyway.
+func (s *TrustedIssuerService) checkReplay(ctx context.Context, issuer *models.TrustedIssuer, claims *jwt.RegisteredClaims) error {
+	if claims.ID == "" {
+		return fmt.Errorf("%w: assertion is missing jti", ErrInvalidAssertion)
+	}
+
+	ttl := time.Until(claims.ExpiresAt.Time) + assertionLeeway
+	added, err := s.replayCache.Add(ctx, "jwt_bearer_assertion:"+issuer.Issuer+":"+claims.ID, ttl)
+	if err != nil {
+		return err
+	}
+	if !added {
+		return fmt.Errorf("%w: assertion has already been used", ErrInvalidAssertion)
+	}
+	return nil
+}
+
+// matchSubject reports whether subject matches pattern: exactly, or by prefix when the
+// pattern ends in "*".
+func matchSubject(pattern, subject string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		return strings.HasPrefix(subject, prefix)
+	}
+	return pattern == subject
+}
+
+// ListTrustedIssuers retrieves every trusted issuer.
+func (s *TrustedIssuerService) ListTrustedIssuers(ctx context.Context) ([]models.TrustedIssuer, error) {
+	return s.store.List(ctx)
+}
+
+// GetTrustedIssuer retrieves a trusted issuer by its ID.
+func (s *TrustedIssuerService) GetTrustedIssuer(ctx context.Context, id string) (*models.TrustedIssuer, error) {
+	objID, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, utils.ErrNotFound
+	}
+	return s.store.GetByID(ctx, objID)
+}
+
+// CreateTrustedIssuer registers a new trusted issuer.
+func (s *TrustedIssuerService) CreateTrustedIssuer(ctx context.Context, req TrustedIssuerRequest) (*models.TrustedIssuer, error) {
+	if _, err := s.store.GetByIssuer(ctx, req.Issuer); err == nil {
+		return nil, ErrTrustedIssuerExists
+	}
+
+	issuer := &models.TrustedIssuer{}
+	if err := s.apply(ctx, issuer, req); err != nil {
+		return nil, err
+	}
+	if err := s.store.Create(ctx, issuer); err != nil {
+		return nil, err
+	}
+	return issuer, nil
+}
+
+// UpdateTrustedIssuer replaces the configuration of a trusted issuer.
+func (s *TrustedIssuerService) UpdateTrustedIssuer(ctx context.Context, id string, req TrustedIssuerRequest) (*models.TrustedIssuer, error) {
+	issuer, err := s.GetTrustedIssuer(ctx, id)
+	if err != nil {
+		return nil, err // Will be ErrNotFound if it doesn't exist
+	}
+	if existing, err := s.store.GetByIssuer(ctx, req.Issuer); err == nil && existing.ID != issuer.ID {
+		return nil, ErrTrustedIssuerExists
+	}
+
+	if err := s.apply(ctx, issuer, req); err != nil {
+		return nil, err
+	}
+	if err := s.store.Update(ctx, issuer); err != nil {
+		return nil, err
+	}
+	return issuer, nil
+}
+
+// DeleteTrustedIssuer removes a trusted issuer from the registry. Its assertions are rejected
+// from then on, while tokens already issued for them remain valid until they expire.
+func (s *TrustedIssuerService) DeleteTrustedIssuer(ctx context.Context, id string) error {
+	objID, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return utils.ErrNotFound
+	}
+	return s.store.Delete(ctx, objID)
+}
+
+// apply validates req and copies it onto issuer.
+func (s *TrustedIssuerService) apply(ctx context.Context, issuer *models.TrustedIssuer, req TrustedIssuerRequest) error {
+	if req.JWKSURL == "" && (len(req.JWKS) == 0 || string(req.JWKS) == "null") {
+		return &utils.AppError{Code: "VALIDATION_ERROR", Message: "A trusted issuer must have a jwks or jwks_url.", HTTPStatus: http.StatusBadRequest}
+	}
+	jwks, err := validateClientKeys("", req.JWKSURL, req.JWKS)
+	if err != nil {
+		return err
+	}
+	for _, mapping := range req.SubjectMappings {
+		if mapping.Subject == "" {
+			return &utils.AppError{Code: "VALIDATION_ERROR", Message: "Every subject mapping needs a subject.", HTTPStatus: http.StatusBadRequest}
+		}
+		unknownUser := &utils.AppError{Code: "VALIDATION_ERROR", Message: "The subject " + mapping.Subject + " maps to an unknown user.", HTTPStatus: http.StatusBadRequest}
+		id, err := bson.ObjectIDFromHex(mapping.UserID)
+		if err != nil {
+			return unknownUser
+		}
+		if _, err := s.userStore.GetByID(ctx, id); err != nil {
+			if errors.Is(err, utils.ErrNotFound) {
+				return unknownUser
+			}
+			return err
+		}
+	}
+
+	issuer.Issuer = req.Issuer
+	issuer.Name = req.Name
+	issuer.JWKSURL = req.JWKSURL
+	issuer.JWKS = jwks
+	issuer.AllowedSubjects = req.AllowedSubjects
+	issuer.SubjectMappings = req.SubjectMappings
+	issuer.Scopes = req.Scopes
+	issuer.AllowedClients = req.AllowedClients
+	return nil
+}
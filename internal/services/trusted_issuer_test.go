@@ -0,0 +1,190 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/aminshahid573/authexa/internal/models"
+	"github.com/aminshahid573/authexa/internal/utils"
+	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// MockTrustedIssuerStore is an in-memory implementation of the storage.TrustedIssuerStore interface.
+type MockTrustedIssuerStore struct {
+	Issuers []*models.TrustedIssuer
+}
+
+func (m *MockTrustedIssuerStore) GetByID(ctx context.Context, id bson.ObjectID) (*models.TrustedIssuer, error) {
+	for _, issuer := range m.Issuers {
+		if issuer.ID == id {
+			return issuer, nil
+		}
+	}
+	return nil, utils.ErrNotFound
+}
+
+func (m *MockTrustedIssuerStore) GetByIssuer(ctx context.Context, iss string) (*models.TrustedIssuer, error) {
+	for _, issuer := range m.Issuers {
+		if issuer.Issuer == iss {
+			return issuer, nil
+		}
+	}
+	return nil, utils.ErrNotFound
+}
+
+func (m *MockTrustedIssuerStore) Create(ctx context.Context, issuer *models.TrustedIssuer) error {
+	m.Issuers = append(m.Issuers, issuer)
+	return nil
+}
+
+func (m *MockTrustedIssuerStore) List(ctx context.Context) ([]models.TrustedIssuer, error) {
+	return nil, nil
+}
+
+func (m *MockTrustedIssuerStore) Update(ctx context.Context, issuer *models.TrustedIssuer) error {
+	return nil
+}
+
+func (m *MockTrustedIssuerStore) Delete(ctx context.Context, id bson.ObjectID) error {
+	return nil
+}
+
+// TestTrustedIssuerService_VerifyAssertion tests the validation of JWT bearer grant assertions.
+func TestTrustedIssuerService_VerifyAssertion(t *testing.T) {
+	ctx := context.Background()
+	const externalIssuer = "https://idp.example.com"
+	privateKey, jwks := newTestKeySet(t, "k1")
+	otherKey, _ := newTestKeySet(t, "k1")
+	user := &models.User{ID: bson.NewObjectID(), Username: "mapped_user"}
+	userStore := &MockUserStore{
+		GetByIDFunc: func(ctx context.Context, id bson.ObjectID) (*models.User, error) {
+			if id == user.ID {
+				return user, nil
+			}
+			return nil, utils.ErrNotFound
+		},
+	}
+	issuerStore := &MockTrustedIssuerStore{Issuers: []*models.TrustedIssuer{{
+		ID:              bson.NewObjectID(),
+		Issuer:          externalIssuer,
+		JWKS:            jwks,
+		AllowedSubjects: []string{"service-*", "unmapped"},
+		SubjectMappings: []models.SubjectMapping{{Subject: "service-*", UserID: user.ID.Hex()}},
+		Scopes:          []string{"api:read"},
+		AllowedClients:  []string{"bearer-client"},
+	}}}
+
+	validClaims := func() jwt.RegisteredClaims {
+		return jwt.RegisteredClaims{
+			Issuer:    externalIssuer,
+			Subject:   "service-1",
+			Audience:  jwt.ClaimStrings{testIssuer},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+			ID:        "assertion-1",
+		}
+	}
+
+	tests := []struct {
+		name     string
+		clientID string
+		claims   func(claims *jwt.RegisteredClaims)
+		// signer overrides the issuer's key.
+		signer   func() string
+		replayed bool
+		wantErr  bool
+	}{
+		{name: "Valid Assertion"},
+		{name: "Token Endpoint Audience", claims: func(c *jwt.RegisteredClaims) { c.Audience = jwt.ClaimStrings{testIssuer + "/oauth2/token"} }},
+		{name: "Replayed Assertion", replayed: true, wantErr: true},
+		{name: "Client Not Allowed", clientID: "other-client", wantErr: true},
+		{name: "Untrusted Issuer", claims: func(c *jwt.RegisteredClaims) { c.Issuer = "https://evil.example.com" }, wantErr: true},
+		{name: "Wrong Key", signer: func() string { return signTestJWT(t, otherKey, "k1", validClaims()) }, wantErr: true},
+		{name: "Foreign Audience", claims: func(c *jwt.RegisteredClaims) { c.Audience = jwt.ClaimStrings{"https://other.example.com"} }, wantErr: true},
+		{name: "Missing Expiry", claims: func(c *jwt.RegisteredClaims) { c.ExpiresAt = nil }, wantErr: true},
+		{name: "Expired", claims: func(c *jwt.RegisteredClaims) { c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Hour)) }, wantErr: true},
+		{name: "Missing jti", claims: func(c *jwt.RegisteredClaims) { c.ID = "" }, wantErr: true},
+		{name: "Subject Not Allowed", claims: func(c *jwt.RegisteredClaims) { c.Subject = "admin" }, wantErr: true},
+		{name: "Missing Subject", claims: func(c *jwt.RegisteredClaims) { c.Subject = "" }, wantErr: true},
+		{name: "Subject Not Mapped", claims: func(c *jwt.RegisteredClaims) { c.Subject = "unmapped" }, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewTrustedIssuerService(issuerStore, userStore, NewJWKSResolver(nil), NewMockReplayCache(), testIssuer, testIssuer)
+			clientID := tt.clientID
+			if clientID == "" {
+				clientID = "bearer-client"
+			}
+			claims := validClaims()
+			if tt.claims != nil {
+				tt.claims(&claims)
+			}
+			assertion := signTestJWT(t, privateKey, "k1", claims)
+			if tt.signer != nil {
+				assertion = tt.signer()
+			}
+			if tt.replayed {
+				if _, err := service.VerifyAssertion(ctx, assertion, clientID); err != nil {
+					t.Fatalf("expected the first use to succeed, but got: %v", err)
+				}
+			}
+
+			subject, err := service.VerifyAssertion(ctx, assertion, clientID)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidAssertion) {
+					t.Errorf("expected ErrInvalidAssertion, but got: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, but got: %v", err)
+			}
+			if subject.UserID != user.ID.Hex() || subject.Subject != claims.Subject {
+				t.Errorf("expected subject %s mapped to user %s, but got: %+v", claims.Subject, user.ID.Hex(), subject)
+			}
+		})
+	}
+}
+
+// TestClientPolicy_AssertionScopes tests that a JWT bearer grant must request scopes allowed
+// for both the client and the trusted issuer.
+func TestClientPolicy_AssertionScopes(t *testing.T) {
+	policy := NewClientPolicy(newTestScopeService(t))
+	client := &models.Client{Scopes: []string{"openid", "api:*"}}
+	issuer := &models.TrustedIssuer{Scopes: []string{"openid", "api:read"}}
+
+	tests := []struct {
+		name      string
+		requested []string
+		wantErr   bool
+	}{
+		{name: "Allowed For Both", requested: []string{"openid", "api:read"}},
+		{name: "Not Allowed For Issuer", requested: []string{"api:write"}, wantErr: true},
+		{name: "Not Allowed For Client", requested: []string{"profile"}, wantErr: true},
+		{name: "Unknown Scope", requested: []string{"api:delete"}, wantErr: true},
+		{name: "Nothing Requested", requested: nil, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := policy.AssertionScopes(client, issuer, tt.requested)
+			if tt.wantErr {
+				var appErr *utils.AppError
+				if !errors.As(err, &appErr) || appErr.Code != "invalid_scope" {
+					t.Errorf("expected an invalid_scope error, but got: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, but got: %v", err)
+			}
+			if !slices.Equal(got, tt.requested) {
+				t.Errorf("expected scopes %v, but got: %v", tt.requested, got)
+			}
+		})
+	}
+}
@@ -37,6 +37,12 @@ type TokenStore interface {
 	GetByUserCode(ctx context.Context, userCode string) (*models.Token, error)
 	Update(ctx context.Context, token *models.Token) error
 	DeleteBySignature(ctx context.Context, signature string) error
+	// MarkRotated atomically flags a refresh token as rotated. It returns ErrNotFound
+	// if the token does not exist or has already been rotated.
+	MarkRotated(ctx context.Context, signature string, rotatedAt time.Time) error
+	DeleteByFamilyID(ctx context.Context, familyID string) error
+	// DeleteByGrantID removes the reference access tokens minted from a refresh token family.
+	DeleteByGrantID(ctx context.Context, grantID string) error
 	Count(ctx context.Context) (int64, error)
 }
 
@@ -60,6 +66,85 @@ type PKCEStore interface {
 	Delete(ctx context.Context, code string) error
 }
 
+// TokenDenylist defines the interface for tracking revoked access tokens by their "jti" (typically Redis).
+type TokenDenylist interface {
+	// Add denylists a token identifier until ttl elapses, which should match the token's remaining lifetime.
+	Add(ctx context.Context, jti string, ttl time.Duration) error
+	Contains(ctx context.Context, jti string) (bool, error)
+	// TrackIssued records that an access token was minted from a grant (refresh token family),
+	// so that revoking the grant can also revoke its access tokens.
+	TrackIssued(ctx context.Context, grantID, jti string, ttl time.Duration) error
+	ListIssued(ctx context.Context, grantID string) ([]string, error)
+}
+
+// ReplayCache defines the interface for detecting reused one-time identifiers, such as
+// the "jti" of client assertions (typically Redis).
+type ReplayCache interface {
+	// Add records key until ttl elapses. It returns false if key was already recorded.
+	Add(ctx context.Context, key string, ttl time.Duration) (bool, error)
+}
+
+// PushedRequestStore defines the interface for storing pushed authorization requests (typically Redis).
+type PushedRequestStore interface {
+	Save(ctx context.Context, req *models.PushedAuthorizationRequest) error
+	// Consume retrieves and deletes a request in one step, so it can be used only once.
+	Consume(ctx context.Context, requestURI string) (*models.PushedAuthorizationRequest, error)
+}
+
+// AuthorizationRequestStore defines the interface for storing authorization requests
+// awaiting the user's consent (typically Redis).
+type AuthorizationRequestStore interface {
+	Save(ctx context.Context, req *models.AuthorizationRequest) error
+	// Consume retrieves and deletes a request in one step, so it can be used only once.
+	Consume(ctx context.Context, id string) (*models.AuthorizationRequest, error)
+}
+
+// ScopeStore defines the interface for the scope registry.
+type ScopeStore interface {
+	GetByName(ctx context.Context, name string) (*models.Scope, error)
+	Create(ctx context.Context, scope *models.Scope) error
+	List(ctx context.Context) ([]models.Scope, error)
+	Update(ctx context.Context, scope *models.Scope) error
+	Delete(ctx context.Context, name string) error
+}
+
+// ResourceServerStore defines the interface for the resource server registry.
+type ResourceServerStore interface {
+	GetByID(ctx context.Context, id bson.ObjectID) (*models.ResourceServer, error)
+	GetByIdentifier(ctx context.Context, identifier string) (*models.ResourceServer, error)
+	Create(ctx context.Context, resource *models.ResourceServer) error
+	List(ctx context.Context) ([]models.ResourceServer, error)
+	Update(ctx context.Context, resource *models.ResourceServer) error
+	Delete(ctx context.Context, id bson.ObjectID) error
+}
+
+// TrustedIssuerStore defines the interface for the registry of trusted assertion issuers.
+type TrustedIssuerStore interface {
+	GetByID(ctx context.Context, id bson.ObjectID) (*models.TrustedIssuer, error)
+	GetByIssuer(ctx context.Context, issuer string) (*models.TrustedIssuer, error)
+	Create(ctx context.Context, issuer *models.TrustedIssuer) error
+	List(ctx context.Context) ([]models.TrustedIssuer, error)
+	Update(ctx context.Context, issuer *models.TrustedIssuer) error
+	Delete(ctx context.Context, id bson.ObjectID) error
+}
+
+// ConsentStore defines the interface for storing the scopes users have approved for clients.
+type ConsentStore interface {
+	Get(ctx context.Context, userID, clientID string) (*models.ConsentGrant, error)
+	// Save creates the grant for its user and client, or replaces the existing one.
+	Save(ctx context.Context, grant *models.ConsentGrant) error
+}
+
+// SigningKeyStore defines the interface for persisted JWT signing key storage.
+type SigningKeyStore interface {
+	Create(ctx context.Context, key *models.SigningKey) error
+	GetByKeyID(ctx context.Context, keyID string) (*models.SigningKey, error)
+	List(ctx context.Context) ([]models.SigningKey, error)
+	// UpdateState atomically moves a key from one lifecycle state to another. It returns
+	// ErrNotFound if the key does not exist or is no longer in the expected state.
+	UpdateState(ctx context.Context, keyID string, from, to models.SigningKeyState, at time.Time) error
+}
+
 // DataStore is a composite interface that embeds all store interfaces.
 // This is useful for dependency injection.
 type DataStore struct {
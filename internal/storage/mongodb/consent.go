@@ -0,0 +1,50 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aminshahid573/authexa/internal/models"
+	"github.com/aminshahid573/authexa/internal/utils"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// ConsentRepository implements the storage.ConsentStore interface for MongoDB.
+type ConsentRepository struct {
+	collection *mongo.Collection
+}
+
+// NewConsentRepository creates a new ConsentRepository.
+func NewConsentRepository(db *mongo.Database) *ConsentRepository {
+	return &ConsentRepository{
+		collection: db.Collection("consent_grants"),
+	}
+}
+
+// Get retrieves the consent grant a user gave a client.
+func (r *ConsentRepository) Get(ctx context.Context, userID, clientID string) (*models.ConsentGrant, error) {
+	var grant models.ConsentGrant
+	filter := bson.M{"user_id": userID, "client_id": clientID}
+
+	err := r.collection.FindOne(ctx, filter).Decode(&grant)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, utils.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to find consent grant for client %s: %w", clientID, err)
+	}
+	return &grant, nil
+}
+
+// Save creates or replaces the consent grant a user gave a client.
+func (r *ConsentRepository) Save(ctx context.Context, grant *models.ConsentGrant) error {
+	filter := bson.M{"user_id": grant.UserID, "client_id": grant.ClientID}
+
+	if _, err := r.collection.ReplaceOne(ctx, filter, grant, options.Replace().SetUpsert(true)); err != nil {
+		return fmt.Errorf("failed to save consent grant for client %s: %w", grant.ClientID, err)
+	}
+	return nil
+}
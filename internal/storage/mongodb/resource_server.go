@@ -0,0 +1,103 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aminshahid573/authexa/internal/models"
+	"github.com/aminshahid573/authexa/internal/utils"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// ResourceServerRepository implements the storage.ResourceServerStore interface for MongoDB.
+type ResourceServerRepository struct {
+	collection *mongo.Collection
+}
+
+// NewResourceServerRepository creates a new ResourceServerRepository.
+func NewResourceServerRepository(db *mongo.Database) *ResourceServerRepository {
+	return &ResourceServerRepository{
+		collection: db.Collection("resource_servers"),
+	}
+}
+
+// GetByID retrieves a resource server by its ID.
+func (r *ResourceServerRepository) GetByID(ctx context.Context, id bson.ObjectID) (*models.ResourceServer, error) {
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+// GetByIdentifier retrieves a resource server by its identifier URI.
+func (r *ResourceServerRepository) GetByIdentifier(ctx context.Context, identifier string) (*models.ResourceServer, error) {
+	return r.findOne(ctx, bson.M{"identifier": identifier})
+}
+
+// findOne retrieves the resource server matching filter.
+func (r *ResourceServerRepository) findOne(ctx context.Context, filter bson.M) (*models.ResourceServer, error) {
+	var resource models.ResourceServer
+	err := r.collection.FindOne(ctx, filter).Decode(&resource)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, utils.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to find resource server: %w", err)
+	}
+	return &resource, nil
+}
+
+// Create inserts a new resource server into the database.
+func (r *ResourceServerRepository) Create(ctx context.Context, resource *models.ResourceServer) error {
+	resource.ID = bson.NewObjectID()
+	resource.CreatedAt = time.Now()
+	resource.UpdatedAt = time.Now()
+
+	if _, err := r.collection.InsertOne(ctx, resource); err != nil {
+		return fmt.Errorf("failed to create resource server %s: %w", resource.Identifier, err)
+	}
+	return nil
+}
+
+// List retrieves all resource servers, sorted by identifier.
+func (r *ResourceServerRepository) List(ctx context.Context) ([]models.ResourceServer, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "identifier", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find resource servers: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var resources []models.ResourceServer
+	if err := cursor.All(ctx, &resources); err != nil {
+		return nil, fmt.Errorf("failed to decode resource servers: %w", err)
+	}
+	return resources, nil
+}
+
+// Update replaces an existing resource server document.
+func (r *ResourceServerRepository) Update(ctx context.Context, resource *models.ResourceServer) error {
+	resource.UpdatedAt = time.Now()
+	filter := bson.M{"_id": resource.ID}
+
+	result, err := r.collection.ReplaceOne(ctx, filter, resource)
+	if err != nil {
+		return fmt.Errorf("failed to update resource server %s: %w", resource.Identifier, err)
+	}
+	if result.MatchedCount == 0 {
+		return utils.ErrNotFound
+	}
+	return nil
+}
+
+// Delete removes a resource server from the database by its ID.
+func (r *ResourceServerRepository) Delete(ctx context.Context, id bson.ObjectID) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return fmt.Errorf("failed to delete resource server: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return utils.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aminshahid573/authexa/internal/models"
+	"github.com/aminshahid573/authexa/internal/utils"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// ScopeRepository implements the storage.ScopeStore interface for MongoDB.
+type ScopeRepository struct {
+	collection *mongo.Collection
+}
+
+// NewScopeRepository creates a new ScopeRepository.
+func NewScopeRepository(db *mongo.Database) *ScopeRepository {
+	return &ScopeRepository{
+		collection: db.Collection("scopes"),
+	}
+}
+
+// GetByName retrieves a scope by its name.
+func (r *ScopeRepository) GetByName(ctx context.Context, name string) (*models.Scope, error) {
+	var scope models.Scope
+	filter := bson.M{"name": name}
+
+	err := r.collection.FindOne(ctx, filter).Decode(&scope)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, utils.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to find scope %s: %w", name, err)
+	}
+	return &scope, nil
+}
+
+// Create inserts a new scope into the database.
+func (r *ScopeRepository) Create(ctx context.Context, scope *models.Scope) error {
+	scope.ID = bson.NewObjectID()
+	scope.CreatedAt = time.Now()
+	scope.UpdatedAt = time.Now()
+
+	if _, err := r.collection.InsertOne(ctx, scope); err != nil {
+		return fmt.Errorf("failed to create scope %s: %w", scope.Name, err)
+	}
+	return nil
+}
+
+// List retrieves all scopes, sorted by name.
+func (r *ScopeRepository) List(ctx context.Context) ([]models.Scope, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "name", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find scopes: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var scopes []models.Scope
+	if err := cursor.All(ctx, &scopes); err != nil {
+		return nil, fmt.Errorf("failed to decode scopes: %w", err)
+	}
+	return scopes, nil
+}
+
+// Update replaces an existing scope document.
+func (r *ScopeRepository) Update(ctx context.Context, scope *models.Scope) error {
+	scope.UpdatedAt = time.Now()
+	filter := bson.M{"name": scope.Name}
+
+	result, err := r.collection.ReplaceOne(ctx, filter, scope)
+	if err != nil {
+		return fmt.Errorf("failed to update scope %s: %w", scope.Name, err)
+	}
+	if result.MatchedCount == 0 {
+		return utils.ErrNotFound
+	}
+	return nil
+}
+
+// Delete removes a scope from the database by its name.
+func (r *ScopeRepository) Delete(ctx context.Context, name string) error {
+	filter := bson.M{"name": name}
+	result, err := r.collection.DeleteOne(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("failed to delete scope %s: %w", name, err)
+	}
+	if result.DeletedCount == 0 {
+		return utils.ErrNotFound
+	}
+	return nil
+}
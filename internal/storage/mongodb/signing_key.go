@@ -0,0 +1,92 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aminshahid573/authexa/internal/models"
+	"github.com/aminshahid573/authexa/internal/utils"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// SigningKeyRepository implements the storage.SigningKeyStore interface for MongoDB.
+type SigningKeyRepository struct {
+	collection *mongo.Collection
+}
+
+// NewSigningKeyRepository creates a new SigningKeyRepository.
+func NewSigningKeyRepository(db *mongo.Database) *SigningKeyRepository {
+	return &SigningKeyRepository{
+		collection: db.Collection("signing_keys"),
+	}
+}
+
+// Create inserts a new signing key into the database.
+func (r *SigningKeyRepository) Create(ctx context.Context, key *models.SigningKey) error {
+	key.ID = bson.NewObjectID()
+	key.CreatedAt = time.Now()
+
+	if _, err := r.collection.InsertOne(ctx, key); err != nil {
+		return fmt.Errorf("failed to create signing key: %w", err)
+	}
+	return nil
+}
+
+// GetByKeyID retrieves a signing key by its key ID.
+func (r *SigningKeyRepository) GetByKeyID(ctx context.Context, keyID string) (*models.SigningKey, error) {
+	var key models.SigningKey
+	filter := bson.M{"kid": keyID}
+
+	err := r.collection.FindOne(ctx, filter).Decode(&key)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, utils.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to find signing key %s: %w", keyID, err)
+	}
+	return &key, nil
+}
+
+// List retrieves all signing keys, newest first.
+func (r *SigningKeyRepository) List(ctx context.Context) ([]models.SigningKey, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list signing keys: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var keys []models.SigningKey
+	if err := cursor.All(ctx, &keys); err != nil {
+		return nil, fmt.Errorf("failed to decode signing keys: %w", err)
+	}
+	return keys, nil
+}
+
+// UpdateState moves a key between lifecycle states. Filtering on the current state makes
+// the transition atomic, so replicas racing to rotate the same key cannot both succeed.
+func (r *SigningKeyRepository) UpdateState(ctx context.Context, keyID string, from, to models.SigningKeyState, at time.Time) error {
+	set := bson.M{"state": to}
+	switch to {
+	case models.SigningKeyStateActive:
+		set["activated_at"] = at
+	case models.SigningKeyStateRetired:
+		set["retired_at"] = at
+	case models.SigningKeyStateRevoked:
+		set["revoked_at"] = at
+	}
+
+	filter := bson.M{"kid": keyID, "state": from}
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$set": set})
+	if err != nil {
+		return fmt.Errorf("failed to update signing key state: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return utils.ErrNotFound
+	}
+	return nil
+}
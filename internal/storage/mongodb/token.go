@@ -86,12 +86,52 @@ func (r *TokenRepository) DeleteBySignature(ctx context.Context, signature strin
 	return err
 }
 
+// MarkRotated flags a refresh token as rotated, unless another request already did so.
+// The filter on rotated_at makes the check-and-set atomic, so two concurrent refresh
+// requests presenting the same token cannot both succeed.
+func (r *TokenRepository) MarkRotated(ctx context.Context, signature string, rotatedAt time.Time) error {
+	filter := bson.M{
+		"signature":  signature,
+		"type":       models.TokenTypeRefreshToken,
+		"rotated_at": bson.M{"$exists": false},
+	}
+	update := bson.M{"$set": bson.M{"rotated_at": rotatedAt}}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to mark token as rotated: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return utils.ErrNotFound
+	}
+	return nil
+}
+
+// DeleteByFamilyID removes every refresh token belonging to a token family.
+func (r *TokenRepository) DeleteByFamilyID(ctx context.Context, familyID string) error {
+	filter := bson.M{"family_id": familyID}
+	if _, err := r.collection.DeleteMany(ctx, filter); err != nil {
+		return fmt.Errorf("failed to delete token family %s: %w", familyID, err)
+	}
+	return nil
+}
+
+// DeleteByGrantID removes every reference access token minted from a refresh token family.
+func (r *TokenRepository) DeleteByGrantID(ctx context.Context, grantID string) error {
+	filter := bson.M{"grant_id": grantID, "type": models.TokenTypeAccessToken}
+	if _, err := r.collection.DeleteMany(ctx, filter); err != nil {
+		return fmt.Errorf("failed to delete access tokens of grant %s: %w", grantID, err)
+	}
+	return nil
+}
+
 // Count returns the total number of token documents (auth codes, refresh tokens, etc.).
 func (r *TokenRepository) Count(ctx context.Context) (int64, error) {
-	// We count only non-expired refresh tokens to represent "active" tokens.
+	// We count only non-expired, non-rotated refresh tokens to represent "active" tokens.
 	filter := bson.M{
 		"type":       models.TokenTypeRefreshToken,
 		"expires_at": bson.M{"$gt": time.Now()},
+		"rotated_at": bson.M{"$exists": false},
 	}
 	count, err := r.collection.CountDocuments(ctx, filter)
 	if err != nil {
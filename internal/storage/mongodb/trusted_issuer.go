@@ -0,0 +1,103 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aminshahid573/authexa/internal/models"
+	"github.com/aminshahid573/authexa/internal/utils"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// TrustedIssuerRepository implements the storage.TrustedIssuerStore interface for MongoDB.
+type TrustedIssuerRepository struct {
+	collection *mongo.Collection
+}
+
+// NewTrustedIssuerRepository creates a new TrustedIssuerRepository.
+func NewTrustedIssuerRepository(db *mongo.Database) *TrustedIssuerRepository {
+	return &TrustedIssuerRepository{
+		collection: db.Collection("trusted_issuers"),
+	}
+}
+
+// GetByID retrieves a trusted issuer by its ID.
+func (r *TrustedIssuerRepository) GetByID(ctx context.Context, id bson.ObjectID) (*models.TrustedIssuer, error) {
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+// GetByIssuer retrieves a trusted issuer by the "iss" of its assertions.
+func (r *TrustedIssuerRepository) GetByIssuer(ctx context.Context, issuer string) (*models.TrustedIssuer, error) {
+	return r.findOne(ctx, bson.M{"issuer": issuer})
+}
+
+// findOne retrieves the trusted issuer matching filter.
+func (r *TrustedIssuerRepository) findOne(ctx context.Context, filter bson.M) (*models.TrustedIssuer, error) {
+	var issuer models.TrustedIssuer
+	err := r.collection.FindOne(ctx, filter).Decode(&issuer)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, utils.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to find trusted issuer: %w", err)
+	}
+	return &issuer, nil
+}
+
+// Create inserts a new trusted issuer into the database.
+func (r *TrustedIssuerRepository) Create(ctx context.Context, issuer *models.TrustedIssuer) error {
+	issuer.ID = bson.NewObjectID()
+	issuer.CreatedAt = time.Now()
+	issuer.UpdatedAt = time.Now()
+
+	if _, err := r.collection.InsertOne(ctx, issuer); err != nil {
+		return fmt.Errorf("failed to create trusted issuer %s: %w", issuer.Issuer, err)
+	}
+	return nil
+}
+
+// List retrieves all trusted issuers, sorted by issuer.
+func (r *TrustedIssuerRepository) List(ctx context.Context) ([]models.TrustedIssuer, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "issuer", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find trusted issuers: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var issuers []models.TrustedIssuer
+	if err := cursor.All(ctx, &issuers); err != nil {
+		return nil, fmt.Errorf("failed to decode trusted issuers: %w", err)
+	}
+	return issuers, nil
+}
+
+// Update replaces an existing trusted issuer document.
+func (r *TrustedIssuerRepository) Update(ctx context.Context, issuer *models.TrustedIssuer) error {
+	issuer.UpdatedAt = time.Now()
+	filter := bson.M{"_id": issuer.ID}
+
+	result, err := r.collection.ReplaceOne(ctx, filter, issuer)
+	if err != nil {
+		return fmt.Errorf("failed to update trusted issuer %s: %w", issuer.Issuer, err)
+	}
+	if result.MatchedCount == 0 {
+		return utils.ErrNotFound
+	}
+	return nil
+}
+
+// Delete removes a trusted issuer from the database by its ID.
+func (r *TrustedIssuerRepository) Delete(ctx context.Context, id bson.ObjectID) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return fmt.Errorf("failed to delete trusted issuer: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return utils.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aminshahid573/authexa/internal/models"
+	"github.com/aminshahid573/authexa/internal/utils"
+	"github.com/redis/go-redis/v9"
+)
+
+// AuthorizationRequestRepository implements the storage.AuthorizationRequestStore interface for Redis.
+type AuthorizationRequestRepository struct {
+	client *redis.Client
+}
+
+// NewAuthorizationRequestRepository creates a new AuthorizationRequestRepository.
+func NewAuthorizationRequestRepository(client *redis.Client) *AuthorizationRequestRepository {
+	return &AuthorizationRequestRepository{client: client}
+}
+
+// Save stores an authorization request until it expires.
+func (r *AuthorizationRequestRepository) Save(ctx context.Context, req *models.AuthorizationRequest) error {
+	key := fmt.Sprintf("authz_request:%s", req.ID)
+	ttl := time.Until(req.ExpiresAt)
+	if ttl <= 0 {
+		return errors.New("authorization request expiration must be in the future")
+	}
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to marshal authorization request: %w", err)
+	}
+	if err := r.client.Set(ctx, key, data, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to save authorization request to redis: %w", err)
+	}
+	return nil
+}
+
+// Consume atomically retrieves and deletes an authorization request, so that
+// the user's decision can be submitted only once.
+func (r *AuthorizationRequestRepository) Consume(ctx context.Context, id string) (*models.AuthorizationRequest, error) {
+	data, err := r.client.GetDel(ctx, fmt.Sprintf("authz_request:%s", id)).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, utils.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get authorization request from redis: %w", err)
+	}
+
+	var req models.AuthorizationRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal authorization request: %w", err)
+	}
+	return &req, nil
+}
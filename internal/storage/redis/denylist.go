@@ -0,0 +1,66 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// DenylistRepository implements the storage.TokenDenylist interface for Redis.
+type DenylistRepository struct {
+	client *redis.Client
+}
+
+// NewDenylistRepository creates a new DenylistRepository.
+func NewDenylistRepository(client *redis.Client) *DenylistRepository {
+	return &DenylistRepository{client: client}
+}
+
+// Add stores a revoked token identifier. The key expires together with the token,
+// after which the token is rejected for being expired anyway.
+func (r *DenylistRepository) Add(ctx context.Context, jti string, ttl time.Duration) error {
+	if ttl <= 0 {
+		return errors.New("denylist ttl must be positive")
+	}
+	key := fmt.Sprintf("denylist:%s", jti)
+	if err := r.client.Set(ctx, key, 1, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to add token to denylist: %w", err)
+	}
+	return nil
+}
+
+// Contains reports whether a token identifier has been revoked.
+func (r *DenylistRepository) Contains(ctx context.Context, jti string) (bool, error) {
+	key := fmt.Sprintf("denylist:%s", jti)
+	n, err := r.client.Exists(ctx, key).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to check token denylist: %w", err)
+	}
+	return n > 0, nil
+}
+
+// TrackIssued adds a token identifier to the set of access tokens minted from a grant.
+// The set's TTL is extended on every addition so it outlives its newest member.
+func (r *DenylistRepository) TrackIssued(ctx context.Context, grantID, jti string, ttl time.Duration) error {
+	key := fmt.Sprintf("grant_tokens:%s", grantID)
+	pipe := r.client.TxPipeline()
+	pipe.SAdd(ctx, key, jti)
+	pipe.Expire(ctx, key, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to track issued access token: %w", err)
+	}
+	return nil
+}
+
+// ListIssued returns the identifiers of access tokens minted from a grant that may still be valid.
+func (r *DenylistRepository) ListIssued(ctx context.Context, grantID string) ([]string, error) {
+	key := fmt.Sprintf("grant_tokens:%s", grantID)
+	jtis, err := r.client.SMembers(ctx, key).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list issued access tokens: %w", err)
+	}
+	return jtis, nil
+}
@@ -0,0 +1,64 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aminshahid573/authexa/internal/models"
+	"github.com/aminshahid573/authexa/internal/utils"
+	"github.com/redis/go-redis/v9"
+)
+
+// PARRepository implements the storage.PushedRequestStore interface for Redis.
+type PARRepository struct {
+	client *redis.Client
+}
+
+// NewPARRepository creates a new PARRepository.
+func NewPARRepository(client *redis.Client) *PARRepository {
+	return &PARRepository{client: client}
+}
+
+// Save stores a pushed authorization request until it expires.
+func (r *PARRepository) Save(ctx context.Context, req *models.PushedAuthorizationRequest) error {
+	key := fmt.Sprintf("par:%s", req.RequestURI)
+	ttl := time.Until(req.ExpiresAt)
+	if ttl <= 0 {
+		return errors.New("pushed authorization request expiration must be in the future")
+	}
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to marshal pushed authorization request: %w", err)
+	}
+	if err := r.client.Set(ctx, key, data, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to save pushed authorization request to redis: %w", err)
+	}
+	return nil
+}
+
+// Consume atomically retrieves and deletes a pushed authorization request,
+// so that its request_uri can be used only once.
+func (r *PARRepository) Consume(ctx context.Context, requestURI string) (*models.PushedAuthorizationRequest, error) {
+	data, err := r.client.GetDel(ctx, fmt.Sprintf("par:%s", requestURI)).Bytes()
+	return decodePushedRequest(data, err)
+}
+
+// decodePushedRequest decodes the result of reading a pushed authorization request.
+func decodePushedRequest(data []byte, err error) (*models.PushedAuthorizationRequest, error) {
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, utils.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get pushed authorization request from redis: %w", err)
+	}
+
+	var req models.PushedAuthorizationRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal pushed authorization request: %w", err)
+	}
+	return &req, nil
+}
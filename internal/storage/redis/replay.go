@@ -0,0 +1,33 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ReplayRepository implements the storage.ReplayCache interface for Redis.
+type ReplayRepository struct {
+	client *redis.Client
+}
+
+// NewReplayRepository creates a new ReplayRepository.
+func NewReplayRepository(client *redis.Client) *ReplayRepository {
+	return &ReplayRepository{client: client}
+}
+
+// Add records a one-time identifier. SETNX makes the check-and-set atomic, so two
+// concurrent requests presenting the same identifier cannot both succeed.
+func (r *ReplayRepository) Add(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	if ttl <= 0 {
+		return false, errors.New("replay cache ttl must be positive")
+	}
+	added, err := r.client.SetNX(ctx, fmt.Sprintf("replay:%s", key), 1, ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to record identifier in replay cache: %w", err)
+	}
+	return added, nil
+}
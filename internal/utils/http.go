@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// outboundRequestTimeout bounds how long fetching a client-supplied URL may take.
+const outboundRequestTimeout = 5 * time.Second
+
+// ErrNonPublicAddress is returned when an outbound request would connect to an address
+// that is not on the public internet.
+var ErrNonPublicAddress = errors.New("connections to non-public addresses are not allowed")
+
+// sharedAddressSpace is the carrier-grade NAT range (RFC 6598), which netip does not
+// consider private.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// NewOutboundHTTPClient returns an HTTP client for fetching URLs that clients and
+// issuers supply, such as JWKS URLs and request URIs. Unless allowPrivateNetworks is set,
+// it refuses to connect to loopback, private, link-local and other non-public addresses,
+// so that those URLs cannot be used to reach internal services. The check is made on the
+// resolved address of every connection, redirects included.
+func NewOutboundHTTPClient(allowPrivateNetworks bool) *http.Client {
+	dialer := &net.Dialer{Timeout: outboundRequestTimeout}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if !allowPrivateNetworks {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return fmt.Errorf("invalid outbound address %q: %w", address, err)
+			}
+			if !IsPublicAddr(addrPort.Addr()) {
+				return fmt.Errorf("%w: %s", ErrNonPublicAddress, addrPort.Addr())
+			}
+			return nil
+		}
+		// A proxy would be the only address checked, so requests are always made directly.
+		transport.Proxy = nil
+	}
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{Timeout: outboundRequestTimeout, Transport: transport}
+}
+
+// IsPublicAddr reports whether addr is a globally routable unicast address.
+func IsPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsGlobalUnicast() && !addr.IsPrivate() && !sharedAddressSpace.Contains(addr)
+}
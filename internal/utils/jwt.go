@@ -2,116 +2,289 @@
 package utils
 
 import (
-	"crypto/rsa"
-	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/aminshahid573/authexa/internal/config"
+	"github.com/aminshahid573/authexa/internal/models"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"github.com/lestrrat-go/jwx/v2/jwk"
 )
 
-// CustomClaims defines the structure of our JWT claims.
+// AccessTokenType is the "typ" header of access tokens (RFC 9068 section 2.1).
+const AccessTokenType = "at+jwt"
+
+// AuthorizationResponseType is the "typ" header of JARM authorization responses, which sets
+// them apart from ID tokens signed with the same keys.
+const AuthorizationResponseType = "oauth-authz-resp+jwt"
+
+// CustomClaims defines the structure of our JWT access token claims (RFC 9068 section 2.2).
 type CustomClaims struct {
-	Scope    []string `json:"scope,omitempty"`
-	ClientID string   `json:"client_id"`
+	Scope    ScopeClaim `json:"scope,omitempty"`
+	ClientID string     `json:"client_id"`
 	jwt.RegisteredClaims
+
+	// Authentication information of the user the token was issued for, if any.
+	AuthTime int64    `json:"auth_time,omitempty"`
+	AMR      []string `json:"amr,omitempty"`
+	ACR      string   `json:"acr,omitempty"`
+	Roles    []string `json:"roles,omitempty"`
+
+	// Act is the delegation chain of a token obtained by token exchange.
+	Act *models.Actor `json:"act,omitempty"`
+}
+
+// ScopeClaim is the "scope" claim of an access token, encoded as a space-delimited string.
+// Tokens issued before RFC 9068 carry a JSON array, which is still decoded.
+type ScopeClaim []string
+
+// MarshalJSON encodes the scopes as a space-delimited string.
+func (s ScopeClaim) MarshalJSON() ([]byte, error) {
+	return json.Marshal(strings.Join(s, " "))
+}
+
+// UnmarshalJSON decodes a space-delimited string or a JSON array of scopes.
+func (s *ScopeClaim) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err == nil {
+		*s = strings.Fields(str)
+		return nil
+	}
+	var scopes []string
+	if err := json.Unmarshal(data, &scopes); err != nil {
+		return fmt.Errorf("scope must be a string or an array of strings: %w", err)
+	}
+	*s = scopes
+	return nil
+}
+
+// AccessTokenParams describes the contents of an access token.
+type AccessTokenParams struct {
+	Subject  string
+	ClientID string
+	// Audience is the resource server the token is meant for, or the client itself when empty.
+	Audience string
+	Scopes   []string
+	AuthTime time.Time
+	AMR      []string
+	ACR      string
+	Roles    []string
+	Act      *models.Actor
+	// Alg is the JWS algorithm to sign with. Empty selects DefaultSigningAlgorithm.
+	Alg string
+	// ExpiresAt is when the token expires. Zero selects the configured access token lifespan.
+	ExpiresAt time.Time
 }
 
 // IDTokenClaims defines the structure for OpenID Connect ID Tokens.
 type IDTokenClaims struct {
 	jwt.RegisteredClaims
-	Nonce    string `json:"nonce,omitempty"`
-	AuthTime int64  `json:"auth_time,omitempty"`
+	Nonce    string   `json:"nonce,omitempty"`
+	AuthTime int64    `json:"auth_time,omitempty"`
+	AMR      []string `json:"amr,omitempty"`
+}
+
+// idTokenCandidate holds the claims of a token presented as an ID token, including those
+// that only other kinds of tokens carry.
+type idTokenCandidate struct {
+	IDTokenClaims
+	ClientID string     `json:"client_id,omitempty"`
+	Scope    ScopeClaim `json:"scope,omitempty"`
 }
 
 // JWTManager handles the creation and validation of JWTs.
+// Its keys are loaded, and replaced on rotation, by the key management service.
 type JWTManager struct {
-	privateKey           *rsa.PrivateKey
-	publicKey            *rsa.PublicKey
-	keyID                string
+	mu sync.RWMutex
+	// activeKeys holds the key that signs new tokens for each algorithm. publishedKeys are
+	// advertised in the JWKS and accepted for verification, which lets tokens outlive a rotation.
+	activeKeys    map[string]*SigningKey
+	publishedKeys []*SigningKey
+
 	issuer               string
 	accessTokenLifespan  time.Duration
 	refreshTokenLifespan time.Duration
+
+	acceptLegacyAccessTokens bool
+	accessTokenRolesClaim    bool
 }
 
-// NewJWTManager creates a new JWTManager, generating a new RSA key pair on startup.
-func NewJWTManager(cfg config.JWTConfig) (*JWTManager, error) {
-	pemData, err := base64.StdEncoding.DecodeString(cfg.PrivateKeyBase64)
-	if err != nil {
-		return nil, fmt.Errorf("failed to base64 decode private key: %w", err)
-	}
+// authorizationResponseLifespan is how long a JARM response JWT is valid. It only has to
+// survive the redirect back to the client.
+const authorizationResponseLifespan = 10 * time.Minute
 
-	privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(pemData)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse RSA private key from PEM: %w", err)
-	}
+// ErrNoSigningKey is returned when no key has been loaded for the requested algorithm.
+var ErrNoSigningKey = errors.New("no active signing key")
 
-	// Generate a unique ID for this key.
-	keyID := uuid.NewString()
-
-	slog.Info("new RSA key pair generated for JWT signing", "key_id", keyID)
+// DefaultSigningAlgorithm is used when a client has not chosen a signing algorithm.
+var DefaultSigningAlgorithm = jwt.SigningMethodRS256.Alg()
 
+// NewJWTManager creates a new JWTManager without keys. SetKeys must be called before tokens are issued.
+func NewJWTManager(cfg config.JWTConfig) *JWTManager {
 	return &JWTManager{
-		privateKey:           privateKey,
-		publicKey:            &privateKey.PublicKey,
-		keyID:                keyID,
 		issuer:               cfg.Issuer,
 		accessTokenLifespan:  cfg.AccessTokenLifespan,
 		refreshTokenLifespan: cfg.RefreshTokenLifespan,
-	}, nil
+
+		acceptLegacyAccessTokens: cfg.AcceptLegacyAccessTokens,
+		accessTokenRolesClaim:    cfg.AccessTokenRolesClaim,
+	}
 }
 
-// GetPublicKeySet returns the public key as a JWK Set.
-func (m *JWTManager) GetPublicKeySet() (jwk.Set, error) {
-	key, err := jwk.FromRaw(m.publicKey)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create JWK from public key: %w", err)
+// SetKeys atomically replaces the signing keys and the set of published verification keys.
+// At most one active key per algorithm is expected.
+func (m *JWTManager) SetKeys(active []*SigningKey, published []*SigningKey) {
+	activeKeys := make(map[string]*SigningKey, len(active))
+	for _, k := range active {
+		activeKeys[k.Algorithm] = k
 	}
 
-	key.Set(jwk.KeyIDKey, m.keyID)
-	key.Set(jwk.AlgorithmKey, "RS256")
-	key.Set(jwk.KeyUsageKey, jwk.ForSignature)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for alg, k := range activeKeys {
+		if current, ok := m.activeKeys[alg]; !ok || current.KeyID != k.KeyID {
+			slog.Info("JWT signing key activated", "key_id", k.KeyID, "alg", alg)
+		}
+	}
+	m.activeKeys = activeKeys
+	m.publishedKeys = published
+}
+
+// GetPublicKeySet returns every published public key as a JWK Set.
+func (m *JWTManager) GetPublicKeySet() (jwk.Set, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 
 	keySet := jwk.NewSet()
-	keySet.AddKey(key)
+	for _, k := range m.publishedKeys {
+		key, err := jwk.FromRaw(k.PrivateKey.Public())
+		if err != nil {
+			return nil, fmt.Errorf("failed to create JWK from public key: %w", err)
+		}
+
+		key.Set(jwk.KeyIDKey, k.KeyID)
+		key.Set(jwk.AlgorithmKey, k.Algorithm)
+		if IsEncryptionAlgorithm(k.Algorithm) {
+			key.Set(jwk.KeyUsageKey, jwk.ForEncryption)
+		} else {
+			key.Set(jwk.KeyUsageKey, jwk.ForSignature)
+		}
+		keySet.AddKey(key)
+	}
 	return keySet, nil
 }
 
-// GenerateAccessToken creates a new JWT access token signed with the private key.
-func (m *JWTManager) GenerateAccessToken(userID, clientID string, scopes []string) (string, error) {
+// sign signs the claims with the active key for alg, setting the "kid" header so verifiers
+// can select the right key. An empty alg selects DefaultSigningAlgorithm.
+func (m *JWTManager) sign(claims jwt.Claims, alg string) (string, error) {
+	return m.signWithType(claims, alg, "")
+}
+
+// signWithType signs the claims like sign, with typ as the "typ" header instead of "JWT".
+func (m *JWTManager) signWithType(claims jwt.Claims, alg, typ string) (string, error) {
+	if alg == "" {
+		alg = DefaultSigningAlgorithm
+	}
+
+	m.mu.RLock()
+	key := m.activeKeys[alg]
+	m.mu.RUnlock()
+
+	if key == nil {
+		return "", fmt.Errorf("%w for %s", ErrNoSigningKey, alg)
+	}
+
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(key.Algorithm), claims)
+	token.Header["kid"] = key.KeyID
+	if typ != "" {
+		token.Header["typ"] = typ
+	}
+	return token.SignedString(key.PrivateKey)
+}
+
+// keyFunc resolves the verification key named by a token's "kid" header.
+func (m *JWTManager) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, k := range m.publishedKeys {
+		if k.KeyID != kid || IsEncryptionAlgorithm(k.Algorithm) {
+			continue
+		}
+		// Ensure the signing method is the one the key was issued for.
+		if token.Method.Alg() != k.Algorithm {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return k.PrivateKey.Public(), nil
+	}
+	return nil, fmt.Errorf("unknown signing key: %q", kid)
+}
+
+// DecryptionKey returns the published encryption key with the given "kid", which a client
+// may have encrypted a request object to.
+func (m *JWTManager) DecryptionKey(kid string) (*SigningKey, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, k := range m.publishedKeys {
+		if k.KeyID == kid && IsEncryptionAlgorithm(k.Algorithm) {
+			return k, true
+		}
+	}
+	return nil, false
+}
+
+// GenerateAccessToken creates a new RFC 9068 access token signed with the active key for
+// params.Alg. The claims are returned alongside the token so callers can track its "jti".
+func (m *JWTManager) GenerateAccessToken(params AccessTokenParams) (string, *CustomClaims, error) {
+	audience := params.Audience
+	if audience == "" {
+		audience = params.ClientID
+	}
 	now := time.Now()
+	expiresAt := params.ExpiresAt
+	if expiresAt.IsZero() {
+		expiresAt = now.Add(m.accessTokenLifespan)
+	}
 	claims := CustomClaims{
-		Scope:    scopes,
-		ClientID: clientID,
+		Scope:    params.Scopes,
+		ClientID: params.ClientID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			Issuer:    m.issuer,
-			Subject:   userID,
-			Audience:  jwt.ClaimStrings{clientID},
-			ExpiresAt: jwt.NewNumericDate(now.Add(m.accessTokenLifespan)),
+			Subject:   params.Subject,
+			Audience:  jwt.ClaimStrings{audience},
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
 			NotBefore: jwt.NewNumericDate(now),
 			IssuedAt:  jwt.NewNumericDate(now),
 			ID:        uuid.NewString(),
 		},
+		AMR:   params.AMR,
+		ACR:   params.ACR,
+		Roles: params.Roles,
+		Act:   params.Act,
+	}
+	if !params.AuthTime.IsZero() {
+		claims.AuthTime = params.AuthTime.Unix()
 	}
 
-	// Use RS256 signing method
-	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
-	// Set the 'kid' (Key ID) in the header
-	token.Header["kid"] = m.keyID
-
-	signedToken, err := token.SignedString(m.privateKey)
+	signedToken, err := m.signWithType(claims, params.Alg, AccessTokenType)
 	if err != nil {
-		return "", fmt.Errorf("failed to sign access token: %w", err)
+		return "", nil, fmt.Errorf("failed to sign access token: %w", err)
 	}
-	return signedToken, nil
+	return signedToken, &claims, nil
 }
 
-// GenerateIDToken creates a new OIDC ID token signed with the private key.
-func (m *JWTManager) GenerateIDToken(userID, clientID string, nonce string, authTime time.Time) (string, error) {
+// GenerateIDToken creates a new OIDC ID token signed with the active key for alg.
+func (m *JWTManager) GenerateIDToken(userID, clientID string, nonce string, authTime time.Time, amr []string, alg string) (string, error) {
 	now := time.Now()
 	claims := IDTokenClaims{
 		RegisteredClaims: jwt.RegisteredClaims{
@@ -129,40 +302,136 @@ func (m *JWTManager) GenerateIDToken(userID, clientID string, nonce string, auth
 	if !authTime.IsZero() {
 		claims.AuthTime = authTime.Unix()
 	}
+	claims.AMR = amr
 
-	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
-	token.Header["kid"] = m.keyID
-
-	signedToken, err := token.SignedString(m.privateKey)
+	signedToken, err := m.sign(claims, alg)
 	if err != nil {
 		return "", fmt.Errorf("failed to sign ID token: %w", err)
 	}
 	return signedToken, nil
 }
 
-// VerifyToken parses and validates a token string using the public key.
+// GenerateAuthorizationResponse signs the parameters of an authorization response as a
+// JARM response JWT addressed to the client.
+func (m *JWTManager) GenerateAuthorizationResponse(clientID string, params map[string]string, alg string) (string, error) {
+	claims := jwt.MapClaims{
+		"iss": m.issuer,
+		"aud": clientID,
+		"exp": time.Now().Add(authorizationResponseLifespan).Unix(),
+	}
+	for key, value := range params {
+		claims[key] = value
+	}
+
+	signedToken, err := m.signWithType(claims, alg, AuthorizationResponseType)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign authorization response: %w", err)
+	}
+	return signedToken, nil
+}
+
+// VerifyToken parses and validates an access token against the published keys. Tokens must
+// have the "at+jwt" type; untyped and "JWT" tokens issued before the RFC 9068 profile are
+// accepted only while legacy access tokens are enabled.
 func (m *JWTManager) VerifyToken(tokenString string) (*CustomClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &CustomClaims{}, func(token *jwt.Token) (interface{}, error) {
-		// Ensure the signing method is what we expect (RS256)
-		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		// Return the public key for verification
-		return m.publicKey, nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, &CustomClaims{}, m.keyFunc)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse token: %w", err)
 	}
 
-	if claims, ok := token.Claims.(*CustomClaims); ok && token.Valid {
-		return claims, nil
+	claims, ok := token.Claims.(*CustomClaims)
+	if !ok || !token.Valid {
+		return nil, fmt.Errorf("invalid token")
 	}
 
-	return nil, fmt.Errorf("invalid token")
+	typ, _ := token.Header["typ"].(string)
+	switch {
+	case isAccessTokenType(typ):
+	case m.acceptLegacyAccessTokens && (typ == "" || strings.EqualFold(typ, "JWT")):
+		// ID tokens share the legacy type, but never carry a client_id.
+		if claims.ClientID == "" {
+			return nil, fmt.Errorf("token is not an access token")
+		}
+	default:
+		return nil, fmt.Errorf("unexpected token type %q", typ)
+	}
+	return claims, nil
+}
+
+// VerifyIDToken parses and validates an ID token issued by this server, including its expiry.
+func (m *JWTManager) VerifyIDToken(tokenString string) (*IDTokenClaims, error) {
+	claims, err := m.parseIDToken(tokenString)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ID token: %w", err)
+	}
+	return claims, nil
+}
+
+// parseIDToken verifies a token issued by this server and checks that it is an ID token.
+// Access tokens and JARM responses are signed with the same keys, so they are told apart
+// by their type and claims: only ID tokens have the plain "JWT" type, a subject and an
+// audience, and no client_id or scope.
+func (m *JWTManager) parseIDToken(tokenString string, opts ...jwt.ParserOption) (*IDTokenClaims, error) {
+	var claims idTokenCandidate
+	token, err := jwt.ParseWithClaims(tokenString, &claims, m.keyFunc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Issuer != m.issuer {
+		return nil, fmt.Errorf("unexpected issuer %q", claims.Issuer)
+	}
+	if typ, _ := token.Header["typ"].(string); typ != "" && !strings.EqualFold(typ, "JWT") {
+		return nil, fmt.Errorf("unexpected token type %q", typ)
+	}
+	if claims.Subject == "" || len(claims.Audience) == 0 || claims.ClientID != "" || len(claims.Scope) > 0 {
+		return nil, fmt.Errorf("token is not an ID token")
+	}
+	return &claims.IDTokenClaims, nil
+}
+
+// isAccessTokenType reports whether typ identifies an RFC 9068 access token. Media types
+// are case-insensitive and may carry the "application/" prefix (RFC 7515 section 4.1.9).
+func isAccessTokenType(typ string) bool {
+	return strings.EqualFold(typ, AccessTokenType) || strings.EqualFold(typ, "application/"+AccessTokenType)
+}
+
+// ParseIDTokenHint verifies the signature of an ID token previously issued by this server.
+// Expiry is deliberately not enforced, as OIDC allows expired ID tokens to be used as hints.
+func (m *JWTManager) ParseIDTokenHint(tokenString string) (*IDTokenClaims, error) {
+	claims, err := m.parseIDToken(tokenString, jwt.WithoutClaimsValidation())
+	if err != nil {
+		return nil, fmt.Errorf("invalid id token hint: %w", err)
+	}
+	return claims, nil
 }
 
 // GetAccessTokenLifespan returns the configured lifespan for access tokens.
 func (m *JWTManager) GetAccessTokenLifespan() time.Duration {
 	return m.accessTokenLifespan
 }
+
+// AccessTokenRolesClaim reports whether access tokens carry the user's role in a "roles" claim.
+func (m *JWTManager) AccessTokenRolesClaim() bool {
+	return m.accessTokenRolesClaim
+}
+
+// GetIssuer returns the issuer identifier placed in the "iss" claim of every token.
+func (m *JWTManager) GetIssuer() string {
+	return m.issuer
+}
+
+// SigningAlgorithms returns the JWS algorithms this manager currently holds an active key for,
+// in the order of SupportedSigningAlgorithms.
+func (m *JWTManager) SigningAlgorithms() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var algs []string
+	for _, alg := range SupportedSigningAlgorithms {
+		if _, ok := m.activeKeys[alg]; ok {
+			algs = append(algs, alg)
+		}
+	}
+	return algs
+}
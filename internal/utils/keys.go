@@ -0,0 +1,147 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+)
+
+// rsaKeyBits is the modulus size of generated RSA signing keys.
+const rsaKeyBits = 2048
+
+// SupportedSigningAlgorithms lists the JWS algorithms tokens can be signed with.
+// RS256 comes first, as it is the default and the algorithm OpenID Connect requires.
+var SupportedSigningAlgorithms = []string{
+	jwt.SigningMethodRS256.Alg(),
+	jwt.SigningMethodPS256.Alg(),
+	jwt.SigningMethodES256.Alg(),
+	jwt.SigningMethodEdDSA.Alg(),
+}
+
+// SupportedEncryptionAlgorithms lists the JWE key management algorithms clients can
+// encrypt request objects to us with. Each has its own key, published with "use" set to "enc".
+var SupportedEncryptionAlgorithms = []string{
+	string(jwa.RSA_OAEP_256),
+	string(jwa.ECDH_ES),
+}
+
+// ManagedKeyAlgorithms lists every algorithm the key management service keeps keys for.
+var ManagedKeyAlgorithms = append(slices.Clone(SupportedSigningAlgorithms), SupportedEncryptionAlgorithms...)
+
+// IsEncryptionAlgorithm reports whether alg is a JWE key management algorithm rather than a JWS one.
+func IsEncryptionAlgorithm(alg string) bool {
+	return slices.Contains(SupportedEncryptionAlgorithms, alg)
+}
+
+// SigningKey is a private key the JWTManager can sign and verify tokens with. Keys for
+// an encryption algorithm are instead used to decrypt what clients encrypt to us.
+type SigningKey struct {
+	KeyID      string
+	Algorithm  string
+	PrivateKey crypto.Signer
+}
+
+// NewSigningKey wraps a private key, deriving its key ID from the RFC 7638 thumbprint
+// of the public key. The same key therefore always has the same kid, on every replica.
+func NewSigningKey(alg string, privateKey crypto.Signer) (*SigningKey, error) {
+	if !keyMatchesAlgorithm(alg, privateKey) {
+		return nil, fmt.Errorf("private key of type %T cannot be used for %s", privateKey, alg)
+	}
+
+	key, err := jwk.FromRaw(privateKey.Public())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JWK from public key: %w", err)
+	}
+	thumbprint, err := key.Thumbprint(crypto.SHA256)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute key thumbprint: %w", err)
+	}
+
+	return &SigningKey{
+		KeyID:      base64.RawURLEncoding.EncodeToString(thumbprint),
+		Algorithm:  alg,
+		PrivateKey: privateKey,
+	}, nil
+}
+
+// GenerateSigningKey creates a new random private key for the given signing or encryption algorithm.
+func GenerateSigningKey(alg string) (*SigningKey, error) {
+	var privateKey crypto.Signer
+	var err error
+
+	switch alg {
+	case jwt.SigningMethodRS256.Alg(), jwt.SigningMethodPS256.Alg(), string(jwa.RSA_OAEP_256):
+		privateKey, err = rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	case jwt.SigningMethodES256.Alg(), string(jwa.ECDH_ES):
+		privateKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case jwt.SigningMethodEdDSA.Alg():
+		_, privateKey, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return nil, fmt.Errorf("unsupported key algorithm: %s", alg)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate %s key: %w", alg, err)
+	}
+	return NewSigningKey(alg, privateKey)
+}
+
+// EncodePrivateKeyPEM encodes a private key as a PKCS #8 PEM block.
+func EncodePrivateKeyPEM(privateKey crypto.Signer) (string, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal private key: %w", err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})), nil
+}
+
+// ParsePrivateKeyPEM decodes a PKCS #8 or PKCS #1 PEM-encoded private key.
+func ParsePrivateKeyPEM(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("failed to decode PEM block")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("private key cannot be used for signing")
+	}
+	return signer, nil
+}
+
+// keyMatchesAlgorithm reports whether a private key is of the type the algorithm requires.
+func keyMatchesAlgorithm(alg string, privateKey crypto.Signer) bool {
+	switch alg {
+	case jwt.SigningMethodRS256.Alg(), jwt.SigningMethodPS256.Alg(), string(jwa.RSA_OAEP_256):
+		_, ok := privateKey.(*rsa.PrivateKey)
+		return ok
+	case jwt.SigningMethodES256.Alg(), string(jwa.ECDH_ES):
+		key, ok := privateKey.(*ecdsa.PrivateKey)
+		return ok && key.Curve == elliptic.P256()
+	case jwt.SigningMethodEdDSA.Alg():
+		_, ok := privateKey.(ed25519.PrivateKey)
+		return ok
+	default:
+		return false
+	}
+}
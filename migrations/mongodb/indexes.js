@@ -20,6 +20,36 @@ print("Created index on clients.client_id");
 db.tokens.createIndex({ "signature": 1 });
 // Create a TTL index on 'expires_at' to automatically delete expired documents.
 db.tokens.createIndex({ "expires_at": 1 }, { expireAfterSeconds: 0 });
+// Create an index on 'family_id' to revoke every refresh token of a family on reuse.
+db.tokens.createIndex({ "family_id": 1 }, { sparse: true });
+// Create an index on 'grant_id' to delete the reference access tokens of a grant.
+db.tokens.createIndex({ "grant_id": 1 }, { sparse: true });
 print("Created indexes on tokens collection");
 
+// --- Signing Keys Collection ---
+// Create a unique index on the key ID and an index on the lifecycle state.
+db.signing_keys.createIndex({ "kid": 1 }, { unique: true });
+db.signing_keys.createIndex({ "state": 1 });
+print("Created indexes on signing_keys collection");
+
+// --- Consent Grants Collection ---
+// Create a unique index on the user and client, as each user has one grant per client.
+db.consent_grants.createIndex({ "user_id": 1, "client_id": 1 }, { unique: true });
+print("Created index on consent_grants collection");
+
+// --- Scopes Collection ---
+// Create a unique index on the scope name, so that concurrent creates cannot register it twice.
+db.scopes.createIndex({ "name": 1 }, { unique: true });
+print("Created index on scopes.name");
+
+// --- Resource Servers Collection ---
+// Create a unique index on the resource identifier.
+db.resource_servers.createIndex({ "identifier": 1 }, { unique: true });
+print("Created index on resource_servers.identifier");
+
+// --- Trusted Issuers Collection ---
+// Create a unique index on the issuer identifier, which assertions are matched by.
+db.trusted_issuers.createIndex({ "issuer": 1 }, { unique: true });
+print("Created index on trusted_issuers.issuer");
+
 print("Index creation complete.");
\ No newline at end of file
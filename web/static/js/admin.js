@@ -230,6 +230,7 @@ function setupClientPageEventListeners() {
     addClientBtn.addEventListener('click', () => {
         clientForm.reset();
         clientForm.removeAttribute('data-editing-client-id');
+        clientForm.elements.token_endpoint_auth_method.disabled = false;
         document.getElementById('modalTitle').textContent = 'Add New Client';
         clientModal.classList.remove('hidden');
     });
@@ -270,7 +271,11 @@ async function handleEditClick(clientID) {
         document.getElementById('modalTitle').textContent = `Edit Client: ${client.name}`;
         form.elements.name.value = client.name;
         form.elements.redirect_uris.value = client.redirect_uris.join('\n');
+        form.elements.post_logout_redirect_uris.value = (client.post_logout_redirect_uris || []).join('\n');
         form.elements.scopes.value = client.scopes.join(' ');
+        form.elements.required_scopes.value = (client.required_scopes || []).join(' ');
+        form.elements.token_exchange_audiences.value = (client.token_exchange_audiences || []).join(' ');
+        form.elements.token_exchange_scopes.value = (client.token_exchange_scopes || []).join(' ');
 
         // Check the correct checkboxes for grant_types and response_types
         client.grant_types.forEach(type => {
@@ -281,6 +286,22 @@ async function handleEditClick(clientID) {
             const checkbox = form.querySelector(`input[name="response_types"][value="${type}"]`);
             if (checkbox) checkbox.checked = true;
         });
+        form.elements.refresh_token_rotation_disabled.checked = !!client.refresh_token_rotation_disabled;
+        form.elements.require_pushed_authorization_requests.checked = !!client.require_pushed_authorization_requests;
+        form.elements.require_signed_request_object.checked = !!client.require_signed_request_object;
+        form.elements.trusted.checked = !!client.trusted;
+        // The authentication method is fixed at creation time.
+        form.elements.token_endpoint_auth_method.value = client.token_endpoint_auth_method || '';
+        form.elements.token_endpoint_auth_method.disabled = true;
+        form.elements.jwks_url.value = client.jwks_url || '';
+        form.elements.jwks.value = client.jwks ? JSON.stringify(client.jwks, null, 2) : '';
+        form.elements.id_token_signed_response_alg.value = client.id_token_signed_response_alg || '';
+        form.elements.access_token_signing_alg.value = client.access_token_signing_alg || '';
+        form.elements.access_token_format.value = client.access_token_format || '';
+        form.elements.default_response_mode.value = client.default_response_mode || '';
+        form.elements.authorization_signed_response_alg.value = client.authorization_signed_response_alg || '';
+        form.elements.request_object_signing_alg.value = client.request_object_signing_alg || '';
+        form.elements.request_uris.value = (client.request_uris || []).join('\n');
 
         document.getElementById('clientModal').classList.remove('hidden');
     } catch (error) {
@@ -299,12 +320,40 @@ async function handleClientFormSubmit(event) {
     const formData = new FormData(form);
     const csrfToken = form.querySelector('input[name="_csrf"]').value;
 
+    let jwks;
+    if (formData.get('jwks').trim()) {
+        try {
+            jwks = JSON.parse(formData.get('jwks'));
+        } catch (error) {
+            showNotification('The JWKS is not valid JSON.', 'error');
+            return;
+        }
+    }
+
     const payload = {
         name: formData.get('name'),
         redirect_uris: formData.get('redirect_uris').split('\n').map(uri => uri.trim()).filter(uri => uri),
+        post_logout_redirect_uris: formData.get('post_logout_redirect_uris').split('\n').map(uri => uri.trim()).filter(uri => uri),
         grant_types: formData.getAll('grant_types'),
         response_types: formData.getAll('response_types'),
         scopes: formData.get('scopes').split(' ').map(s => s.trim()).filter(s => s),
+        required_scopes: formData.get('required_scopes').split(' ').map(s => s.trim()).filter(s => s),
+        token_exchange_audiences: formData.get('token_exchange_audiences').split(' ').map(s => s.trim()).filter(s => s),
+        token_exchange_scopes: formData.get('token_exchange_scopes').split(' ').map(s => s.trim()).filter(s => s),
+        token_endpoint_auth_method: formData.get('token_endpoint_auth_method') || '',
+        jwks_url: formData.get('jwks_url'),
+        jwks: jwks,
+        refresh_token_rotation_disabled: formData.get('refresh_token_rotation_disabled') === 'on',
+        require_pushed_authorization_requests: formData.get('require_pushed_authorization_requests') === 'on',
+        id_token_signed_response_alg: formData.get('id_token_signed_response_alg'),
+        access_token_signing_alg: formData.get('access_token_signing_alg'),
+        access_token_format: formData.get('access_token_format'),
+        default_response_mode: formData.get('default_response_mode'),
+        authorization_signed_response_alg: formData.get('authorization_signed_response_alg'),
+        request_object_signing_alg: formData.get('request_object_signing_alg'),
+        request_uris: formData.get('request_uris').split('\n').map(uri => uri.trim()).filter(uri => uri),
+        require_signed_request_object: formData.get('require_signed_request_object') === 'on',
+        trusted: formData.get('trusted') === 'on',
     };
 
     try {
@@ -358,7 +407,7 @@ async function deleteClient(clientID) {
 
 function showSecretModal(clientID, clientSecret) {
     document.getElementById('newClientId').textContent = clientID;
-    document.getElementById('newClientSecret').textContent = clientSecret;
+    document.getElementById('newClientSecret').textContent = clientSecret || '(none: the client does not use a secret)';
     document.getElementById('secretModal').classList.remove('hidden');
 }
 